@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"favorites/internal/auth"
+	"favorites/internal/models/apikey"
+	"favorites/internal/repository"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+// apiKeyCommand mints and revokes API keys from the command line, which is
+// how the first admin key of a project is created:
+//
+//	favorites apikey create -project <id> -name <name> -scopes read,write [-ttl 720h]
+//	favorites apikey revoke -project <id> -id <key id>
+func apiKeyCommand(storage repository.Storage, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Expected apikey create or apikey revoke")
+	}
+	flags := flag.NewFlagSet("apikey "+args[0], flag.ExitOnError)
+	project := flags.String("project", "", "project the key is valid for")
+	switch args[0] {
+	case "create":
+		name := flags.String("name", "", "name describing the key's holder")
+		scopes := flags.String("scopes", "read", "comma separated scopes: read, write, admin")
+		ttl := flags.Duration("ttl", 0, "lifetime of the key, forever if 0")
+		_ = flags.Parse(args[1:])
+		createAPIKey(storage, parseProject(*project), *name, *scopes, *ttl)
+	case "revoke":
+		id := flags.String("id", "", "id of the key to revoke")
+		_ = flags.Parse(args[1:])
+		keyID, err := uuid.Parse(*id)
+		if err != nil {
+			log.Fatalf("Invalid -id: %v", err)
+		}
+		err = storage.APIKeys.RevokeAPIKey(context.Background(), parseProject(*project), keyID)
+		if err != nil {
+			log.Fatalf("Failed to revoke API key: %v", err)
+		}
+		log.Printf("Revoked API key %s", keyID)
+	default:
+		log.Fatalf("Unknown apikey command %q, expected create or revoke", args[0])
+	}
+}
+
+func parseProject(project string) uuid.UUID {
+	projectID, err := uuid.Parse(project)
+	if err != nil {
+		log.Fatalf("Invalid -project: %v", err)
+	}
+	return projectID
+}
+
+func createAPIKey(storage repository.Storage, projectID uuid.UUID, name, rawScopes string, ttl time.Duration) {
+	if name == "" {
+		log.Fatal("-name is required")
+	}
+	var scopes []apikey.Scope
+	for _, scope := range strings.Split(rawScopes, ",") {
+		if !apikey.IsValidScope(scope) {
+			log.Fatalf("Invalid scope %q, expected read, write or admin", scope)
+		}
+		scopes = append(scopes, apikey.Scope(scope))
+	}
+	var expiresAt *time.Time
+	if ttl > 0 {
+		expiration := time.Now().UTC().Add(ttl)
+		expiresAt = &expiration
+	}
+	k, secret := auth.NewAPIKey(projectID, name, scopes, expiresAt)
+	if err := storage.APIKeys.CreateAPIKey(context.Background(), &k); err != nil {
+		log.Fatalf("Failed to create API key: %v", err)
+	}
+	log.Printf("Created API key %s", k.ID)
+	// The token goes to stdout alone so that it can be piped into a secret store.
+	fmt.Println(auth.APIKeyToken(k, secret))
+}
@@ -1,42 +1,154 @@
 package main
 
 import (
+	"context"
+	"favorites/config"
 	_ "favorites/docs"
 	"favorites/internal/db"
+	"favorites/internal/dispatch"
 	"favorites/internal/handlers"
+	"favorites/internal/objects"
+	"favorites/internal/purge"
+	"favorites/internal/relay"
+	"favorites/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"log"
 	"os"
+	"time"
 )
 
+// webhookTimeout bounds a single delivery to the outbox webhook and a single
+// request to the object resolver.
+const webhookTimeout = 10 * time.Second
+
 // @title			Favorites API
 // @version		1.0
 // @description	A favorites management service API in Go using Gin framework.
 // @host			localhost:8080
-// @BasePath		/favorites
+// @BasePath		/
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				JWT as "Bearer <token>"
+// @securityDefinitions.apikey	APIKeyAuth
+// @in							header
+// @name						X-API-Key
+// @description				API key of a backend service, valid for one project
 func main() {
-	dbConn, err := db.ConnectDB()
-	if err != nil {
-		panic(err)
+	cfg := config.LoadConfig()
+	storage, closeStorage := openStorage(cfg)
+	defer closeStorage()
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	switch command {
+	case "serve":
+		serve(storage, cfg)
+	case "repair-counts":
+		repairCounts(storage)
+	case "apikey":
+		apiKeyCommand(storage, os.Args[2:])
+	default:
+		log.Fatalf("Unknown command %q, expected serve, repair-counts or apikey", command)
 	}
-	defer func(dbConn *sqlx.DB) {
-		err := dbConn.Close()
+}
+
+func openStorage(cfg config.Config) (repository.Storage, func()) {
+	switch cfg.Storage {
+	case config.StorageMemory:
+		return repository.NewMemoryStorage(), func() {}
+	case config.StoragePostgres:
+		dbConn, err := db.ConnectDB()
 		if err != nil {
 			panic(err)
 		}
-	}(dbConn)
-	err = db.RunMigrations(dbConn, "file:///app/internal/db/migrations")
+		err = db.RunMigrations(dbConn, "file:///app/internal/db/migrations")
+		if err != nil {
+			panic(err)
+		}
+		return repository.NewPostgresStorage(dbConn, cfg.DbUrl), func() {
+			closeDB(dbConn)
+		}
+	default:
+		panic("unknown STORAGE_BACKEND: " + cfg.Storage)
+	}
+}
+
+func closeDB(dbConn *sqlx.DB) {
+	err := dbConn.Close()
 	if err != nil {
 		panic(err)
 	}
+}
+
+func serve(storage repository.Storage, cfg config.Config) {
+	go purge.NewPurger(storage.Favorites, cfg.DeletedRetention, cfg.PurgeInterval).Run(context.Background())
+	publishers := relay.Publishers{dispatch.NewFanout(storage.Webhooks)}
+	if publisher := newPublisher(cfg); publisher != nil {
+		publishers = append(publishers, publisher)
+	}
+	go relay.NewRelay(storage.Outbox, publishers, cfg.OutboxBatchSize, cfg.OutboxInterval, cfg.OutboxRetention).
+		Run(context.Background())
+	go dispatch.NewDispatcher(storage.Webhooks, dispatch.Options{
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Backoff:     cfg.WebhookRetryBase,
+		MaxBackoff:  cfg.WebhookRetryMax,
+		Timeout:     cfg.WebhookTimeout,
+		BatchSize:   cfg.OutboxBatchSize,
+		Interval:    cfg.WebhookInterval,
+		Retention:   cfg.WebhookDeliveryRetention,
+	}).Run(context.Background())
+	if cfg.ObjectResolverURL != "" {
+		resolver := objects.NewHTTPResolver(cfg.ObjectResolverURL, webhookTimeout)
+		ingester := objects.NewIngester(storage.Favorites, storage.Objects, cfg.EraseChunkSize)
+		go objects.NewReconciler(storage.Favorites, storage.Objects, resolver, ingester, cfg.ReconcileBatchSize, cfg.ReconcileInterval).
+			Run(context.Background())
+	}
 	r := gin.Default()
-	handlers.RegisterRoutes(dbConn, r)
+	handlers.RegisterRoutes(storage, cfg, r)
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	err = r.Run(":" + port)
+	err := r.Run(":" + port)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// newPublisher returns the publisher outbox messages are relayed to along
+// with the webhook subscriptions, or nil if none is configured.
+func newPublisher(cfg config.Config) relay.Publisher {
+	switch cfg.OutboxPublisher {
+	case config.PublisherNone:
+		return nil
+	case config.PublisherHTTP:
+		if cfg.OutboxWebhookURL == "" {
+			panic("OUTBOX_WEBHOOK_URL must be set for the http outbox publisher")
+		}
+		return relay.NewHTTPPublisher(cfg.OutboxWebhookURL, webhookTimeout)
+	case config.PublisherFile:
+		if cfg.OutboxFile == "" {
+			panic("OUTBOX_FILE must be set for the file outbox publisher")
+		}
+		publisher, err := relay.NewFilePublisher(cfg.OutboxFile)
+		if err != nil {
+			panic(err)
+		}
+		return publisher
+	default:
+		panic("unknown OUTBOX_PUBLISHER: " + cfg.OutboxPublisher)
+	}
+}
+
+// repairCounts recomputes the per-object favorite counters from the
+// favorites table, for when they drift from the base data.
+func repairCounts(storage repository.Storage) {
+	drifted, err := storage.Favorites.RepairFavoriteCounts(context.Background())
 	if err != nil {
 		panic(err)
 	}
+	log.Printf("Repaired %d drifted favorite counters", drifted)
 }
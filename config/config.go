@@ -1,13 +1,187 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+const (
+	PublisherNone = "none"
+	PublisherHTTP = "http"
+	PublisherFile = "file"
+)
+
+type SigningKey struct {
+	ID     string
+	Secret string
+}
 
 type Config struct {
-	DbUrl string
+	DbUrl   string
+	Storage string
+	// CursorSigningKeys sign pagination cursors. The first key signs new
+	// cursors, the rest only verify, which allows rotating keys.
+	CursorSigningKeys []SigningKey
+	CursorTTL         time.Duration
+	// IdempotencyWindow is how long responses to requests carrying an
+	// Idempotency-Key are replayed.
+	IdempotencyWindow time.Duration
+	// LookupMaxObjects caps the number of object ids in one lookup request.
+	LookupMaxObjects int
+	// BatchMaxItems caps the number of items in one batch create or delete.
+	BatchMaxItems int
+	// EraseChunkSize is how many favorites one transaction deletes when all
+	// favorites of an owner or an object are deleted.
+	EraseChunkSize int
+	// JWTSecret verifies HS256 tokens and JWKSFile points to the RS256 keys.
+	// At least one of them has to be set.
+	JWTSecret   string
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+	// JWTGroupsClaim names the token claim listing the caller's group ids.
+	JWTGroupsClaim string
+	// JWTProjectClaim names the token claim with the id of the only project
+	// the token is valid for.
+	JWTProjectClaim string
+	// RestoreWindow is how long a deleted favorite can be restored.
+	RestoreWindow time.Duration
+	// DeletedRetention is how long deleted favorites are kept before the
+	// purger, running every PurgeInterval, removes them for good.
+	DeletedRetention time.Duration
+	PurgeInterval    time.Duration
+	// OutboxPublisher selects where outbox messages are relayed to besides
+	// the webhook subscriptions of projects: nowhere, OutboxWebhookURL or the
+	// NDJSON file OutboxFile.
+	OutboxPublisher  string
+	OutboxWebhookURL string
+	OutboxFile       string
+	// OutboxBatchSize messages are relayed at a time every OutboxInterval,
+	// and published messages are kept for OutboxRetention.
+	OutboxBatchSize int
+	OutboxInterval  time.Duration
+	OutboxRetention time.Duration
+	// WebhookMaxAttempts is how many times a webhook delivery is attempted,
+	// waiting WebhookRetryBase after the first failure and twice as long
+	// after every next one, up to WebhookRetryMax.
+	WebhookMaxAttempts int
+	WebhookRetryBase   time.Duration
+	WebhookRetryMax    time.Duration
+	WebhookTimeout     time.Duration
+	// WebhookInterval is how often due deliveries are attempted, and
+	// finished ones are listed for WebhookDeliveryRetention.
+	WebhookInterval          time.Duration
+	WebhookDeliveryRetention time.Duration
+	// ObjectResolverURL is asked every ReconcileInterval, about up to
+	// ReconcileBatchSize objects at a time, whether objects were deleted or
+	// restored without an event. Objects are not reconciled if it is empty.
+	ObjectResolverURL  string
+	ReconcileInterval  time.Duration
+	ReconcileBatchSize int
+	// StreamHeartbeat is how often an idle stream of favorite changes sends
+	// a comment to keep the connection open.
+	StreamHeartbeat time.Duration
+	// SyncRetention is how long a change token stays valid. Clients with
+	// older tokens download their favorites afresh.
+	SyncRetention time.Duration
 }
 
 func LoadConfig() Config {
+	storage := os.Getenv("STORAGE_BACKEND")
+	if storage == "" {
+		storage = StoragePostgres
+	}
 	return Config{
-		DbUrl: os.Getenv("DATABASE_URL"),
+		DbUrl:                    os.Getenv("DATABASE_URL"),
+		Storage:                  storage,
+		CursorSigningKeys:        signingKeysFromEnv("CURSOR_SIGNING_KEYS"),
+		CursorTTL:                durationFromEnv("CURSOR_TTL", 24*time.Hour),
+		IdempotencyWindow:        durationFromEnv("IDEMPOTENCY_WINDOW", 24*time.Hour),
+		LookupMaxObjects:         intFromEnv("LOOKUP_MAX_OBJECTS", 100),
+		BatchMaxItems:            intFromEnv("BATCH_MAX_ITEMS", 1000),
+		EraseChunkSize:           intFromEnv("ERASE_CHUNK_SIZE", 500),
+		JWTSecret:                os.Getenv("JWT_SECRET"),
+		JWKSFile:                 os.Getenv("JWT_JWKS_FILE"),
+		JWTIssuer:                os.Getenv("JWT_ISSUER"),
+		JWTAudience:              os.Getenv("JWT_AUDIENCE"),
+		JWTGroupsClaim:           stringFromEnv("JWT_GROUPS_CLAIM", "groups"),
+		JWTProjectClaim:          stringFromEnv("JWT_PROJECT_CLAIM", "project_id"),
+		RestoreWindow:            durationFromEnv("RESTORE_WINDOW", 24*time.Hour),
+		DeletedRetention:         durationFromEnv("DELETED_RETENTION", 30*24*time.Hour),
+		PurgeInterval:            durationFromEnv("PURGE_INTERVAL", time.Hour),
+		OutboxPublisher:          stringFromEnv("OUTBOX_PUBLISHER", PublisherNone),
+		OutboxWebhookURL:         os.Getenv("OUTBOX_WEBHOOK_URL"),
+		OutboxFile:               os.Getenv("OUTBOX_FILE"),
+		OutboxBatchSize:          intFromEnv("OUTBOX_BATCH_SIZE", 100),
+		OutboxInterval:           durationFromEnv("OUTBOX_INTERVAL", time.Second),
+		OutboxRetention:          durationFromEnv("OUTBOX_RETENTION", 7*24*time.Hour),
+		WebhookMaxAttempts:       intFromEnv("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:         durationFromEnv("WEBHOOK_RETRY_BASE", 10*time.Second),
+		WebhookRetryMax:          durationFromEnv("WEBHOOK_RETRY_MAX", time.Hour),
+		WebhookTimeout:           durationFromEnv("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookInterval:          durationFromEnv("WEBHOOK_INTERVAL", time.Second),
+		WebhookDeliveryRetention: durationFromEnv("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour),
+		ObjectResolverURL:        os.Getenv("OBJECT_RESOLVER_URL"),
+		ReconcileInterval:        durationFromEnv("RECONCILE_INTERVAL", 24*time.Hour),
+		ReconcileBatchSize:       intFromEnv("RECONCILE_BATCH_SIZE", 100),
+		StreamHeartbeat:          durationFromEnv("STREAM_HEARTBEAT", 15*time.Second),
+		SyncRetention:            durationFromEnv("SYNC_RETENTION", 30*24*time.Hour),
+	}
+}
+
+func stringFromEnv(name string, fallback string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func intFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		log.Fatalf("Invalid %s: expected a positive integer", name)
+	}
+	return number
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Fatalf("Invalid %s: expected a positive duration", name)
+	}
+	return duration
+}
+
+// signingKeysFromEnv parses a comma separated list of id:secret pairs.
+func signingKeysFromEnv(name string) []SigningKey {
+	var keys []SigningKey
+	for _, pair := range strings.Split(os.Getenv(name), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || secret == "" {
+			log.Fatalf("Invalid %s: expected id:secret pairs", name)
+		}
+		keys = append(keys, SigningKey{ID: id, Secret: secret})
 	}
+	return keys
 }
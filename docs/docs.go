@@ -15,17 +15,180 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/favorites": {
+        "/projects/{project_id}/api-keys": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Mints an API key for backend services of the project and responds with it and its token.\nThe token is sent in the X-API-Key header and is shown only once; only a hash of it is stored.\nRequires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Create API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Key to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Revokes the API key of the project and responses with NoContent Code. Requires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Revoke API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of API key to revoke in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/audit": {
             "get": {
-                "description": "Responds with the page of favorites by owner_type, owner_id, limit and offset as JSON.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the page of the project's audit events as JSON, newest first. Every creation, deletion and restore of a favorite is recorded\nwith the caller that made it, the X-Request-ID of its request and snapshots of the favorite before and after the change.\nEvents can be narrowed to an owner, to an object and to a time range; owner_type and owner_id, as well as object_type and object_id, go together.\nPass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one. Requires the admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "favorites"
+                    "audit"
                 ],
-                "summary": "Get favorites array",
+                "summary": "Get audit log",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "size of page",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
                     {
                         "enum": [
                             "USER",
@@ -34,29 +197,54 @@ const docTemplate = `{
                         "type": "string",
                         "description": "type of owner",
                         "name": "owner_type",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "ID of owner in uuid format",
                         "name": "owner_id",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "size of page",
-                        "name": "limit",
-                        "in": "query",
-                        "required": true
+                        "enum": [
+                            "DOCUMENT",
+                            "IMAGE",
+                            "VIDEO"
+                        ],
+                        "type": "string",
+                        "description": "type of object",
+                        "name": "object_type",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "last id of previous page in base64 format",
-                        "name": "cursor",
-                        "in": "query",
-                        "required": true
+                        "description": "ID of object in uuid format",
+                        "name": "object_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only events recorded after the time, in RFC 3339 format",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only events recorded before the time, in RFC 3339 format",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Next-Cursor, returns the following page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Prev-Cursor, returns the preceding page",
+                        "name": "before",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -65,7 +253,21 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                                "$ref": "#/definitions/favorites_internal_models_audit.Event"
+                            }
+                        },
+                        "headers": {
+                            "X-Has-More": {
+                                "type": "string",
+                                "description": "whether a following page exists"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the following page, empty on the last page"
+                            },
+                            "X-Prev-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the preceding page, empty on the first page"
                             }
                         }
                     },
@@ -75,6 +277,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/gin.H"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -88,32 +302,61 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Creates a new favorite entry and responses with it as JSON.",
+            }
+        },
+        "/projects/{project_id}/collections": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with all collections of the owner ordered by name as JSON. The caller must represent the owner.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "favorites"
+                    "collections"
                 ],
-                "summary": "Create new favorite",
+                "summary": "Get collections of owner",
                 "parameters": [
                     {
-                        "description": "Favorite to create",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/favorites_internal_handlers_dto.CreateFavoriteRequest"
-                        }
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "USER",
+                            "GROUP"
+                        ],
+                        "type": "string",
+                        "description": "type of owner",
+                        "name": "owner_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of owner in uuid format",
+                        "name": "owner_id",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_models_collection.Collection"
+                            }
                         }
                     },
                     "400": {
@@ -122,6 +365,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/gin.H"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -129,30 +384,48 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/favorites/id": {
-            "delete": {
-                "description": "Deletes favorite entry and responses with NoContent Code.",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Creates a named collection of the owner's favorites and responds with it as JSON.\nNames are unique per owner. The caller must represent the owner.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "favorites"
+                    "collections"
                 ],
-                "summary": "Delete favorite by id",
+                "summary": "Create collection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID of favorite to delete in uuid format",
-                        "name": "id",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Collection to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.CreateCollectionRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created"
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_collection.Collection"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -160,6 +433,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/gin.H"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -168,17 +459,3075 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "favorites_internal_handlers_dto.CreateFavoriteRequest": {
+        },
+        "/projects/{project_id}/collections/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the collection as JSON. The caller must represent its owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "Get collection by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of collection in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_collection.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the collection, keeping the favorites in it, and responses with NoContent Code.\nThe caller must represent its owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "Delete collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of collection in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Renames the collection and responds with it as JSON. The caller must represent its owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "Rename collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of collection in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.RenameCollectionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_collection.Collection"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/collections/{id}/favorites": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the page of the collection's favorites by limit as JSON, newest first unless sort says otherwise.\nPass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one.\nThe caller must represent the collection's owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "Get favorites of collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of collection in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "size of page",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "object_type",
+                            "manual"
+                        ],
+                        "type": "string",
+                        "default": "newest",
+                        "description": "order of favorites",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Next-Cursor, returns the following page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Prev-Cursor, returns the preceding page",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                            }
+                        },
+                        "headers": {
+                            "X-Has-More": {
+                                "type": "string",
+                                "description": "whether a following page exists"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the following page, empty on the last page"
+                            },
+                            "X-Prev-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the preceding page, empty on the first page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/collections/{id}/favorites/{favorite_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Adds the favorite to the collection. Responds with 201 if it was added and 200 if it already was in the collection.\nThe favorite must belong to the collection's owner, whom the caller must represent.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "Add favorite to collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of collection in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of favorite in uuid format",
+                        "name": "favorite_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "201": {
+                        "description": "Created"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Removes the favorite from the collection, keeping the favorite itself, and responses with NoContent Code.\nThe caller must represent the collection's owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "collections"
+                ],
+                "summary": "Remove favorite from collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of collection in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of favorite in uuid format",
+                        "name": "favorite_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the page of the project's favorites by owner_type, owner_id and limit as JSON, newest first unless sort says otherwise.\nThe caller must represent the owner: be the USER itself or a member of the GROUP.\nPass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one.\nAn empty cursor header means there is no page in that direction.\nCursors are opaque signed tokens bound to the query, including filters and sort, and expire; a tampered, expired or foreign cursor is rejected with 400.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Get favorites array",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "USER",
+                            "GROUP"
+                        ],
+                        "type": "string",
+                        "description": "type of owner",
+                        "name": "owner_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of owner in uuid format",
+                        "name": "owner_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "size of page",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "DOCUMENT",
+                                "IMAGE",
+                                "VIDEO"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only favorites of these object types",
+                        "name": "object_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only favorites created after the time, in RFC 3339 format",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only favorites created before the time, in RFC 3339 format",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "only favorites carrying all of these tags",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "object_type",
+                            "manual"
+                        ],
+                        "type": "string",
+                        "default": "newest",
+                        "description": "order of favorites, manual lists pinned favorites first and then the owner's order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Next-Cursor, returns the following page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Prev-Cursor, returns the preceding page",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "deprecated alias of after",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                            }
+                        },
+                        "headers": {
+                            "X-Has-More": {
+                                "type": "string",
+                                "description": "whether a following page exists"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the following page, empty on the last page"
+                            },
+                            "X-Prev-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the preceding page, empty on the first page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new favorite entry and responses with it as JSON.\nIf the owner already favorited the object, responds with the existing entry and 200 instead of 201.\nRequests repeating an Idempotency-Key within the idempotency window get the original response replayed.\nTags are trimmed and lowercased; the note and tags of an existing entry are left as they are.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Create new favorite",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Favorite to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.CreateFavoriteRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "key identifying retries of the same request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Deletes every favorite of the owner within the project, e.g. when a user closes their account, and responds with how many were deleted.\nFavorites are deleted in transactions of ERASE_CHUNK_SIZE, each recorded like DELETE /favorites/{id},\nand an ERASE receipt with the total is appended to the audit log. A request that failed midway can be repeated.\nThe caller must represent the owner: be the USER itself or a member of the GROUP.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Delete all favorites of an owner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "USER",
+                            "GROUP"
+                        ],
+                        "type": "string",
+                        "description": "type of owner",
+                        "name": "owner_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of owner in uuid format",
+                        "name": "owner_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.DeleteFavoritesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites/changes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the favorites the owner gained and lost since the change token, oldest first and one change per favorite,\nso that clients keeping a copy of the favorites can catch up without downloading them all.\nA favorite.deleted change is a tombstone carrying the favorite as it was deleted, and favorite.hidden one deleted because its object was;\na restored favorite comes as favorite.created.\nPass next_token as since to continue, right away while has_more is set and later for the following changes.\nTokens are signed and bound to the owner. Without since, with an expired token or with one older than the events still kept,\nresync is set and no changes are listed:\nthe client should download the favorites with GET /favorites and then continue from next_token.\nThe caller must represent the owner: be the USER itself or a member of the GROUP.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Get favorite changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "USER",
+                            "GROUP"
+                        ],
+                        "type": "string",
+                        "description": "type of owner",
+                        "name": "owner_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of owner in uuid format",
+                        "name": "owner_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_token of the previous response",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 100,
+                        "description": "number of changes to scan, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.FavoriteChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Applies the changes a client made to the owner's favorites while offline, in order, and responds with the outcome of each.\nChanges name the object rather than the favorite. The last writer wins: a change made before the server last changed the\nowner's favorite of the object is not applied and comes back as a conflict along with the server's favorite.\nChanges dated in the future count as made now. A change that is already in effect is unchanged.\nThe caller must represent the owner: be the USER itself or a member of the GROUP.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Push favorite changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Owner and changes to apply",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.PushFavoriteChangesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.PushFavoriteChangesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites/lookup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with a map of every requested object_id to the owner's favorite id, or null if the object is not favorited.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Check which objects are favorited",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Owner and objects to check",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.LookupFavoritesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.LookupFavoritesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Streams the favorites the owner gains and loses as Server-Sent Events, across all replicas of the service.\nEvery event is named favorite.created, favorite.deleted or favorite.hidden, carries a dto.FavoriteChangeEvent as data and the position in the event log as id;\nfavorite.hidden is a favorite lost because its object was deleted, and a restored favorite comes as favorite.created. Comment lines are sent as heartbeats while nothing changes.\nA client reconnecting with the Last-Event-ID header first receives the events it missed, otherwise the stream starts with the next change.\nThe caller must represent the owner: be the USER itself or a member of the GROUP.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Stream favorite changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "USER",
+                            "GROUP"
+                        ],
+                        "type": "string",
+                        "description": "type of owner",
+                        "name": "owner_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of owner in uuid format",
+                        "name": "owner_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "id of the last event received, to resume from",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.FavoriteChangeEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Deletes favorite entry of the project and responses with NoContent Code.\nThe entry can be restored within the restore window and is purged for good after the retention period.\nFavorites of other projects are reported as not found, favorites of owners the caller does not represent are forbidden.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Delete favorite by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of favorite to delete in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Changes the note and/or replaces the tags of the favorite and responds with it as JSON. Omitted fields are left as they are.\nTags are trimmed and lowercased. The caller must represent the owner of the favorite.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Edit note and tags of favorite",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of favorite to edit in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New note and tags",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.UpdateFavoriteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites/{id}/position": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Places the favorite directly after the favorite after and/or directly before the favorite before and responds with it as JSON.\nAnchors must belong to the same owner, and the favorite joins their section: pinned favorites or the others.\nWithout anchors pinned moves the favorite to the top of the pinned favorites or of the others.\nOnly the moved favorite changes, so concurrent moves of other favorites are not lost.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Move favorite in manual order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of favorite to move in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New place of the favorite",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.MoveFavoriteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Undoes the deletion of the favorite within the restore window and responds with it as JSON.\nResponds with 409 if the owner has favorited the same object again since, or if the favorite was hidden by the deletion of its object, which restores it together with the object.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Restore deleted favorite",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of deleted favorite in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites:batchCreate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Creates every item like POST /favorites in one transaction and responds with the result of each item, in order.\nAn item the owner already favorited, or repeating an earlier item, responds with the existing entry as exists.\nItems go to the top of their owners' favorites, later items above earlier ones.\nWithout partial the whole batch is rejected if any item is, naming the first offending item.\nWith partial=true rejected items are reported as invalid or forbidden and the others are still created.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Create favorites in batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "report rejected items instead of rejecting the batch",
+                        "name": "partial",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Favorites to create, at most BATCH_MAX_ITEMS",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.BatchCreateFavoritesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.BatchFavoritesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/favorites:batchDelete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the favorites with the given ids like DELETE /favorites/{id} in one transaction\nand responds with the result of each id, in order, carrying the deleted favorite.\nWithout partial the whole batch is rejected with 404 or 403 if any favorite is missing or belongs to an owner the caller does not represent.\nWith partial=true such ids are reported as not_found or forbidden and the others are still deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Delete favorites in batch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "report rejected ids instead of rejecting the batch",
+                        "name": "partial",
+                        "in": "query"
+                    },
+                    {
+                        "description": "IDs of favorites to delete, at most BATCH_MAX_ITEMS",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.BatchDeleteFavoritesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.BatchFavoritesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/groups/{group_id}/members/{user_id}": {
+            "put": {
+                "security": [
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Adds the user to the group of the project. Responds with 201 if the user was added and 200 if the user already was a member.\nRequires the admin scope. Members act for the group from their next request.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Add user to group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of group in uuid format",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of user in uuid format",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "201": {
+                        "description": "Created"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Removes the user from the group of the project and responses with NoContent Code.\nRequires the admin scope. The user stops acting for the group from its next request.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Remove user from group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of group in uuid format",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of user in uuid format",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/objects/counts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the number of owners that favorited each of the requested objects within a project.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "objects"
+                ],
+                "summary": "Get favorite counters of objects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "DOCUMENT",
+                            "IMAGE",
+                            "VIDEO"
+                        ],
+                        "type": "string",
+                        "description": "type of objects",
+                        "name": "object_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "IDs of objects in uuid format",
+                        "name": "object_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.ObjectCountsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/objects/events": {
+            "post": {
+                "security": [
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Takes the announcement of the service owning an object that it was deleted or restored. Requires the admin scope.\nThe favorites of a deleted object are hidden by deleting them like DELETE /objects/{type}/{id}/favorites.\nOnce the object is restored the favorites hidden since are restored, except for owners that favorited it again meanwhile,\nas long as they were not purged after DELETED_RETENTION.\nEvents of an object apply in the order of occurred_at, which defaults to and is capped at the time of receipt.\nAn event older than the latest one of the object is skipped and reported as stale. Sending an event again is harmless.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "objects"
+                ],
+                "summary": "Ingest an object event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Object event",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.ObjectEventRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.ObjectEventResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/objects/{type}/{id}/favorites": {
+            "get": {
+                "security": [
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the page of owners (USER or GROUP) that favorited a single object within a project as JSON, newest first.\nEntries carry the owner and when it favorited the object only, never the note, tags or order of the favorite.\nPagination works like GET /favorites. Requires the admin scope: objects belong to other services and this one does not know their owners,\nso it cannot let an object owner in by itself. The service owning the object calls this endpoint with an admin API key on behalf of the\nobject owner and decides who may see the result.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "objects"
+                ],
+                "summary": "Get owners who favorited an object",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "DOCUMENT",
+                            "IMAGE",
+                            "VIDEO"
+                        ],
+                        "type": "string",
+                        "description": "type of object",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of object in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "size of page",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Next-Cursor, returns older favorites",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Prev-Cursor, returns newer favorites",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_handlers_dto.ObjectOwner"
+                            }
+                        },
+                        "headers": {
+                            "X-Has-More": {
+                                "type": "string",
+                                "description": "whether a next (older) page exists"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the next (older) page, empty on the last page"
+                            },
+                            "X-Prev-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the previous (newer) page, empty on the first page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the favorites of every owner of the object within the project, e.g. when the object itself was removed,\nand responds with how many were deleted. Requires the admin scope.\nFavorites are deleted in transactions of ERASE_CHUNK_SIZE, each recorded like DELETE /favorites/{id},\nand an ERASE receipt with the total is appended to the audit log. A request that failed midway can be repeated.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "objects"
+                ],
+                "summary": "Delete all favorites of an object",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "DOCUMENT",
+                            "IMAGE",
+                            "VIDEO"
+                        ],
+                        "type": "string",
+                        "description": "type of object",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of object in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.DeleteFavoritesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/tags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with every tag of the owner's favorites and how many favorites carry it as JSON, most used first.\nThe caller must represent the owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Get tags of owner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "USER",
+                            "GROUP"
+                        ],
+                        "type": "string",
+                        "description": "type of owner",
+                        "name": "owner_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of owner in uuid format",
+                        "name": "owner_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_handlers_dto.TagCount"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/users/{id}/effective-favorites": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the page of the user's own favorites merged with those of the user's groups as JSON, one favorite per object.\nAn object favorited several times is represented by the user's own favorite, otherwise by the newest group favorite.\nPass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one.\nThe caller must represent the user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "favorites"
+                ],
+                "summary": "Get effective favorites of user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of user in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "size of page",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "newest",
+                            "oldest",
+                            "object_type"
+                        ],
+                        "type": "string",
+                        "default": "newest",
+                        "description": "order of favorites",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Next-Cursor, returns the following page",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "signed cursor from X-Prev-Cursor, returns the preceding page",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                            }
+                        },
+                        "headers": {
+                            "X-Has-More": {
+                                "type": "string",
+                                "description": "whether a following page exists"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the following page, empty on the last page"
+                            },
+                            "X-Prev-Cursor": {
+                                "type": "string",
+                                "description": "cursor of the preceding page, empty on the first page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with all webhook subscriptions of the project, oldest first. Requires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_models_webhook.Subscription"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Subscribes url to changes of the project's favorites and responds with the subscription.\nEvery delivery is posted as JSON with the X-Webhook-Event and X-Webhook-ID headers, and X-Webhook-Signature \"t=\u003cunix time\u003e,v1=\u003csignature\u003e\",\nwhere the signature is the hex HMAC-SHA256 of \"\u003cunix time\u003e.\u003cbody\u003e\" keyed with secret.\nFailed deliveries are retried with exponential backoff and end up DEAD after the last attempt.\nRequires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_handlers_dto.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_webhook.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the webhook subscription together with its deliveries and responses with NoContent Code.\nRequires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of webhook in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Responds with the recent deliveries to the webhook, newest first, with the outcome of their latest attempt.\nRequires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of webhook in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "default": 20,
+                        "description": "number of deliveries, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/favorites_internal_models_webhook.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/webhooks/{id}/deliveries/{delivery_id}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Makes a failed or dead delivery pending again with a fresh set of attempts and responds with it.\nA delivery that succeeded cannot be redelivered. Requires the admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver webhook delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of project in uuid format",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of webhook in uuid format",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of delivery in uuid format",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/favorites_internal_models_webhook.Delivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/gin.H"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "favorites_internal_handlers_dto.BatchCreateFavoritesRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_handlers_dto.CreateFavoriteRequest"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.BatchDeleteFavoritesRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.BatchFavoriteResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "favorite": {
+                    "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "exists",
+                        "deleted",
+                        "invalid",
+                        "forbidden",
+                        "not_found"
+                    ]
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.BatchFavoritesResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_handlers_dto.BatchFavoriteResult"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/favorites_internal_models_apikey.APIKey"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.CreateCollectionRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "owner_id",
+                "owner_type"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "owner_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.CreateFavoriteRequest": {
+            "type": "object",
+            "required": [
+                "object_id",
+                "object_type",
+                "owner_id",
+                "owner_type"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "object_id": {
+                    "type": "string"
+                },
+                "object_type": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "owner_type": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "secret",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "description": "Events and ObjectTypes filter what is delivered, empty ones let through\nboth events and every object type.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "object_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret keys the HMAC-SHA256 signature of every delivery.",
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.DeleteFavoritesResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.FavoriteChangeEvent": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "favorite": {
+                    "description": "Favorite is the favorite as the change left it.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                        }
+                    ]
+                },
+                "type": {
+                    "$ref": "#/definitions/favorites_internal_models_outbox.Type"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.FavoriteChangesResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_handlers_dto.FavoriteChangeEvent"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "next_token": {
+                    "type": "string"
+                },
+                "resync": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.FavoriteMutation": {
+            "type": "object",
+            "required": [
+                "changed_at",
+                "object_id",
+                "object_type",
+                "type"
+            ],
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "object_id": {
+                    "type": "string"
+                },
+                "object_type": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "favorite.created",
+                        "favorite.deleted"
+                    ]
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.FavoriteMutationResult": {
+            "type": "object",
+            "properties": {
+                "favorite": {
+                    "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "applied",
+                        "unchanged",
+                        "conflict"
+                    ]
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.LookupFavoritesRequest": {
+            "type": "object",
+            "required": [
+                "object_ids",
+                "object_type",
+                "owner_id",
+                "owner_type"
+            ],
+            "properties": {
+                "object_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "object_type": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "owner_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.LookupFavoritesResponse": {
+            "type": "object",
+            "properties": {
+                "favorites": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.MoveFavoriteRequest": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "string"
+                },
+                "pinned": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.ObjectCountsResponse": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.ObjectEventRequest": {
             "type": "object",
             "required": [
                 "object_id",
                 "object_type",
-                "owner_id",
-                "owner_type",
-                "project_id"
+                "type"
             ],
             "properties": {
                 "object_id": {
@@ -187,14 +3536,275 @@ const docTemplate = `{
                 "object_type": {
                     "type": "string"
                 },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "object.deleted",
+                        "object.restored"
+                    ]
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.ObjectEventResponse": {
+            "type": "object",
+            "properties": {
+                "favorites": {
+                    "type": "integer"
+                },
+                "stale": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.ObjectOwner": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "owner_type": {
+                    "$ref": "#/definitions/favorites_internal_models_favorite.OwnerType"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.PushFavoriteChangesRequest": {
+            "type": "object",
+            "required": [
+                "mutations",
+                "owner_id",
+                "owner_type"
+            ],
+            "properties": {
+                "mutations": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_handlers_dto.FavoriteMutation"
+                    }
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "owner_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.PushFavoriteChangesResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_handlers_dto.FavoriteMutationResult"
+                    }
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.RenameCollectionRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.TagCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "favorites_internal_handlers_dto.UpdateFavoriteRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "favorites_internal_models_apikey.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_models_apikey.Scope"
+                    }
+                }
+            }
+        },
+        "favorites_internal_models_apikey.Scope": {
+            "type": "string",
+            "enum": [
+                "read",
+                "write",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "ScopeRead",
+                "ScopeWrite",
+                "ScopeAdmin"
+            ]
+        },
+        "favorites_internal_models_audit.Action": {
+            "type": "string",
+            "enum": [
+                "CREATE",
+                "DELETE",
+                "HIDE",
+                "RESTORE",
+                "ERASE"
+            ],
+            "x-enum-varnames": [
+                "ActionCreate",
+                "ActionDelete",
+                "ActionHide",
+                "ActionRestore",
+                "ActionErase"
+            ]
+        },
+        "favorites_internal_models_audit.ActorType": {
+            "type": "string",
+            "enum": [
+                "USER",
+                "SERVICE",
+                "SYSTEM"
+            ],
+            "x-enum-varnames": [
+                "ActorTypeUser",
+                "ActorTypeService",
+                "ActorTypeSystem"
+            ]
+        },
+        "favorites_internal_models_audit.Event": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/favorites_internal_models_audit.Action"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "actor_type": {
+                    "$ref": "#/definitions/favorites_internal_models_audit.ActorType"
+                },
+                "after": {
+                    "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                },
+                "before": {
+                    "description": "Before and After are snapshots of the favorite around the change. Before\nis nil for a creation.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/favorites_internal_models_favorite.Favorite"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "erased": {
+                    "description": "Erased is the number of favorites an ERASE receipt covers. A receipt\nnames the owner or the object whose favorites were erased and has no\nfavorite id or snapshots.",
+                    "type": "integer"
+                },
+                "favorite_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "object_id": {
+                    "type": "string"
+                },
+                "object_type": {
+                    "$ref": "#/definitions/favorites_internal_models_favorite.ObjectType"
+                },
                 "owner_id": {
                     "type": "string"
                 },
                 "owner_type": {
+                    "$ref": "#/definitions/favorites_internal_models_favorite.OwnerType"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "seq": {
+                    "description": "Seq grows in the order the events of a project were committed.",
+                    "type": "integer"
+                }
+            }
+        },
+        "favorites_internal_models_collection.Collection": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "string"
                 },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "owner_type": {
+                    "$ref": "#/definitions/favorites_internal_models_favorite.OwnerType"
+                },
                 "project_id": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
@@ -204,9 +3814,16 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "description": "DeletedAt is set while the favorite is soft-deleted and can still be\nrestored or waits to be purged.",
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "note": {
+                    "type": "string"
+                },
                 "object_id": {
                     "type": "string"
                 },
@@ -219,8 +3836,22 @@ const docTemplate = `{
                 "owner_type": {
                     "$ref": "#/definitions/favorites_internal_models_favorite.OwnerType"
                 },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "position": {
+                    "description": "Position is the rank of the favorite in its owner's manual order,\nwhich lists pinned favorites first.",
+                    "type": "string"
+                },
                 "project_id": {
                     "type": "string"
+                },
+                "tags": {
+                    "description": "Tags are kept in the favorite_tags table and sorted.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
@@ -248,10 +3879,131 @@ const docTemplate = `{
                 "OwnerTypeGroup"
             ]
         },
+        "favorites_internal_models_outbox.Type": {
+            "type": "string",
+            "enum": [
+                "favorite.created",
+                "favorite.updated",
+                "favorite.moved",
+                "favorite.deleted",
+                "favorite.hidden",
+                "favorite.restored"
+            ],
+            "x-enum-varnames": [
+                "TypeFavoriteCreated",
+                "TypeFavoriteUpdated",
+                "TypeFavoriteMoved",
+                "TypeFavoriteDeleted",
+                "TypeFavoriteHidden",
+                "TypeFavoriteRestored"
+            ]
+        },
+        "favorites_internal_models_webhook.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/favorites_internal_models_outbox.Type"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "description": "LastStatusCode and LastError describe the latest failed attempt.",
+                    "type": "integer"
+                },
+                "message_id": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "Payload is the JSON body posted to the subscriber.",
+                    "type": "object"
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/favorites_internal_models_webhook.Status"
+                },
+                "subscription_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "favorites_internal_models_webhook.Status": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "DELIVERED",
+                "DEAD"
+            ],
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusDelivered",
+                "StatusDead"
+            ]
+        },
+        "favorites_internal_models_webhook.Subscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_models_outbox.Type"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "object_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/favorites_internal_models_favorite.ObjectType"
+                    }
+                },
+                "project_id": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "gin.H": {
             "type": "object",
             "additionalProperties": {}
         }
+    },
+    "securityDefinitions": {
+        "APIKeyAuth": {
+            "description": "API key of a backend service, valid for one project",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "JWT as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -259,7 +4011,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "localhost:8080",
-	BasePath:         "/favorites",
+	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "Favorites API",
 	Description:      "A favorites management service API in Go using Gin framework.",
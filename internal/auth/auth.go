@@ -0,0 +1,275 @@
+// Package auth verifies the JWTs callers present and turns them into a
+// Principal, the set of owners the caller may act for. Tokens are signed
+// either with a shared HS256 secret or with RS256 keys published in a JWKS
+// document.
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"favorites/internal/models/apikey"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed          = errors.New("malformed token")
+	ErrUnsupportedAlg     = errors.New("token algorithm is not accepted")
+	ErrBadSignature       = errors.New("token signature is invalid")
+	ErrExpired            = errors.New("token has expired")
+	ErrNotYetValid        = errors.New("token is not valid yet")
+	ErrWrongIssuer        = errors.New("token was issued by another issuer")
+	ErrWrongAudience      = errors.New("token was issued for another audience")
+	ErrInvalidSubject     = errors.New("token subject is not a uuid")
+	ErrInvalidGroupsClaim = errors.New("token groups claim is not a list of uuids")
+	ErrInvalidProject     = errors.New("token project claim is missing or not a uuid")
+)
+
+// leeway absorbs clock skew between the issuer and this service.
+const leeway = 30 * time.Second
+
+// Principal is the authenticated caller: a user and the groups it belongs to,
+// or a backend service holding an API key.
+type Principal struct {
+	// Subject is the user id, or the key id for services.
+	Subject uuid.UUID
+	// Groups are the groups the user belongs to: those listed in its token,
+	// to which the HTTP layer adds those of the membership source of the
+	// project.
+	Groups []uuid.UUID
+	Scopes []apikey.Scope
+	// Service is set for API key callers, which act on behalf of any owner
+	// of their project.
+	Service bool
+	// ProjectID is the only project a user's token is valid for. API keys
+	// carry their project themselves.
+	ProjectID uuid.UUID
+}
+
+// userScopes are granted to every caller with a valid JWT.
+var userScopes = []apikey.Scope{apikey.ScopeRead, apikey.ScopeWrite}
+
+// ServicePrincipal is the caller holding the API key k.
+func ServicePrincipal(k apikey.APIKey) Principal {
+	return Principal{Subject: k.ID, Scopes: k.Scopes, Service: true}
+}
+
+// Allows reports whether the caller was granted scope. The admin scope
+// grants every other scope.
+func (p Principal) Allows(scope apikey.Scope) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, apikey.ScopeAdmin)
+}
+
+// Represents reports whether the caller may act on favorites of the owner.
+// A user represents itself and every group in Groups, a service represents
+// every owner.
+func (p Principal) Represents(ownerType favorite.OwnerType, ownerID uuid.UUID) bool {
+	if p.Service {
+		return true
+	}
+	switch ownerType {
+	case favorite.OwnerTypeUser:
+		return ownerID == p.Subject
+	case favorite.OwnerTypeGroup:
+		return slices.Contains(p.Groups, ownerID)
+	default:
+		return false
+	}
+}
+
+type Options struct {
+	// HMACSecret verifies HS256 tokens. HS256 is rejected when it is empty.
+	HMACSecret []byte
+	// RSAKeys verify RS256 tokens by key id. RS256 is rejected when empty.
+	RSAKeys map[string]*rsa.PublicKey
+	// Issuer and Audience are checked against iss and aud when set.
+	Issuer   string
+	Audience string
+	// GroupsClaim names the claim listing the caller's group ids.
+	GroupsClaim string
+	// ProjectClaim names the claim with the id of the project the token is
+	// valid for. Tokens without it are rejected.
+	ProjectClaim string
+}
+
+type Verifier struct {
+	options Options
+	now     func() time.Time
+}
+
+func NewVerifier(options Options) (*Verifier, error) {
+	if len(options.HMACSecret) == 0 && len(options.RSAKeys) == 0 {
+		return nil, errors.New("an HS256 secret or RS256 keys are required to verify tokens")
+	}
+	if options.GroupsClaim == "" {
+		options.GroupsClaim = "groups"
+	}
+	if options.ProjectClaim == "" {
+		options.ProjectClaim = "project_id"
+	}
+	return &Verifier{options: options, now: time.Now}, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the signature and the registered claims of token and returns
+// the caller it was issued to.
+func (v *Verifier) Verify(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrMalformed
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Principal{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, ErrMalformed
+	}
+	if err = v.verifySignature(h, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+	var claims map[string]json.RawMessage
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, err
+	}
+	if err = v.verifyClaims(claims); err != nil {
+		return Principal{}, err
+	}
+	return v.principal(claims)
+}
+
+func (v *Verifier) verifySignature(h header, signed string, signature []byte) error {
+	switch h.Alg {
+	case "HS256":
+		if len(v.options.HMACSecret) == 0 {
+			return ErrUnsupportedAlg
+		}
+		mac := hmac.New(sha256.New, v.options.HMACSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrBadSignature
+		}
+		return nil
+	case "RS256":
+		key, ok := v.options.RSAKeys[h.Kid]
+		if !ok {
+			return ErrBadSignature
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return ErrBadSignature
+		}
+		return nil
+	default:
+		return ErrUnsupportedAlg
+	}
+}
+
+func (v *Verifier) verifyClaims(claims map[string]json.RawMessage) error {
+	now := v.now()
+	var exp, nbf *float64
+	if err := decodeClaim(claims, "exp", &exp); err != nil {
+		return err
+	}
+	if exp == nil || now.After(time.Unix(int64(*exp), 0).Add(leeway)) {
+		return ErrExpired
+	}
+	if err := decodeClaim(claims, "nbf", &nbf); err != nil {
+		return err
+	}
+	if nbf != nil && now.Add(leeway).Before(time.Unix(int64(*nbf), 0)) {
+		return ErrNotYetValid
+	}
+	if v.options.Issuer != "" {
+		var iss string
+		if err := decodeClaim(claims, "iss", &iss); err != nil {
+			return err
+		}
+		if iss != v.options.Issuer {
+			return ErrWrongIssuer
+		}
+	}
+	if v.options.Audience != "" {
+		// aud is either a single string or a list of them.
+		var audiences []string
+		var audience string
+		if decodeClaim(claims, "aud", &audience) == nil {
+			audiences = []string{audience}
+		} else if err := decodeClaim(claims, "aud", &audiences); err != nil {
+			return err
+		}
+		if !slices.Contains(audiences, v.options.Audience) {
+			return ErrWrongAudience
+		}
+	}
+	return nil
+}
+
+func (v *Verifier) principal(claims map[string]json.RawMessage) (Principal, error) {
+	var subject string
+	if err := decodeClaim(claims, "sub", &subject); err != nil {
+		return Principal{}, err
+	}
+	subjectID, err := uuid.Parse(subject)
+	if err != nil {
+		return Principal{}, ErrInvalidSubject
+	}
+	var groups []string
+	if decodeClaim(claims, v.options.GroupsClaim, &groups) != nil {
+		return Principal{}, ErrInvalidGroupsClaim
+	}
+	var project string
+	if decodeClaim(claims, v.options.ProjectClaim, &project) != nil {
+		return Principal{}, ErrInvalidProject
+	}
+	projectID, err := uuid.Parse(project)
+	if err != nil {
+		return Principal{}, ErrInvalidProject
+	}
+	principal := Principal{Subject: subjectID, Scopes: userScopes, ProjectID: projectID}
+	for _, group := range groups {
+		groupID, err := uuid.Parse(group)
+		if err != nil {
+			return Principal{}, ErrInvalidGroupsClaim
+		}
+		principal.Groups = append(principal.Groups, groupID)
+	}
+	return principal, nil
+}
+
+func decodeSegment(segment string, target any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+	if json.Unmarshal(decoded, target) != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+// decodeClaim unmarshals the named claim into target, leaving target
+// untouched when the claim is absent.
+func decodeClaim(claims map[string]json.RawMessage, name string, target any) error {
+	raw, ok := claims[name]
+	if !ok {
+		return nil
+	}
+	if json.Unmarshal(raw, target) != nil {
+		return ErrMalformed
+	}
+	return nil
+}
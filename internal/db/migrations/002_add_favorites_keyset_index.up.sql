@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_favorites_owner_created_at_id ON favorites (owner_type, owner_id, created_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_favorites_owner;
@@ -0,0 +1,26 @@
+-- Keep the oldest row of every duplicate group before enforcing uniqueness.
+DELETE
+FROM favorites f
+    USING favorites d
+WHERE f.project_id = d.project_id
+  AND f.owner_type = d.owner_type
+  AND f.owner_id = d.owner_id
+  AND f.object_id = d.object_id
+  AND f.object_type = d.object_type
+  AND (f.created_at, f.id) > (d.created_at, d.id);
+
+ALTER TABLE favorites
+    ADD CONSTRAINT uq_favorites_owner_object UNIQUE (project_id, owner_type, owner_id, object_id, object_type);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    key           VARCHAR   NOT NULL,
+    scope         VARCHAR   NOT NULL,
+    request_hash  VARCHAR   NOT NULL,
+    status_code   INTEGER   NOT NULL,
+    response_body BYTEA     NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (key, scope)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys (created_at);
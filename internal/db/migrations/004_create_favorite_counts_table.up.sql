@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS favorite_counts
+(
+    project_id  UUID    NOT NULL,
+    object_type VARCHAR NOT NULL,
+    object_id   UUID    NOT NULL,
+    count       BIGINT  NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, object_type, object_id)
+);
+
+INSERT INTO favorite_counts (project_id, object_type, object_id, count)
+SELECT project_id, object_type, object_id, COUNT(*)
+FROM favorites
+GROUP BY project_id, object_type, object_id
+ON CONFLICT (project_id, object_type, object_id) DO UPDATE SET count = EXCLUDED.count;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_favorites_owner_object_type_created_at_id
+    ON favorites (owner_type, owner_id, object_type, created_at DESC, id DESC);
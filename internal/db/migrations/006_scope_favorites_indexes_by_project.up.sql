@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS idx_favorites_project_owner_created_at_id
+    ON favorites (project_id, owner_type, owner_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_favorites_project_owner_object_type_created_at_id
+    ON favorites (project_id, owner_type, owner_id, object_type, created_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_favorites_owner_created_at_id;
+DROP INDEX IF EXISTS idx_favorites_owner_object_type_created_at_id;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id   UUID      NOT NULL,
+    name         VARCHAR   NOT NULL,
+    secret_hash  VARCHAR   NOT NULL,
+    scopes       VARCHAR[] NOT NULL,
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_project_id ON api_keys (project_id);
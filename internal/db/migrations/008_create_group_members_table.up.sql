@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS group_members
+(
+    project_id UUID      NOT NULL,
+    group_id   UUID      NOT NULL,
+    user_id    UUID      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_members_project_user ON group_members (project_id, user_id);
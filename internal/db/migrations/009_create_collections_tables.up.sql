@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS collections
+(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID      NOT NULL,
+    owner_type VARCHAR   NOT NULL,
+    owner_id   UUID      NOT NULL,
+    name       VARCHAR   NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_collections_owner_name UNIQUE (project_id, owner_type, owner_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS collection_favorites
+(
+    collection_id UUID      NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+    favorite_id   UUID      NOT NULL REFERENCES favorites (id) ON DELETE CASCADE,
+    added_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, favorite_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_favorites_favorite_id ON collection_favorites (favorite_id);
@@ -0,0 +1,20 @@
+ALTER TABLE favorites
+    ADD COLUMN IF NOT EXISTS position VARCHAR COLLATE "C",
+    ADD COLUMN IF NOT EXISTS pinned   BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Rank existing favorites newest first within every owner. Ranks must not end
+-- in '0' so that there is always room for another rank before them.
+UPDATE favorites f
+SET position = ranked.position
+FROM (SELECT id,
+             LPAD(ROW_NUMBER() OVER (PARTITION BY project_id, owner_type, owner_id
+                                     ORDER BY created_at DESC, id DESC)::TEXT, 10, '0') || 'i' AS position
+      FROM favorites) ranked
+WHERE f.id = ranked.id
+  AND f.position IS NULL;
+
+ALTER TABLE favorites
+    ALTER COLUMN position SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_favorites_project_owner_manual
+    ON favorites (project_id, owner_type, owner_id, pinned DESC, position, id);
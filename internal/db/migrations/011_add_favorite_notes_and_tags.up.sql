@@ -0,0 +1,11 @@
+ALTER TABLE favorites
+    ADD COLUMN IF NOT EXISTS note VARCHAR NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS favorite_tags
+(
+    favorite_id UUID    NOT NULL REFERENCES favorites (id) ON DELETE CASCADE,
+    tag         VARCHAR NOT NULL,
+    PRIMARY KEY (favorite_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorite_tags_tag ON favorite_tags (tag, favorite_id);
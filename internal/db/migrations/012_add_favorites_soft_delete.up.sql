@@ -0,0 +1,14 @@
+ALTER TABLE favorites
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Soft-deleted favorites must not keep the owner from favoriting the object
+-- again, so uniqueness only covers live rows.
+ALTER TABLE favorites
+    DROP CONSTRAINT IF EXISTS uq_favorites_owner_object;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_favorites_owner_object
+    ON favorites (project_id, owner_type, owner_id, object_id, object_type)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_favorites_deleted_at
+    ON favorites (deleted_at)
+    WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,40 @@
+-- favorite_events is an append-only audit log. It has no foreign key to
+-- favorites so that events outlive purged favorites.
+CREATE TABLE IF NOT EXISTS favorite_events
+(
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id  UUID      NOT NULL,
+    favorite_id UUID      NOT NULL,
+    owner_type  VARCHAR   NOT NULL,
+    owner_id    UUID      NOT NULL,
+    object_type VARCHAR   NOT NULL,
+    object_id   UUID      NOT NULL,
+    action      VARCHAR   NOT NULL,
+    actor_type  VARCHAR   NOT NULL,
+    actor_id    UUID      NOT NULL,
+    request_id  VARCHAR   NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorite_events_project
+    ON favorite_events (project_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_favorite_events_owner
+    ON favorite_events (project_id, owner_type, owner_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_favorite_events_object
+    ON favorite_events (project_id, object_type, object_id, created_at DESC, id DESC);
+
+CREATE OR REPLACE FUNCTION reject_favorite_event_change() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'favorite_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_favorite_events_append_only ON favorite_events;
+CREATE TRIGGER trg_favorite_events_append_only
+    BEFORE UPDATE OR DELETE
+    ON favorite_events
+    FOR EACH ROW
+EXECUTE FUNCTION reject_favorite_event_change();
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS favorite_outbox
+(
+    id           BIGSERIAL PRIMARY KEY,
+    type         VARCHAR   NOT NULL,
+    project_id   UUID      NOT NULL,
+    favorite_id  UUID      NOT NULL,
+    payload      JSONB     NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorite_outbox_pending
+    ON favorite_outbox (id)
+    WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_favorite_outbox_published_at
+    ON favorite_outbox (published_at)
+    WHERE published_at IS NOT NULL;
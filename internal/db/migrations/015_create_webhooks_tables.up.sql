@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions
+(
+    id           UUID PRIMARY KEY   DEFAULT gen_random_uuid(),
+    project_id   UUID      NOT NULL,
+    url          VARCHAR   NOT NULL,
+    secret       VARCHAR   NOT NULL,
+    events       VARCHAR[] NOT NULL DEFAULT '{}',
+    object_types VARCHAR[] NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_project_id ON webhook_subscriptions (project_id);
+
+-- message_id refers to favorite_outbox without a foreign key, since published
+-- messages are deleted long before their deliveries.
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               UUID PRIMARY KEY   DEFAULT gen_random_uuid(),
+    project_id       UUID      NOT NULL,
+    subscription_id  UUID      NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    message_id       BIGINT    NOT NULL,
+    event            VARCHAR   NOT NULL,
+    payload          JSONB     NOT NULL,
+    status           VARCHAR   NOT NULL DEFAULT 'PENDING',
+    attempts         INT       NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_status_code INT,
+    last_error       VARCHAR,
+    delivered_at     TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_webhook_deliveries_message UNIQUE (subscription_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription
+    ON webhook_deliveries (subscription_id, created_at DESC, id DESC);
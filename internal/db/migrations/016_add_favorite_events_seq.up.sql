@@ -0,0 +1,9 @@
+-- seq orders the audit log by commit: writers take it under the same advisory
+-- lock as outbox ids, so a reader that saw an event has seen all before it.
+ALTER TABLE favorite_events
+    ADD COLUMN IF NOT EXISTS seq BIGSERIAL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorite_events_seq
+    ON favorite_events (seq);
+CREATE INDEX IF NOT EXISTS idx_favorite_events_owner_seq
+    ON favorite_events (project_id, owner_type, owner_id, seq);
@@ -0,0 +1,5 @@
+-- erased is the number of favorites an ERASE receipt covers. Receipts record
+-- the erasure of all favorites of an owner or an object, so they name no
+-- single favorite.
+ALTER TABLE favorite_events
+    ADD COLUMN IF NOT EXISTS erased BIGINT NOT NULL DEFAULT 0;
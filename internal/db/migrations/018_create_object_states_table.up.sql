@@ -0,0 +1,16 @@
+-- object_states keeps the latest deleted or restored event of every object
+-- other services announced, to skip events arriving out of order.
+CREATE TABLE IF NOT EXISTS object_states
+(
+    project_id  UUID      NOT NULL,
+    object_type VARCHAR   NOT NULL,
+    object_id   UUID      NOT NULL,
+    deleted     BOOLEAN   NOT NULL,
+    changed_at  TIMESTAMP NOT NULL,
+    hidden_at   TIMESTAMP,
+    PRIMARY KEY (project_id, object_type, object_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_object_states_deleted
+    ON object_states (project_id, object_type, object_id)
+    WHERE deleted;
@@ -0,0 +1,22 @@
+-- redact_favorite_snapshot removes the note and tags from a favorite snapshot.
+-- Erasing an owner applies it to every copy of the owner's favorites kept in
+-- the audit log, the outbox and the webhook deliveries.
+CREATE OR REPLACE FUNCTION redact_favorite_snapshot(snapshot JSONB) RETURNS JSONB AS
+$$
+SELECT snapshot || '{"note": "", "tags": []}'::JSONB;
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+-- The audit log stays append-only except for the redaction of its snapshots:
+-- an update may change nothing but before and after, and only by redacting.
+CREATE OR REPLACE FUNCTION reject_favorite_event_change() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND to_jsonb(NEW) - 'before' - 'after' = to_jsonb(OLD) - 'before' - 'after'
+        AND NEW.before IS NOT DISTINCT FROM redact_favorite_snapshot(OLD.before)
+        AND NEW.after IS NOT DISTINCT FROM redact_favorite_snapshot(OLD.after) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'favorite_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,15 @@
+-- hidden_by_object marks the deleted favorites hidden by the deletion of their
+-- object. Their owners cannot restore them; restoring the object does.
+ALTER TABLE favorites
+    ADD COLUMN IF NOT EXISTS hidden_by_object BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Favorites hidden before the column existed are the ones deleted since their
+-- object was.
+UPDATE favorites f
+SET hidden_by_object = TRUE
+FROM object_states s
+WHERE s.deleted
+  AND f.project_id = s.project_id
+  AND f.object_type = s.object_type
+  AND f.object_id = s.object_id
+  AND f.deleted_at >= s.hidden_at;
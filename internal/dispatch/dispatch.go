@@ -0,0 +1,158 @@
+// Package dispatch delivers the changes of favorites to the webhooks
+// projects subscribed. Every delivery is posted with an HMAC-SHA256
+// signature, retried with exponential backoff while it fails and given up
+// as dead after the last attempt, until it is redelivered by hand.
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"favorites/internal/models/webhook"
+	"favorites/internal/repository"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxErrorLength caps the error kept for a failed attempt.
+const maxErrorLength = 500
+
+type Options struct {
+	// MaxAttempts is how many times a delivery is attempted before it is
+	// dead.
+	MaxAttempts int
+	// Backoff is the wait after the first failed attempt. It doubles with
+	// every further failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds a single attempt.
+	Timeout time.Duration
+	// BatchSize deliveries are claimed at a time every Interval.
+	BatchSize int
+	Interval  time.Duration
+	// Retention is how long delivered and dead deliveries are listed.
+	Retention time.Duration
+}
+
+type Dispatcher struct {
+	webhooks repository.WebhookStore
+	options  Options
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewDispatcher(webhooks repository.WebhookStore, options Options) *Dispatcher {
+	return &Dispatcher{
+		webhooks: webhooks,
+		options:  options,
+		client:   &http.Client{Timeout: options.Timeout},
+		now:      time.Now,
+	}
+}
+
+// DispatchOnce attempts the deliveries due now, batch by batch until none are
+// left, and returns how many it attempted.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	attempted := 0
+	for {
+		// A claimed delivery is not attempted by anyone else until the lease
+		// is over, which outlasts the attempts of a whole batch.
+		lease := time.Duration(d.options.BatchSize)*d.options.Timeout + time.Minute
+		claimed, err := d.webhooks.ClaimDueDeliveries(ctx, d.now().UTC(), d.options.BatchSize, lease)
+		if err != nil {
+			return attempted, err
+		}
+		for _, delivery := range claimed {
+			if err = d.webhooks.SaveDeliveryAttempt(ctx, d.attempt(ctx, delivery)); err != nil {
+				return attempted, err
+			}
+			attempted++
+		}
+		if len(claimed) < d.options.BatchSize {
+			return attempted, nil
+		}
+	}
+}
+
+// attempt posts the delivery once and returns it updated with the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, claimed repository.ClaimedDelivery) webhook.Delivery {
+	delivery := claimed.Delivery
+	delivery.Attempts++
+	statusCode, err := d.post(ctx, claimed)
+	now := d.now().UTC()
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	if err == nil {
+		delivery.Status = webhook.StatusDelivered
+		delivery.DeliveredAt = &now
+		return delivery
+	}
+	message := err.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	delivery.LastError = &message
+	if delivery.Attempts >= d.options.MaxAttempts {
+		delivery.Status = webhook.StatusDead
+		return delivery
+	}
+	delivery.Status = webhook.StatusPending
+	delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	return delivery
+}
+
+// backoff is the wait after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.options.Backoff
+	for i := 1; i < attempts && wait < d.options.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, d.options.MaxBackoff)
+}
+
+// post sends the payload and returns the response status, or zero if there
+// was no response. Only 2xx responses count as delivered.
+func (d *Dispatcher) post(ctx context.Context, claimed repository.ClaimedDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, claimed.URL, bytes.NewReader(claimed.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", claimed.ID.String())
+	req.Header.Set("X-Webhook-Event", string(claimed.Event))
+	req.Header.Set(SignatureHeader, Sign(claimed.Secret, d.now().Unix(), claimed.Payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Run dispatches right away and then every interval until ctx is done.
+// Failures are logged and retried on the next tick.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil {
+			log.Printf("Failed to dispatch webhooks: %v", err)
+		}
+		_, err := d.webhooks.DeleteFinishedDeliveries(ctx, d.now().UTC().Add(-d.options.Retention))
+		if err != nil {
+			log.Printf("Failed to delete finished webhook deliveries: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package dispatch
+
+import (
+	"context"
+	"favorites/internal/models/outbox"
+	"favorites/internal/repository"
+)
+
+// Fanout is a relay.Publisher turning every outbox message into deliveries
+// to the matching webhook subscriptions, which the Dispatcher then attempts.
+type Fanout struct {
+	webhooks repository.WebhookStore
+}
+
+func NewFanout(webhooks repository.WebhookStore) *Fanout {
+	return &Fanout{webhooks: webhooks}
+}
+
+func (f *Fanout) Publish(ctx context.Context, m outbox.Message) error {
+	_, err := f.webhooks.EnqueueDeliveries(ctx, m)
+	return err
+}
@@ -0,0 +1,22 @@
+package dispatch
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// SignatureHeader carries "t=<unix time>,v1=<signature>", where the signature
+// is the hex HMAC-SHA256 of "<unix time>.<body>" keyed with the secret of
+// the subscription. Receivers should recompute it and reject stale times.
+const SignatureHeader = "X-Webhook-Signature"
+
+// Sign returns the SignatureHeader value for body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"favorites/internal/auth"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/apikey"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+)
+
+// CreateAPIKey godoc
+// @Summary       Create API key
+// @Description   Mints an API key for backend services of the project and responds with it and its token.
+// @Description   The token is sent in the X-API-Key header and is shown only once; only a hash of it is stored.
+// @Description   Requires the admin scope.
+// @Tags          api-keys
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  request  body    dto.CreateAPIKeyRequest  true  "Key to create"
+// @Success       201  {object}  dto.CreateAPIKeyResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	var request dto.CreateAPIKeyRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var scopes []apikey.Scope
+	for _, scope := range request.Scopes {
+		if !apikey.IsValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect scope " + scope})
+			return
+		}
+		if !slices.Contains(scopes, apikey.Scope(scope)) {
+			scopes = append(scopes, apikey.Scope(scope))
+		}
+	}
+	k, secret := auth.NewAPIKey(projectID(c), request.Name, scopes, request.ExpiresAt)
+	if err := apiKeys.CreateAPIKey(c.Request.Context(), &k); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, dto.CreateAPIKeyResponse{APIKey: k, Token: auth.APIKeyToken(k, secret)})
+}
+
+// RevokeAPIKey godoc
+// @Summary       Revoke API key
+// @Description   Revokes the API key of the project and responses with NoContent Code. Requires the admin scope.
+// @Tags          api-keys
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of API key to revoke in uuid format"
+// @Success       204
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = apiKeys.RevokeAPIKey(c.Request.Context(), projectID(c), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"favorites/internal/handlers/httputil"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"favorites/internal/pagetoken"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+var errIncompleteFilter = errors.New("incomplete filter")
+
+// actor is the caller to attribute changes to in the audit log.
+func actor(c *gin.Context) audit.Actor {
+	p := principal(c)
+	actorType := audit.ActorTypeUser
+	if p.Service {
+		actorType = audit.ActorTypeService
+	}
+	return audit.Actor{Type: actorType, ID: p.Subject, RequestID: requestID(c)}
+}
+
+// GetAuditEvents godoc
+// @Summary       Get audit log
+// @Description   Responds with the page of the project's audit events as JSON, newest first. Every creation, deletion and restore of a favorite is recorded
+// @Description   with the caller that made it, the X-Request-ID of its request and snapshots of the favorite before and after the change.
+// @Description   Events can be narrowed to an owner, to an object and to a time range; owner_type and owner_id, as well as object_type and object_id, go together.
+// @Description   Pass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one. Requires the admin scope.
+// @Tags          audit
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  limit  query    number  true  "size of page"
+// @Param		  owner_type  query    favorite.OwnerType  false  "type of owner"
+// @Param		  owner_id  query    string  false  "ID of owner in uuid format"
+// @Param		  object_type  query    favorite.ObjectType  false  "type of object"
+// @Param		  object_id  query    string  false  "ID of object in uuid format"
+// @Param		  created_after  query    string  false  "only events recorded after the time, in RFC 3339 format"
+// @Param		  created_before  query    string  false  "only events recorded before the time, in RFC 3339 format"
+// @Param		  after  query   string  false  "signed cursor from X-Next-Cursor, returns the following page"
+// @Param		  before  query   string  false  "signed cursor from X-Prev-Cursor, returns the preceding page"
+// @Success       200  {array}  audit.Event
+// @Header        200  {string}  X-Next-Cursor  "cursor of the following page, empty on the last page"
+// @Header        200  {string}  X-Prev-Cursor  "cursor of the preceding page, empty on the first page"
+// @Header        200  {string}  X-Has-More  "whether a following page exists"
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/audit [get]
+func GetAuditEvents(c *gin.Context) {
+	scope := pagetoken.Scope{
+		"project_id": projectID(c).String(),
+		"audit":      "",
+	}
+	filter, err := parseAuditFilter(c, scope)
+	if err != nil {
+		return
+	}
+	pageRequest, err := httputil.ParsePageRequest(c, cursors, scope)
+	if err != nil {
+		return
+	}
+	page, err := auditLog.GetPageOfEvents(c.Request.Context(), projectID(c), filter, pageRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(page.Events) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No events found"})
+		return
+	}
+	httputil.SetCursorHeaders(c, cursors, page.Next, page.Prev, scope)
+	c.JSON(http.StatusOK, page.Events)
+}
+
+// parseAuditFilter reads the optional audit log filters and records them in
+// scope so that cursors stay bound to them.
+func parseAuditFilter(c *gin.Context, scope pagetoken.Scope) (repository.AuditFilter, error) {
+	var filter repository.AuditFilter
+	var err error
+	if c.Query("owner_type") != "" || c.Query("owner_id") != "" {
+		if !favorite.IsValidOwnerType(c.Query("owner_type")) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+			return filter, errIncompleteFilter
+		}
+		if filter.OwnerID, err = uuid.Parse(c.Query("owner_id")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid owner_id: " + err.Error()})
+			return filter, err
+		}
+		filter.OwnerType = favorite.OwnerType(c.Query("owner_type"))
+		scope["owner_type"] = string(filter.OwnerType)
+		scope["owner_id"] = filter.OwnerID.String()
+	}
+	if c.Query("object_type") != "" || c.Query("object_id") != "" {
+		if !favorite.IsValidObjectType(c.Query("object_type")) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect object_type"})
+			return filter, errIncompleteFilter
+		}
+		if filter.ObjectID, err = uuid.Parse(c.Query("object_id")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid object_id: " + err.Error()})
+			return filter, err
+		}
+		filter.ObjectType = favorite.ObjectType(c.Query("object_type"))
+		scope["object_type"] = string(filter.ObjectType)
+		scope["object_id"] = filter.ObjectID.String()
+	}
+	if filter.CreatedAfter, err = parseTime(c, "created_after", scope); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTime(c, "created_before", scope); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"favorites/config"
+	"favorites/internal/auth"
+	"favorites/internal/models/apikey"
+	"favorites/internal/models/favorite"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// errForeignOwner rejects callers acting on an owner they do not represent.
+var errForeignOwner = errors.New("Not allowed to act on this owner")
+
+const (
+	principalKey = "principal"
+	apiKeyHeader = "X-API-Key"
+)
+
+func newTokenVerifier(cfg config.Config) *auth.Verifier {
+	options := auth.Options{
+		HMACSecret:   []byte(cfg.JWTSecret),
+		Issuer:       cfg.JWTIssuer,
+		Audience:     cfg.JWTAudience,
+		GroupsClaim:  cfg.JWTGroupsClaim,
+		ProjectClaim: cfg.JWTProjectClaim,
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			panic(err)
+		}
+		options.RSAKeys = keys
+	}
+	verifier, err := auth.NewVerifier(options)
+	if err != nil {
+		panic("JWT_SECRET or JWT_JWKS_FILE must be set: " + err.Error())
+	}
+	return verifier
+}
+
+// Authenticate rejects requests without a valid bearer token or API key and
+// makes the caller available through principal. Tokens and API keys are only
+// accepted for the project they were issued for. A user belongs to the groups
+// its token lists and to those of memberships, the source effective favorites
+// are read by.
+func Authenticate(
+	verifier *auth.Verifier,
+	apiKeys repository.APIKeyStore,
+	memberships repository.MembershipSource,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.GetHeader(apiKeyHeader); token != "" {
+			authenticateAPIKey(c, apiKeys, token)
+			return
+		}
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+		p, err := verifier.Verify(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+			return
+		}
+		if p.ProjectID != projectID(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token is not valid for this project"})
+			return
+		}
+		groups, err := memberships.GetGroupsOfUser(c.Request.Context(), p.ProjectID, p.Subject)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, groupID := range groups {
+			if !slices.Contains(p.Groups, groupID) {
+				p.Groups = append(p.Groups, groupID)
+			}
+		}
+		c.Set(principalKey, p)
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, apiKeys repository.APIKeyStore, token string) {
+	id, secret, err := auth.ParseAPIKey(token)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	k, err := apiKeys.GetAPIKey(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": auth.ErrBadAPIKey.Error()})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now().UTC()
+	if err = auth.CheckAPIKey(k, secret, now); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if k.ProjectID != projectID(c) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is not valid for this project"})
+		return
+	}
+	if err = apiKeys.TouchAPIKey(c.Request.Context(), k.ID, now); err != nil {
+		_ = c.Error(err)
+	}
+	c.Set(principalKey, auth.ServicePrincipal(k))
+	c.Next()
+}
+
+// RequireScope rejects callers that were not granted scope.
+func RequireScope(scope apikey.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !principal(c).Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing scope " + string(scope)})
+			return
+		}
+		c.Next()
+	}
+}
+
+func principal(c *gin.Context) auth.Principal {
+	return c.MustGet(principalKey).(auth.Principal)
+}
+
+// authorizeOwner responds with 403 and returns false when the caller does not
+// represent the owner it is acting on.
+func authorizeOwner(c *gin.Context, ownerType favorite.OwnerType, ownerID uuid.UUID) bool {
+	if !principal(c).Represents(ownerType, ownerID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errForeignOwner.Error()})
+		return false
+	}
+	return true
+}
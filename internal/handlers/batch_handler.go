@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/favorite"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+// Outcomes of a batch item.
+const (
+	batchCreated   = "created"
+	batchExists    = "exists"
+	batchDeleted   = "deleted"
+	batchInvalid   = "invalid"
+	batchForbidden = "forbidden"
+	batchNotFound  = "not_found"
+)
+
+// batchMethods are the custom methods of the favorites collection by the
+// suffix of their path. Gin cannot route a colon inside a path segment, so
+// POST /favorites:batchCreate and /favorites:batchDelete share a route taking
+// the suffix of /favorites as the method parameter.
+var batchMethods = map[string]gin.HandlerFunc{
+	":batchCreate": BatchCreateFavorites,
+	":batchDelete": BatchDeleteFavorites,
+}
+
+// requireBatchMethod answers a path that only looks like a custom method of
+// the favorites collection the way the router answers an unknown path.
+func requireBatchMethod(c *gin.Context) {
+	if _, ok := batchMethods[c.Param("method")]; !ok {
+		c.Data(http.StatusNotFound, binding.MIMEPlain, []byte("404 page not found"))
+		c.Abort()
+	}
+}
+
+// BatchFavorites serves the custom method of the favorites collection that
+// requireBatchMethod let through.
+func BatchFavorites(c *gin.Context) {
+	batchMethods[c.Param("method")](c)
+}
+
+// parseBatch reads the partial query parameter and checks the size of a
+// batch, responding with 400 if either is wrong.
+func parseBatch(c *gin.Context, size int) (bool, bool) {
+	partial := false
+	if value := c.Query("partial"); value != "" {
+		var err error
+		if partial, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid partial, expected a boolean"})
+			return false, false
+		}
+	}
+	if size > batchMaxItems {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d items are allowed", batchMaxItems)})
+		return false, false
+	}
+	return partial, true
+}
+
+// batchFailure is the outcome of an item rejected with status.
+func batchFailure(status int) string {
+	if status == http.StatusForbidden {
+		return batchForbidden
+	}
+	return batchInvalid
+}
+
+// BatchCreateFavorites godoc
+// @Summary       Create favorites in batch
+// @Description   Creates every item like POST /favorites in one transaction and responds with the result of each item, in order.
+// @Description   An item the owner already favorited, or repeating an earlier item, responds with the existing entry as exists.
+// @Description   Items go to the top of their owners' favorites, later items above earlier ones.
+// @Description   Without partial the whole batch is rejected if any item is, naming the first offending item.
+// @Description   With partial=true rejected items are reported as invalid or forbidden and the others are still created.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  partial  query    bool  false  "report rejected items instead of rejecting the batch"
+// @Param		  request  body    dto.BatchCreateFavoritesRequest  true  "Favorites to create, at most BATCH_MAX_ITEMS"
+// @Success       200  {object}  dto.BatchFavoritesResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites:batchCreate [post]
+func BatchCreateFavorites(c *gin.Context) {
+	var request dto.BatchCreateFavoritesRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	partial, ok := parseBatch(c, len(request.Items))
+	if !ok {
+		return
+	}
+	results := make([]dto.BatchFavoriteResult, len(request.Items))
+	var favorites []favorite.Favorite
+	var indexes []int
+	for i, item := range request.Items {
+		status := http.StatusBadRequest
+		err := binding.Validator.ValidateStruct(item)
+		var fav favorite.Favorite
+		if err == nil {
+			fav, status, err = favoriteOf(c, item)
+		}
+		if err != nil && !partial {
+			c.JSON(status, gin.H{"error": fmt.Sprintf("Item %d: %s", i, err)})
+			return
+		}
+		if err != nil {
+			results[i] = dto.BatchFavoriteResult{Status: batchFailure(status), Error: err.Error()}
+			continue
+		}
+		favorites = append(favorites, fav)
+		indexes = append(indexes, i)
+	}
+	created, err := repo.CreateFavorites(c.Request.Context(), favorites, actor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for j, i := range indexes {
+		results[i] = dto.BatchFavoriteResult{Status: batchExists, Favorite: &favorites[j]}
+		if created[j] {
+			results[i].Status = batchCreated
+		}
+	}
+	c.JSON(http.StatusOK, dto.BatchFavoritesResponse{Results: results})
+}
+
+// BatchDeleteFavorites godoc
+// @Summary       Delete favorites in batch
+// @Description   Deletes the favorites with the given ids like DELETE /favorites/{id} in one transaction
+// @Description   and responds with the result of each id, in order, carrying the deleted favorite.
+// @Description   Without partial the whole batch is rejected with 404 or 403 if any favorite is missing or belongs to an owner the caller does not represent.
+// @Description   With partial=true such ids are reported as not_found or forbidden and the others are still deleted.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  partial  query    bool  false  "report rejected ids instead of rejecting the batch"
+// @Param		  request  body    dto.BatchDeleteFavoritesRequest  true  "IDs of favorites to delete, at most BATCH_MAX_ITEMS"
+// @Success       200  {object}  dto.BatchFavoritesResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites:batchDelete [post]
+func BatchDeleteFavorites(c *gin.Context) {
+	var request dto.BatchDeleteFavoritesRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	partial, ok := parseBatch(c, len(request.IDs))
+	if !ok {
+		return
+	}
+	found, err := repo.GetFavoritesByIDs(c.Request.Context(), projectID(c), request.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	results := make([]dto.BatchFavoriteResult, len(request.IDs))
+	var ids []uuid.UUID
+	for i, id := range request.IDs {
+		fav, ok := found[id]
+		switch {
+		case !ok && !partial:
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Favorite %s not found", id)})
+			return
+		case !ok:
+			results[i] = dto.BatchFavoriteResult{Status: batchNotFound, Error: "Favorite not found"}
+		case !principal(c).Represents(fav.OwnerType, fav.OwnerID) && !partial:
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Favorite %s: %s", id, errForeignOwner)})
+			return
+		case !principal(c).Represents(fav.OwnerType, fav.OwnerID):
+			results[i] = dto.BatchFavoriteResult{Status: batchForbidden, Error: errForeignOwner.Error()}
+		default:
+			ids = append(ids, id)
+		}
+	}
+	deleted, err := repo.DeleteFavorites(c.Request.Context(), projectID(c), ids, actor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID := make(map[uuid.UUID]*favorite.Favorite, len(deleted))
+	for i := range deleted {
+		byID[deleted[i].ID] = &deleted[i]
+	}
+	for i, id := range request.IDs {
+		if results[i].Status != "" {
+			continue
+		}
+		// A favorite deleted by another request since it was looked up.
+		results[i] = dto.BatchFavoriteResult{Status: batchNotFound, Error: "Favorite not found"}
+		if fav, ok := byID[id]; ok {
+			results[i] = dto.BatchFavoriteResult{Status: batchDeleted, Favorite: fav}
+		}
+	}
+	c.JSON(http.StatusOK, dto.BatchFavoritesResponse{Results: results})
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"errors"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/handlers/httputil"
+	"favorites/internal/models/collection"
+	"favorites/internal/models/favorite"
+	"favorites/internal/pagetoken"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+)
+
+// CreateCollection godoc
+// @Summary       Create collection
+// @Description   Creates a named collection of the owner's favorites and responds with it as JSON.
+// @Description   Names are unique per owner. The caller must represent the owner.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  request  body    dto.CreateCollectionRequest  true  "Collection to create"
+// @Success       201  {object}  collection.Collection
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       409       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections [post]
+func CreateCollection(c *gin.Context) {
+	var request dto.CreateCollectionRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if !favorite.IsValidOwnerType(request.OwnerType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+		return
+	}
+	name, ok := parseCollectionName(c, request.Name)
+	if !ok || !authorizeOwner(c, favorite.OwnerType(request.OwnerType), request.OwnerID) {
+		return
+	}
+	col := collection.Collection{
+		ProjectID: projectID(c),
+		OwnerType: favorite.OwnerType(request.OwnerType),
+		OwnerID:   request.OwnerID,
+		Name:      name,
+	}
+	err := collections.CreateCollection(c.Request.Context(), &col)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Collection with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, col)
+}
+
+// GetCollections godoc
+// @Summary       Get collections of owner
+// @Description   Responds with all collections of the owner ordered by name as JSON. The caller must represent the owner.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  owner_type  query    favorite.OwnerType  true  "type of owner"
+// @Param		  owner_id  query    string  true  "ID of owner in uuid format"
+// @Success       200  {array}  collection.Collection
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections [get]
+func GetCollections(c *gin.Context) {
+	if !favorite.IsValidOwnerType(c.Query("owner_type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+		return
+	}
+	ownerType := favorite.OwnerType(c.Query("owner_type"))
+	ownerID, err := uuid.Parse(c.Query("owner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, ownerType, ownerID) {
+		return
+	}
+	result, err := collections.GetCollectionsByOwner(c.Request.Context(), projectID(c), ownerType, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result == nil {
+		result = []collection.Collection{}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCollection godoc
+// @Summary       Get collection by id
+// @Description   Responds with the collection as JSON. The caller must represent its owner.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of collection in uuid format"
+// @Success       200  {object}  collection.Collection
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections/{id} [get]
+func GetCollection(c *gin.Context) {
+	col, ok := loadCollection(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, col)
+}
+
+// RenameCollection godoc
+// @Summary       Rename collection
+// @Description   Renames the collection and responds with it as JSON. The caller must represent its owner.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of collection in uuid format"
+// @Param		  request  body    dto.RenameCollectionRequest  true  "New name"
+// @Success       200  {object}  collection.Collection
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       409       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections/{id} [patch]
+func RenameCollection(c *gin.Context) {
+	var request dto.RenameCollectionRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, ok := parseCollectionName(c, request.Name)
+	if !ok {
+		return
+	}
+	col, ok := loadCollection(c)
+	if !ok {
+		return
+	}
+	err := collections.RenameCollection(c.Request.Context(), &col, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Collection with this name already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, col)
+}
+
+// DeleteCollection godoc
+// @Summary       Delete collection
+// @Description   Deletes the collection, keeping the favorites in it, and responses with NoContent Code.
+// @Description   The caller must represent its owner.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of collection in uuid format"
+// @Success       204
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections/{id} [delete]
+func DeleteCollection(c *gin.Context) {
+	col, ok := loadCollection(c)
+	if !ok {
+		return
+	}
+	err := collections.DeleteCollection(c.Request.Context(), col.ProjectID, col.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetCollectionFavorites godoc
+// @Summary       Get favorites of collection
+// @Description   Responds with the page of the collection's favorites by limit as JSON, newest first unless sort says otherwise.
+// @Description   Pass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one.
+// @Description   The caller must represent the collection's owner.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of collection in uuid format"
+// @Param		  limit  query    number  true  "size of page"
+// @Param		  sort  query    string  false  "order of favorites"  Enums(newest, oldest, object_type, manual)  default(newest)
+// @Param		  after  query   string  false  "signed cursor from X-Next-Cursor, returns the following page"
+// @Param		  before  query   string  false  "signed cursor from X-Prev-Cursor, returns the preceding page"
+// @Success       200  {array}  favorite.Favorite
+// @Header        200  {string}  X-Next-Cursor  "cursor of the following page, empty on the last page"
+// @Header        200  {string}  X-Prev-Cursor  "cursor of the preceding page, empty on the first page"
+// @Header        200  {string}  X-Has-More  "whether a following page exists"
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections/{id}/favorites [get]
+func GetCollectionFavorites(c *gin.Context) {
+	col, ok := loadCollection(c)
+	if !ok {
+		return
+	}
+	scope := pagetoken.Scope{
+		"project_id": col.ProjectID.String(),
+		"collection": col.ID.String(),
+	}
+	sort, err := httputil.ParseSort(c, scope)
+	if err != nil {
+		return
+	}
+	pageRequest, err := httputil.ParsePageRequest(c, cursors, scope)
+	if err != nil {
+		return
+	}
+	pageRequest.Sort = sort
+	page, err := collections.GetPageOfCollectionFavorites(c.Request.Context(), col, pageRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(page.Favorites) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No favorites found"})
+		return
+	}
+	httputil.SetPageHeaders(c, cursors, page, scope)
+	c.JSON(http.StatusOK, page.Favorites)
+}
+
+// AddFavoriteToCollection godoc
+// @Summary       Add favorite to collection
+// @Description   Adds the favorite to the collection. Responds with 201 if it was added and 200 if it already was in the collection.
+// @Description   The favorite must belong to the collection's owner, whom the caller must represent.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of collection in uuid format"
+// @Param		  favorite_id  path    string  true  "ID of favorite in uuid format"
+// @Success       200
+// @Success       201
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections/{id}/favorites/{favorite_id} [put]
+func AddFavoriteToCollection(c *gin.Context) {
+	col, favoriteID, ok := loadCollectionFavorite(c)
+	if !ok {
+		return
+	}
+	added, err := collections.AddFavoriteToCollection(c.Request.Context(), col, favoriteID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrOwnerMismatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Favorite belongs to another owner than the collection"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !added {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Status(http.StatusCreated)
+}
+
+// RemoveFavoriteFromCollection godoc
+// @Summary       Remove favorite from collection
+// @Description   Removes the favorite from the collection, keeping the favorite itself, and responses with NoContent Code.
+// @Description   The caller must represent the collection's owner.
+// @Tags          collections
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of collection in uuid format"
+// @Param		  favorite_id  path    string  true  "ID of favorite in uuid format"
+// @Success       204
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/collections/{id}/favorites/{favorite_id} [delete]
+func RemoveFavoriteFromCollection(c *gin.Context) {
+	col, favoriteID, ok := loadCollectionFavorite(c)
+	if !ok {
+		return
+	}
+	err := collections.RemoveFavoriteFromCollection(c.Request.Context(), col, favoriteID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite is not in the collection"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// loadCollection loads the collection named by the id path parameter and
+// checks that the caller represents its owner.
+func loadCollection(c *gin.Context) (collection.Collection, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return collection.Collection{}, false
+	}
+	col, err := collections.GetCollection(c.Request.Context(), projectID(c), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return col, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return col, false
+	}
+	return col, authorizeOwner(c, col.OwnerType, col.OwnerID)
+}
+
+func loadCollectionFavorite(c *gin.Context) (collection.Collection, uuid.UUID, bool) {
+	favoriteID, err := uuid.Parse(c.Param("favorite_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid favorite_id"})
+		return collection.Collection{}, uuid.Nil, false
+	}
+	col, ok := loadCollection(c)
+	return col, favoriteID, ok
+}
+
+func parseCollectionName(c *gin.Context, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Collection name must not be blank"})
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+)
+
+// BatchCreateFavoritesRequest carries favorites to create. Each item is
+// checked like CreateFavoriteRequest, one by one so that partial batches can
+// tell which items failed.
+type BatchCreateFavoritesRequest struct {
+	Items []CreateFavoriteRequest `json:"items" binding:"required,min=1"`
+}
+
+type BatchDeleteFavoritesRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1,unique"`
+}
+
+type BatchFavoritesResponse struct {
+	Results []BatchFavoriteResult `json:"results"`
+}
+
+// BatchFavoriteResult tells what became of the item at the same index.
+// Favorite is the created, existing or deleted favorite, and Error explains
+// an item that was left out of a partial batch.
+type BatchFavoriteResult struct {
+	Status   string             `json:"status" enums:"created,exists,deleted,invalid,forbidden,not_found"`
+	Favorite *favorite.Favorite `json:"favorite,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+type CreateCollectionRequest struct {
+	OwnerType string    `json:"owner_type" binding:"required"`
+	OwnerID   uuid.UUID `json:"owner_id" binding:"required"`
+	Name      string    `json:"name" binding:"required,max=200"`
+}
+
+type RenameCollectionRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+}
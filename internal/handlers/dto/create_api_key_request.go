@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"favorites/internal/models/apikey"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse carries the token of a new key. The token is only
+// ever shown in this response.
+type CreateAPIKeyResponse struct {
+	APIKey apikey.APIKey `json:"api_key"`
+	Token  string        `json:"token"`
+}
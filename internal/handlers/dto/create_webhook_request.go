@@ -0,0 +1,11 @@
+package dto
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+	// Secret keys the HMAC-SHA256 signature of every delivery.
+	Secret string `json:"secret" binding:"required,min=16"`
+	// Events and ObjectTypes filter what is delivered, empty ones let through
+	// both events and every object type.
+	Events      []string `json:"events"`
+	ObjectTypes []string `json:"object_types"`
+}
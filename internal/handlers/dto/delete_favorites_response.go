@@ -0,0 +1,7 @@
+package dto
+
+// DeleteFavoritesResponse tells how many favorites a deletion by owner or by
+// object removed.
+type DeleteFavoritesResponse struct {
+	Deleted int64 `json:"deleted"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"time"
+)
+
+// FavoriteChangeEvent is the data of an event of the favorites stream.
+type FavoriteChangeEvent struct {
+	Type outbox.Type `json:"type"`
+	// Favorite is the favorite as the change left it.
+	Favorite  favorite.Favorite `json:"favorite"`
+	ChangedAt time.Time         `json:"changed_at"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"time"
+)
+
+// FavoriteChangesResponse is a batch of changes to the favorites of an owner,
+// one per favorite. Resync asks the client to download the favorites afresh
+// and carry on from NextToken.
+type FavoriteChangesResponse struct {
+	Changes   []FavoriteChangeEvent `json:"changes"`
+	NextToken string                `json:"next_token"`
+	HasMore   bool                  `json:"has_more"`
+	Resync    bool                  `json:"resync"`
+}
+
+type PushFavoriteChangesRequest struct {
+	OwnerType string             `json:"owner_type" binding:"required"`
+	OwnerID   uuid.UUID          `json:"owner_id" binding:"required"`
+	Mutations []FavoriteMutation `json:"mutations" binding:"required,min=1,max=100,dive"`
+}
+
+// FavoriteMutation is a change the client made offline at ChangedAt. Note and
+// Tags only apply to favorite.created.
+type FavoriteMutation struct {
+	Type       string    `json:"type" binding:"required" enums:"favorite.created,favorite.deleted"`
+	ObjectType string    `json:"object_type" binding:"required"`
+	ObjectID   uuid.UUID `json:"object_id" binding:"required"`
+	ChangedAt  time.Time `json:"changed_at" binding:"required"`
+	Note       string    `json:"note" binding:"max=500"`
+	Tags       []string  `json:"tags" binding:"max=20"`
+}
+
+type PushFavoriteChangesResponse struct {
+	Results []FavoriteMutationResult `json:"results"`
+}
+
+// FavoriteMutationResult tells what became of the mutation at the same
+// index. A conflict means the server changed the favorite after the client
+// did. Favorite is the favorite of the object as the server now has it, if any.
+type FavoriteMutationResult struct {
+	Status   string             `json:"status" enums:"applied,unchanged,conflict"`
+	Favorite *favorite.Favorite `json:"favorite"`
+}
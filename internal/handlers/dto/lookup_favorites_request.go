@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+type LookupFavoritesRequest struct {
+	OwnerType  string      `json:"owner_type" binding:"required"`
+	OwnerID    uuid.UUID   `json:"owner_id" binding:"required"`
+	ObjectType string      `json:"object_type" binding:"required"`
+	ObjectIDs  []uuid.UUID `json:"object_ids" binding:"required,min=1"`
+}
+
+// LookupFavoritesResponse maps every requested object_id to the id of the
+// owner's favorite for it, or null if the object is not favorited.
+type LookupFavoritesResponse struct {
+	Favorites map[uuid.UUID]*uuid.UUID `json:"favorites"`
+}
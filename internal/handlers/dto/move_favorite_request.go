@@ -0,0 +1,14 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// MoveFavoriteRequest places a favorite after and/or before other favorites
+// of its owner. Without anchors it goes to the top of the pinned favorites or
+// of the others, depending on pinned.
+type MoveFavoriteRequest struct {
+	After  *uuid.UUID `json:"after"`
+	Before *uuid.UUID `json:"before"`
+	Pinned *bool      `json:"pinned"`
+}
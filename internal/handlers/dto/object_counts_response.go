@@ -0,0 +1,11 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// ObjectCountsResponse maps every requested object_id to the number of owners
+// that favorited it.
+type ObjectCountsResponse struct {
+	Counts map[uuid.UUID]int64 `json:"counts"`
+}
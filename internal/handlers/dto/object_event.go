@@ -0,0 +1,22 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ObjectEventRequest announces that an object was deleted or restored at
+// OccurredAt, which defaults to the time of receipt.
+type ObjectEventRequest struct {
+	Type       string    `json:"type" binding:"required" enums:"object.deleted,object.restored"`
+	ObjectType string    `json:"object_type" binding:"required"`
+	ObjectID   uuid.UUID `json:"object_id" binding:"required"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// ObjectEventResponse tells how many favorites the event hid or restored, or
+// that it was skipped as older than the latest event of the object.
+type ObjectEventResponse struct {
+	Stale     bool  `json:"stale"`
+	Favorites int64 `json:"favorites"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"time"
+)
+
+// ObjectOwner is an owner that favorited an object. It leaves out the note,
+// tags and order of the favorite, which only its owner may see.
+type ObjectOwner struct {
+	OwnerType favorite.OwnerType `json:"owner_type"`
+	OwnerID   uuid.UUID          `json:"owner_id"`
+	CreatedAt time.Time          `json:"created_at"`
+}
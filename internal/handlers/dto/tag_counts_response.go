@@ -0,0 +1,8 @@
+package dto
+
+// TagCount is a tag of an owner and the number of the owner's favorites
+// carrying it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// UpdateFavoriteRequest edits the annotations of a favorite. Omitted fields
+// are left as they are and tags replaces the whole tag set.
+type UpdateFavoriteRequest struct {
+	Note *string   `json:"note" binding:"omitempty,max=500"`
+	Tags *[]string `json:"tags" binding:"omitempty,max=20"`
+}
@@ -1,45 +1,176 @@
 package handlers
 
 import (
-	"encoding/base64"
+	"errors"
+	"favorites/config"
 	_ "favorites/docs"
 	"favorites/internal/handlers/dto"
 	"favorites/internal/handlers/httputil"
+	"favorites/internal/models/apikey"
 	"favorites/internal/models/favorite"
+	"favorites/internal/objects"
+	"favorites/internal/pagetoken"
 	"favorites/internal/repository"
+	"favorites/internal/stream"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jmoiron/sqlx"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"log"
 	"net/http"
-	"strconv"
+	"slices"
+	"strings"
+	"time"
 )
 
-var repo *repository.FavoriteRepository
+var (
+	repo             repository.FavoriteStore
+	apiKeys          repository.APIKeyStore
+	groupMembers     repository.GroupMemberStore
+	memberships      repository.MembershipSource
+	collections      repository.CollectionStore
+	auditLog         repository.AuditStore
+	webhooks         repository.WebhookStore
+	objectEvents     objects.Sink
+	changes          *stream.Hub
+	streamHeartbeat  time.Duration
+	cursors          *pagetoken.Signer
+	changeTokens     *pagetoken.Signer
+	lookupMaxObjects int
+	batchMaxItems    int
+	eraseChunkSize   int
+	restoreWindow    time.Duration
+)
 
-func RegisterRoutes(db *sqlx.DB, r *gin.Engine) {
-	repo = repository.NewFavoriteRepository(db)
-	r.GET("/favorites", GetFavorites)
-	r.POST("/favorites", CreateFavorite)
-	r.DELETE("/favorites/:id", DeleteFavorite)
+func RegisterRoutes(storage repository.Storage, cfg config.Config, r *gin.Engine) {
+	repo = storage.Favorites
+	cursors, changeTokens = newCursorSigners(cfg)
+	lookupMaxObjects = cfg.LookupMaxObjects
+	batchMaxItems = cfg.BatchMaxItems
+	eraseChunkSize = cfg.EraseChunkSize
+	restoreWindow = cfg.RestoreWindow
+	apiKeys = storage.APIKeys
+	groupMembers = storage.GroupMembers
+	memberships = storage.GroupMembers
+	collections = storage.Collections
+	auditLog = storage.Audit
+	webhooks = storage.Webhooks
+	objectEvents = objects.NewIngester(storage.Favorites, storage.Objects, cfg.EraseChunkSize)
+	changes = stream.NewHub(storage.Feed)
+	streamHeartbeat = cfg.StreamHeartbeat
+	authenticate := []gin.HandlerFunc{RequestID(), RequireProject(), Authenticate(newTokenVerifier(cfg), apiKeys, memberships)}
+	projects := r.Group("/projects/:project_id", authenticate...)
+	read, write, admin := RequireScope(apikey.ScopeRead), RequireScope(apikey.ScopeWrite), RequireScope(apikey.ScopeAdmin)
+	projects.GET("/favorites", read, GetFavorites)
+	projects.POST("/favorites", write, Idempotent(storage.Idempotency, cfg.IdempotencyWindow), CreateFavorite)
+	projects.DELETE("/favorites", write, DeleteOwnerFavorites)
+	projects.POST("/favorites/lookup", read, LookupFavorites)
+	// The custom methods of the favorites collection are told apart before
+	// authentication, so that any other path is not found like elsewhere.
+	r.POST("/projects/:project_id/favorites:method", slices.Concat(
+		[]gin.HandlerFunc{requireBatchMethod},
+		authenticate,
+		[]gin.HandlerFunc{write, BatchFavorites},
+	)...)
+	projects.GET("/favorites/stream", read, StreamFavorites)
+	projects.GET("/favorites/changes", read, GetFavoriteChanges)
+	projects.POST("/favorites/changes", write, PushFavoriteChanges)
+	projects.PATCH("/favorites/:id", write, UpdateFavorite)
+	projects.PATCH("/favorites/:id/position", write, MoveFavorite)
+	projects.DELETE("/favorites/:id", write, DeleteFavorite)
+	projects.POST("/favorites/:id/restore", write, RestoreFavorite)
+	projects.GET("/tags", read, GetTags)
+	projects.GET("/objects/counts", read, GetObjectCounts)
+	projects.POST("/objects/events", admin, IngestObjectEvent)
+	projects.GET("/objects/:type/:id/favorites", admin, GetObjectFavorites)
+	projects.DELETE("/objects/:type/:id/favorites", admin, DeleteObjectFavorites)
+	projects.GET("/users/:id/effective-favorites", read, GetEffectiveFavorites)
+	projects.PUT("/groups/:group_id/members/:user_id", admin, AddGroupMember)
+	projects.DELETE("/groups/:group_id/members/:user_id", admin, RemoveGroupMember)
+	projects.POST("/collections", write, CreateCollection)
+	projects.GET("/collections", read, GetCollections)
+	projects.GET("/collections/:id", read, GetCollection)
+	projects.PATCH("/collections/:id", write, RenameCollection)
+	projects.DELETE("/collections/:id", write, DeleteCollection)
+	projects.GET("/collections/:id/favorites", read, GetCollectionFavorites)
+	projects.PUT("/collections/:id/favorites/:favorite_id", write, AddFavoriteToCollection)
+	projects.DELETE("/collections/:id/favorites/:favorite_id", write, RemoveFavoriteFromCollection)
+	projects.GET("/audit", admin, GetAuditEvents)
+	projects.POST("/webhooks", admin, CreateWebhook)
+	projects.GET("/webhooks", admin, GetWebhooks)
+	projects.DELETE("/webhooks/:id", admin, DeleteWebhook)
+	projects.GET("/webhooks/:id/deliveries", admin, GetWebhookDeliveries)
+	projects.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", admin, RedeliverWebhookDelivery)
+	projects.POST("/api-keys", admin, CreateAPIKey)
+	projects.DELETE("/api-keys/:id", admin, RevokeAPIKey)
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
 
+var (
+	errInvalidFilter     = errors.New("invalid filter")
+	errInvalidObjectType = errors.New("Incorrect object_type")
+	errInvalidOwnerType  = errors.New("Incorrect owner_type")
+	errInvalidTag        = fmt.Errorf(
+		"Incorrect tag, tags must be 1 to %d characters long without commas", favorite.MaxTagLength,
+	)
+)
+
+// newCursorSigners returns the signers of page cursors and of change tokens.
+// They share the keys and differ in how long their tokens stay valid.
+func newCursorSigners(cfg config.Config) (*pagetoken.Signer, *pagetoken.Signer) {
+	var keys []pagetoken.Key
+	for _, key := range cfg.CursorSigningKeys {
+		keys = append(keys, pagetoken.Key{ID: key.ID, Secret: []byte(key.Secret)})
+	}
+	if len(keys) == 0 {
+		log.Println("CURSOR_SIGNING_KEYS is not set, cursors will not survive a restart")
+		keys = append(keys, pagetoken.RandomKey())
+	}
+	cursorSigner, err := pagetoken.NewSigner(keys, cfg.CursorTTL)
+	if err != nil {
+		panic(err)
+	}
+	changeSigner, err := pagetoken.NewSigner(keys, cfg.SyncRetention)
+	if err != nil {
+		panic(err)
+	}
+	return cursorSigner, changeSigner
+}
+
 // GetFavorites godoc
 // @Summary       Get favorites array
-// @Description   Responds with the page of favorites by owner_type, owner_id, limit and offset as JSON.
+// @Description   Responds with the page of the project's favorites by owner_type, owner_id and limit as JSON, newest first unless sort says otherwise.
+// @Description   The caller must represent the owner: be the USER itself or a member of the GROUP.
+// @Description   Pass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one.
+// @Description   An empty cursor header means there is no page in that direction.
+// @Description   Cursors are opaque signed tokens bound to the query, including filters and sort, and expire; a tampered, expired or foreign cursor is rejected with 400.
 // @Tags          favorites
 // @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
 // @Param		  owner_type  query    favorite.OwnerType  true  "type of owner"
 // @Param		  owner_id  query    string  true  "ID of owner in uuid format"
 // @Param		  limit  query    number  true  "size of page"
-// @Param		  cursor  query   string  true  "last id of previous page in base64 format"
+// @Param		  object_type  query    []favorite.ObjectType  false  "only favorites of these object types"  collectionFormat(multi)
+// @Param		  created_after  query    string  false  "only favorites created after the time, in RFC 3339 format"
+// @Param		  created_before  query    string  false  "only favorites created before the time, in RFC 3339 format"
+// @Param		  tag  query    []string  false  "only favorites carrying all of these tags"  collectionFormat(multi)
+// @Param		  sort  query    string  false  "order of favorites, manual lists pinned favorites first and then the owner's order"  Enums(newest, oldest, object_type, manual)  default(newest)
+// @Param		  after  query   string  false  "signed cursor from X-Next-Cursor, returns the following page"
+// @Param		  before  query   string  false  "signed cursor from X-Prev-Cursor, returns the preceding page"
+// @Param		  cursor  query   string  false  "deprecated alias of after"
 // @Success       200  {array}  favorite.Favorite
+// @Header        200  {string}  X-Next-Cursor  "cursor of the following page, empty on the last page"
+// @Header        200  {string}  X-Prev-Cursor  "cursor of the preceding page, empty on the first page"
+// @Header        200  {string}  X-Has-More  "whether a following page exists"
+// @Security      BearerAuth
+// @Security      APIKeyAuth
 // @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
 // @Failure       404       {object}  gin.H
 // @Failure       500       {object}  gin.H
-// @Router        /favorites [get]
+// @Router        /projects/{project_id}/favorites [get]
 func GetFavorites(c *gin.Context) {
 	if !favorite.IsValidOwnerType(c.Query("owner_type")) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
@@ -51,44 +182,216 @@ func GetFavorites(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	limit, err := strconv.ParseUint(c.Query("limit"), 10, 64)
-	if err != nil || limit == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+	if !authorizeOwner(c, ownerType, ownerID) {
 		return
 	}
-	cursorID, err := httputil.ParseUUIDFromBase64(c, "cursor")
+	scope := pagetoken.Scope{
+		"project_id": projectID(c).String(),
+		"owner_type": string(ownerType),
+		"owner_id":   ownerID.String(),
+	}
+	filter, err := parseFavoriteFilter(c, scope)
 	if err != nil {
 		return
 	}
-	favorites, nextCursor, err := repo.GetPageOfFavoritesByOwnerTypeAndOwnerID(ownerType, ownerID, limit, cursorID)
+	sort, err := httputil.ParseSort(c, scope)
+	if err != nil {
+		return
+	}
+	pageRequest, err := httputil.ParsePageRequest(c, cursors, scope)
+	if err != nil {
+		return
+	}
+	pageRequest.Sort = sort
+	page, err := repo.GetPageOfFavoritesByOwnerTypeAndOwnerID(
+		c.Request.Context(),
+		projectID(c),
+		ownerType,
+		ownerID,
+		filter,
+		pageRequest,
+	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if len(favorites) == 0 {
+	if len(page.Favorites) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No favorites found"})
 		return
 	}
-	var encodedCursor string
-	if nextCursor != uuid.Nil {
-		encodedCursor = base64.URLEncoding.EncodeToString([]byte(nextCursor.String()))
+	httputil.SetPageHeaders(c, cursors, page, scope)
+	c.JSON(http.StatusOK, page.Favorites)
+}
+
+// parseFavoriteFilter reads the optional listing filters and records them in
+// scope so that cursors stay bound to them.
+func parseFavoriteFilter(c *gin.Context, scope pagetoken.Scope) (repository.FavoriteFilter, error) {
+	var filter repository.FavoriteFilter
+	var err error
+	var objectTypes []string
+	for _, value := range c.QueryArray("object_type") {
+		for _, objectType := range strings.Split(value, ",") {
+			if !favorite.IsValidObjectType(objectType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect object_type"})
+				return filter, errInvalidFilter
+			}
+			if !slices.Contains(objectTypes, objectType) {
+				objectTypes = append(objectTypes, objectType)
+			}
+		}
+	}
+	if len(objectTypes) > 0 {
+		slices.Sort(objectTypes)
+		for _, objectType := range objectTypes {
+			filter.ObjectTypes = append(filter.ObjectTypes, favorite.ObjectType(objectType))
+		}
+		scope["object_type"] = strings.Join(objectTypes, ",")
 	}
-	c.Header("X-Next-Cursor", encodedCursor)
-	c.JSON(http.StatusOK, favorites)
+	var tags []string
+	for _, value := range c.QueryArray("tag") {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	if len(tags) > 0 {
+		if filter.Tags, err = parseTags(c, tags); err != nil {
+			return filter, err
+		}
+		scope["tag"] = strings.Join(filter.Tags, ",")
+	}
+	if filter.CreatedAfter, err = parseTime(c, "created_after", scope); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTime(c, "created_before", scope); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// parseTime reads the optional RFC 3339 time query parameter key and records
+// it in scope. It returns the zero time when the parameter is absent.
+func parseTime(c *gin.Context, key string, scope pagetoken.Scope) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key + ", expected RFC 3339 time"})
+		return t, err
+	}
+	scope[key] = t.UTC().Format(time.RFC3339Nano)
+	return t, nil
+}
+
+// parseTags normalizes tags and returns them sorted and without duplicates.
+func parseTags(c *gin.Context, tags []string) ([]string, error) {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	return normalized, err
+}
+
+// normalizeTags does the work of parseTags without responding.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, ok := favorite.NormalizeTag(tag)
+		if !ok {
+			return nil, errInvalidTag
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// favoriteOf checks a request to create a favorite the way CreateFavorite
+// does and returns the favorite to store, or the status and error to
+// respond with.
+func favoriteOf(c *gin.Context, request dto.CreateFavoriteRequest) (favorite.Favorite, int, error) {
+	if !favorite.IsValidObjectType(request.ObjectType) {
+		return favorite.Favorite{}, http.StatusBadRequest, errInvalidObjectType
+	} else if !favorite.IsValidOwnerType(request.OwnerType) {
+		return favorite.Favorite{}, http.StatusBadRequest, errInvalidOwnerType
+	} else if !principal(c).Represents(favorite.OwnerType(request.OwnerType), request.OwnerID) {
+		return favorite.Favorite{}, http.StatusForbidden, errForeignOwner
+	}
+	tags, err := normalizeTags(request.Tags)
+	if err != nil {
+		return favorite.Favorite{}, http.StatusBadRequest, err
+	}
+	return favorite.Favorite{
+		ProjectID:  projectID(c),
+		OwnerType:  favorite.OwnerType(request.OwnerType),
+		OwnerID:    request.OwnerID,
+		ObjectID:   request.ObjectID,
+		ObjectType: favorite.ObjectType(request.ObjectType),
+		Note:       request.Note,
+		Tags:       tags,
+	}, 0, nil
 }
 
 // CreateFavorite godoc
 // @Summary       Create new favorite
 // @Description   Creates a new favorite entry and responses with it as JSON.
+// @Description   If the owner already favorited the object, responds with the existing entry and 200 instead of 201.
+// @Description   Requests repeating an Idempotency-Key within the idempotency window get the original response replayed.
+// @Description   Tags are trimmed and lowercased; the note and tags of an existing entry are left as they are.
 // @Tags          favorites
 // @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
 // @Param		  request  body    dto.CreateFavoriteRequest  true  "Favorite to create"
+// @Param		  Idempotency-Key  header    string  false  "key identifying retries of the same request"
 // @Success       200  {object}  favorite.Favorite
+// @Success       201  {object}  favorite.Favorite
+// @Security      BearerAuth
+// @Security      APIKeyAuth
 // @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       422       {object}  gin.H
 // @Failure       500       {object}  gin.H
-// @Router        /favorites [post]
+// @Router        /projects/{project_id}/favorites [post]
 func CreateFavorite(c *gin.Context) {
 	var request dto.CreateFavoriteRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fav, status, err := favoriteOf(c, request)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	created, err := repo.CreateFavorite(c.Request.Context(), &fav, actor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !created {
+		c.JSON(http.StatusOK, fav)
+		return
+	}
+	c.JSON(http.StatusCreated, fav)
+}
+
+// LookupFavorites godoc
+// @Summary       Check which objects are favorited
+// @Description   Responds with a map of every requested object_id to the owner's favorite id, or null if the object is not favorited.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  request  body    dto.LookupFavoritesRequest  true  "Owner and objects to check"
+// @Success       200  {object}  dto.LookupFavoritesResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites/lookup [post]
+func LookupFavorites(c *gin.Context) {
+	var request dto.LookupFavoritesRequest
 	if err := c.ShouldBind(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -98,41 +401,307 @@ func CreateFavorite(c *gin.Context) {
 	} else if !favorite.IsValidOwnerType(request.OwnerType) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
 		return
+	} else if len(request.ObjectIDs) > lookupMaxObjects {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d object_ids are allowed", lookupMaxObjects)})
+		return
+	} else if !authorizeOwner(c, favorite.OwnerType(request.OwnerType), request.OwnerID) {
+		return
 	}
-	fav := favorite.Favorite{
-		ProjectID:  request.ProjectID,
-		OwnerType:  favorite.OwnerType(request.OwnerType),
-		OwnerID:    request.OwnerID,
-		ObjectID:   request.ObjectID,
-		ObjectType: favorite.ObjectType(request.ObjectType),
+	favoriteIDs, err := repo.LookupFavorites(
+		c.Request.Context(),
+		projectID(c),
+		favorite.OwnerType(request.OwnerType),
+		request.OwnerID,
+		favorite.ObjectType(request.ObjectType),
+		request.ObjectIDs,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response := dto.LookupFavoritesResponse{Favorites: make(map[uuid.UUID]*uuid.UUID, len(request.ObjectIDs))}
+	for _, objectID := range request.ObjectIDs {
+		if favoriteID, ok := favoriteIDs[objectID]; ok {
+			response.Favorites[objectID] = &favoriteID
+		} else {
+			response.Favorites[objectID] = nil
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateFavorite godoc
+// @Summary       Edit note and tags of favorite
+// @Description   Changes the note and/or replaces the tags of the favorite and responds with it as JSON. Omitted fields are left as they are.
+// @Description   Tags are trimmed and lowercased. The caller must represent the owner of the favorite.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of favorite to edit in uuid format"
+// @Param		  request  body    dto.UpdateFavoriteRequest  true  "New note and tags"
+// @Success       200  {object}  favorite.Favorite
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites/{id} [patch]
+func UpdateFavorite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var request dto.UpdateFavoriteRequest
+	if err = c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update := repository.FavoriteUpdate{Note: request.Note}
+	if request.Tags != nil {
+		tags, err := parseTags(c, *request.Tags)
+		if err != nil {
+			return
+		}
+		update.Tags = &tags
+	}
+	fav, err := repo.GetFavorite(c.Request.Context(), projectID(c), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
 	}
-	err := repo.CreateFavorite(&fav)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, fav)
+	if !authorizeOwner(c, fav.OwnerType, fav.OwnerID) {
+		return
+	}
+	fav, err = repo.UpdateFavorite(c.Request.Context(), projectID(c), id, update)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fav)
+}
+
+// MoveFavorite godoc
+// @Summary       Move favorite in manual order
+// @Description   Places the favorite directly after the favorite after and/or directly before the favorite before and responds with it as JSON.
+// @Description   Anchors must belong to the same owner, and the favorite joins their section: pinned favorites or the others.
+// @Description   Without anchors pinned moves the favorite to the top of the pinned favorites or of the others.
+// @Description   Only the moved favorite changes, so concurrent moves of other favorites are not lost.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of favorite to move in uuid format"
+// @Param		  request  body    dto.MoveFavoriteRequest  true  "New place of the favorite"
+// @Success       200  {object}  favorite.Favorite
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites/{id}/position [patch]
+func MoveFavorite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var request dto.MoveFavoriteRequest
+	if err = c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.After == nil && request.Before == nil && request.Pinned == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "One of after, before and pinned is required"})
+		return
+	}
+	fav, err := repo.GetFavorite(c.Request.Context(), projectID(c), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, fav.OwnerType, fav.OwnerID) {
+		return
+	}
+	move := repository.Move{After: request.After, Before: request.Before, Pinned: request.Pinned}
+	fav, err = repo.MoveFavorite(c.Request.Context(), projectID(c), id, move)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite or anchor not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrOwnerMismatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Anchor belongs to another owner"})
+		return
+	}
+	if errors.Is(err, repository.ErrInvalidMove) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Anchors are out of order or disagree with pinned"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fav)
+}
+
+// DeleteOwnerFavorites godoc
+// @Summary       Delete all favorites of an owner
+// @Description   Deletes every favorite of the owner within the project, e.g. when a user closes their account, and responds with how many were deleted.
+// @Description   Favorites are deleted in transactions of ERASE_CHUNK_SIZE, each recorded like DELETE /favorites/{id},
+// @Description   and an ERASE receipt with the total is appended to the audit log. A request that failed midway can be repeated.
+// @Description   The caller must represent the owner: be the USER itself or a member of the GROUP.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  owner_type  query    favorite.OwnerType  true  "type of owner"
+// @Param		  owner_id  query    string  true  "ID of owner in uuid format"
+// @Success       200  {object}  dto.DeleteFavoritesResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites [delete]
+func DeleteOwnerFavorites(c *gin.Context) {
+	if !favorite.IsValidOwnerType(c.Query("owner_type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+		return
+	}
+	ownerType := favorite.OwnerType(c.Query("owner_type"))
+	ownerID, err := uuid.Parse(c.Query("owner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, ownerType, ownerID) {
+		return
+	}
+	erasure := repository.Erasure{OwnerType: ownerType, OwnerID: ownerID}
+	deleted, err := repo.EraseFavorites(c.Request.Context(), projectID(c), erasure, eraseChunkSize, actor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.DeleteFavoritesResponse{Deleted: deleted})
 }
 
 // DeleteFavorite godoc
 // @Summary       Delete favorite by id
-// @Description   Deletes favorite entry and responses with NoContent Code.
+// @Description   Deletes favorite entry of the project and responses with NoContent Code.
+// @Description   The entry can be restored within the restore window and is purged for good after the retention period.
+// @Description   Favorites of other projects are reported as not found, favorites of owners the caller does not represent are forbidden.
 // @Tags          favorites
 // @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
 // @Param		  id  path    string  true  "ID of favorite to delete in uuid format"
-// @Success       201
+// @Success       204
+// @Security      BearerAuth
+// @Security      APIKeyAuth
 // @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
 // @Failure       500       {object}  gin.H
-// @Router        /favorites/id [delete]
+// @Router        /projects/{project_id}/favorites/{id} [delete]
 func DeleteFavorite(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	fav, err := repo.GetFavorite(c.Request.Context(), projectID(c), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, fav.OwnerType, fav.OwnerID) {
+		return
+	}
+	err = repo.DeleteFavorite(c.Request.Context(), projectID(c), id, actor(c))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
 	}
-	err = repo.DeleteFavorite(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// RestoreFavorite godoc
+// @Summary       Restore deleted favorite
+// @Description   Undoes the deletion of the favorite within the restore window and responds with it as JSON.
+// @Description   Responds with 409 if the owner has favorited the same object again since, or if the favorite was hidden by the deletion of its object, which restores it together with the object.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of deleted favorite in uuid format"
+// @Success       200  {object}  favorite.Favorite
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       409       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites/{id}/restore [post]
+func RestoreFavorite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	deletedAfter := time.Now().UTC().Add(-restoreWindow)
+	fav, err := repo.GetDeletedFavorite(c.Request.Context(), projectID(c), id, deletedAfter)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No deleted favorite to restore"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, fav.OwnerType, fav.OwnerID) {
+		return
+	}
+	fav, err = repo.RestoreFavorite(c.Request.Context(), projectID(c), id, deletedAfter, actor(c))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No deleted favorite to restore"})
+		return
+	}
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The object was favorited again"})
+		return
+	}
+	if errors.Is(err, repository.ErrHiddenByObject) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The favorite is restored together with its object"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, fav)
+}
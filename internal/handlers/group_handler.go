@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// AddGroupMember godoc
+// @Summary       Add user to group
+// @Description   Adds the user to the group of the project. Responds with 201 if the user was added and 200 if the user already was a member.
+// @Description   Requires the admin scope. Members act for the group from their next request.
+// @Tags          groups
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  group_id  path    string  true  "ID of group in uuid format"
+// @Param		  user_id  path    string  true  "ID of user in uuid format"
+// @Success       200
+// @Success       201
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/groups/{group_id}/members/{user_id} [put]
+func AddGroupMember(c *gin.Context) {
+	groupID, userID, ok := parseGroupMember(c)
+	if !ok {
+		return
+	}
+	added, err := groupMembers.AddGroupMember(c.Request.Context(), projectID(c), groupID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !added {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.Status(http.StatusCreated)
+}
+
+// RemoveGroupMember godoc
+// @Summary       Remove user from group
+// @Description   Removes the user from the group of the project and responses with NoContent Code.
+// @Description   Requires the admin scope. The user stops acting for the group from its next request.
+// @Tags          groups
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  group_id  path    string  true  "ID of group in uuid format"
+// @Param		  user_id  path    string  true  "ID of user in uuid format"
+// @Success       204
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/groups/{group_id}/members/{user_id} [delete]
+func RemoveGroupMember(c *gin.Context) {
+	groupID, userID, ok := parseGroupMember(c)
+	if !ok {
+		return
+	}
+	err := groupMembers.RemoveGroupMember(c.Request.Context(), projectID(c), groupID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group member not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseGroupMember reads the group and user path parameters.
+func parseGroupMember(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	groupID, err := uuid.Parse(c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return groupID, userID, true
+}
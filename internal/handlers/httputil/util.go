@@ -1,26 +1,89 @@
 package httputil
 
 import (
-	"encoding/base64"
+	"errors"
+	"favorites/internal/pagetoken"
+	"favorites/internal/repository"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"net/http"
+	"strconv"
 )
 
-func ParseUUIDFromBase64(c *gin.Context, key string) (uuid.UUID, error) {
-	cursorBase64 := c.Query(key)
-	var cursorID uuid.UUID
-	if cursorBase64 != "" {
-		decodedCursor, err := base64.URLEncoding.DecodeString(cursorBase64)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
-			return uuid.UUID{}, err
-		}
-		cursorID, err = uuid.Parse(string(decodedCursor))
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return uuid.UUID{}, err
+func ParseCursor(
+	c *gin.Context,
+	signer *pagetoken.Signer,
+	key string,
+	direction pagetoken.Direction,
+	scope pagetoken.Scope,
+) (*repository.Cursor, error) {
+	token := c.Query(key)
+	if token == "" {
+		return nil, nil
+	}
+	cursor, err := signer.Decode(token, direction, scope)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key + ": " + err.Error()})
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// ParsePageRequest reads limit together with the after (or legacy cursor)
+// and before query parameters. Cursors must have been issued for scope.
+func ParsePageRequest(c *gin.Context, signer *pagetoken.Signer, scope pagetoken.Scope) (repository.PageRequest, error) {
+	limit, err := strconv.ParseUint(c.Query("limit"), 10, 64)
+	if err != nil || limit == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		if err == nil {
+			err = errInvalidLimit
 		}
+		return repository.PageRequest{}, err
+	}
+	afterKey := "after"
+	if c.Query(afterKey) == "" {
+		afterKey = "cursor"
+	}
+	after, err := ParseCursor(c, signer, afterKey, pagetoken.DirectionAfter, scope)
+	if err != nil {
+		return repository.PageRequest{}, err
+	}
+	before, err := ParseCursor(c, signer, "before", pagetoken.DirectionBefore, scope)
+	if err != nil {
+		return repository.PageRequest{}, err
+	}
+	if after != nil && before != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only one of after and before may be set"})
+		return repository.PageRequest{}, errConflictingCursors
 	}
-	return cursorID, nil
+	return repository.PageRequest{Limit: limit, After: after, Before: before}, nil
 }
+
+// ParseSort reads the sort query parameter, newest first by default, and
+// records it in scope so that cursors stay bound to the order.
+func ParseSort(c *gin.Context, scope pagetoken.Scope) (repository.Sort, error) {
+	sort := c.DefaultQuery("sort", string(repository.SortNewest))
+	if !repository.IsValidSort(sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect sort"})
+		return "", errInvalidSort
+	}
+	scope["sort"] = sort
+	return repository.Sort(sort), nil
+}
+
+func SetPageHeaders(c *gin.Context, signer *pagetoken.Signer, page repository.Page, scope pagetoken.Scope) {
+	SetCursorHeaders(c, signer, page.Next, page.Prev, scope)
+}
+
+// SetCursorHeaders sets the paging headers of any listing from the cursors of
+// its following and preceding pages.
+func SetCursorHeaders(c *gin.Context, signer *pagetoken.Signer, next, prev *repository.Cursor, scope pagetoken.Scope) {
+	c.Header("X-Next-Cursor", signer.Encode(next, pagetoken.DirectionAfter, scope))
+	c.Header("X-Prev-Cursor", signer.Encode(prev, pagetoken.DirectionBefore, scope))
+	c.Header("X-Has-More", strconv.FormatBool(next != nil))
+}
+
+var (
+	errInvalidLimit       = errors.New("invalid limit")
+	errConflictingCursors = errors.New("conflicting cursors")
+	errInvalidSort        = errors.New("invalid sort")
+)
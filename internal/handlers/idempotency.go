@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// responseRecorder keeps a copy of everything written to the response so it
+// can be stored for replay.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent replays the stored response for a request that repeats an
+// Idempotency-Key seen within window. Reusing a key with a different body is
+// rejected. Requests without the header pass through untouched.
+func Idempotent(store repository.IdempotencyStore, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+		// The concrete path and the caller keep keys of different projects
+		// and callers apart, so nobody gets another caller's response replayed.
+		scope := c.Request.Method + " " + c.Request.URL.Path + " " + principal(c).Subject.String()
+		notBefore := time.Now().Add(-window)
+		stored, err := store.GetIdempotentResponse(c.Request.Context(), key, scope, notBefore)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if stored != nil {
+			if stored.RequestHash != requestHash {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": idempotencyKeyHeader + " was already used with a different request",
+				})
+				return
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, "application/json; charset=utf-8", stored.ResponseBody)
+			c.Abort()
+			return
+		}
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		// Server errors are not cached so that the client can retry them.
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		err = store.SaveIdempotentResponse(c.Request.Context(), repository.IdempotentResponse{
+			Key:          key,
+			Scope:        scope,
+			RequestHash:  requestHash,
+			StatusCode:   recorder.Status(),
+			ResponseBody: recorder.body.Bytes(),
+		}, notBefore)
+		if err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"favorites/internal/handlers/dto"
+	"favorites/internal/handlers/httputil"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/object"
+	"favorites/internal/pagetoken"
+	"favorites/internal/repository"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// GetObjectFavorites godoc
+// @Summary       Get owners who favorited an object
+// @Description   Responds with the page of owners (USER or GROUP) that favorited a single object within a project as JSON, newest first.
+// @Description   Entries carry the owner and when it favorited the object only, never the note, tags or order of the favorite.
+// @Description   Pagination works like GET /favorites. Requires the admin scope: objects belong to other services and this one does not know their owners,
+// @Description   so it cannot let an object owner in by itself. The service owning the object calls this endpoint with an admin API key on behalf of the
+// @Description   object owner and decides who may see the result.
+// @Tags          objects
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  type  path    favorite.ObjectType  true  "type of object"
+// @Param		  id  path    string  true  "ID of object in uuid format"
+// @Param		  limit  query    number  true  "size of page"
+// @Param		  after  query   string  false  "signed cursor from X-Next-Cursor, returns older favorites"
+// @Param		  before  query   string  false  "signed cursor from X-Prev-Cursor, returns newer favorites"
+// @Success       200  {array}  dto.ObjectOwner
+// @Header        200  {string}  X-Next-Cursor  "cursor of the next (older) page, empty on the last page"
+// @Header        200  {string}  X-Prev-Cursor  "cursor of the previous (newer) page, empty on the first page"
+// @Header        200  {string}  X-Has-More  "whether a next (older) page exists"
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/objects/{type}/{id}/favorites [get]
+func GetObjectFavorites(c *gin.Context) {
+	if !favorite.IsValidObjectType(c.Param("type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect object_type"})
+		return
+	}
+	objectType := favorite.ObjectType(c.Param("type"))
+	objectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	scope := pagetoken.Scope{
+		"project_id":  projectID(c).String(),
+		"object_type": string(objectType),
+		"object_id":   objectID.String(),
+	}
+	pageRequest, err := httputil.ParsePageRequest(c, cursors, scope)
+	if err != nil {
+		return
+	}
+	page, err := repo.GetPageOfFavoritesByObject(c.Request.Context(), projectID(c), objectType, objectID, pageRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(page.Favorites) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No favorites found"})
+		return
+	}
+	owners := make([]dto.ObjectOwner, len(page.Favorites))
+	for i, f := range page.Favorites {
+		owners[i] = dto.ObjectOwner{OwnerType: f.OwnerType, OwnerID: f.OwnerID, CreatedAt: f.CreatedAt}
+	}
+	httputil.SetPageHeaders(c, cursors, page, scope)
+	c.JSON(http.StatusOK, owners)
+}
+
+// DeleteObjectFavorites godoc
+// @Summary       Delete all favorites of an object
+// @Description   Deletes the favorites of every owner of the object within the project, e.g. when the object itself was removed,
+// @Description   and responds with how many were deleted. Requires the admin scope.
+// @Description   Favorites are deleted in transactions of ERASE_CHUNK_SIZE, each recorded like DELETE /favorites/{id},
+// @Description   and an ERASE receipt with the total is appended to the audit log. A request that failed midway can be repeated.
+// @Tags          objects
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  type  path    favorite.ObjectType  true  "type of object"
+// @Param		  id  path    string  true  "ID of object in uuid format"
+// @Success       200  {object}  dto.DeleteFavoritesResponse
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/objects/{type}/{id}/favorites [delete]
+func DeleteObjectFavorites(c *gin.Context) {
+	if !favorite.IsValidObjectType(c.Param("type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect object_type"})
+		return
+	}
+	objectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	erasure := repository.Erasure{ObjectType: favorite.ObjectType(c.Param("type")), ObjectID: objectID}
+	deleted, err := repo.EraseFavorites(c.Request.Context(), projectID(c), erasure, eraseChunkSize, actor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.DeleteFavoritesResponse{Deleted: deleted})
+}
+
+// IngestObjectEvent godoc
+// @Summary       Ingest an object event
+// @Description   Takes the announcement of the service owning an object that it was deleted or restored. Requires the admin scope.
+// @Description   The favorites of a deleted object are hidden by deleting them like DELETE /objects/{type}/{id}/favorites.
+// @Description   Once the object is restored the favorites hidden since are restored, except for owners that favorited it again meanwhile,
+// @Description   as long as they were not purged after DELETED_RETENTION.
+// @Description   Events of an object apply in the order of occurred_at, which defaults to and is capped at the time of receipt.
+// @Description   An event older than the latest one of the object is skipped and reported as stale. Sending an event again is harmless.
+// @Tags          objects
+// @Accept        json
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  request  body    dto.ObjectEventRequest  true  "Object event"
+// @Success       200  {object}  dto.ObjectEventResponse
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/objects/events [post]
+func IngestObjectEvent(c *gin.Context) {
+	var request dto.ObjectEventRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !object.IsValidEventType(request.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect type, expected object.deleted or object.restored"})
+		return
+	}
+	if !favorite.IsValidObjectType(request.ObjectType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect object_type"})
+		return
+	}
+	e := object.Event{
+		Type: object.EventType(request.Type),
+		Ref: object.Ref{
+			ProjectID:  projectID(c),
+			ObjectType: favorite.ObjectType(request.ObjectType),
+			ObjectID:   request.ObjectID,
+		},
+		OccurredAt: request.OccurredAt,
+	}
+	result, err := objectEvents.Ingest(c.Request.Context(), e, actor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.ObjectEventResponse{Stale: result.Stale, Favorites: result.Favorites})
+}
+
+// GetObjectCounts godoc
+// @Summary       Get favorite counters of objects
+// @Description   Responds with the number of owners that favorited each of the requested objects within a project.
+// @Tags          objects
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  object_type  query    favorite.ObjectType  true  "type of objects"
+// @Param		  object_id  query    []string  true  "IDs of objects in uuid format"  collectionFormat(multi)
+// @Success       200  {object}  dto.ObjectCountsResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/objects/counts [get]
+func GetObjectCounts(c *gin.Context) {
+	if !favorite.IsValidObjectType(c.Query("object_type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect object_type"})
+		return
+	}
+	objectType := favorite.ObjectType(c.Query("object_type"))
+	rawObjectIDs := c.QueryArray("object_id")
+	if len(rawObjectIDs) == 0 || len(rawObjectIDs) > lookupMaxObjects {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Between 1 and %d object_id values are required", lookupMaxObjects)})
+		return
+	}
+	objectIDs := make([]uuid.UUID, len(rawObjectIDs))
+	for i, rawObjectID := range rawObjectIDs {
+		objectID, err := uuid.Parse(rawObjectID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		objectIDs[i] = objectID
+	}
+	counts, err := repo.GetFavoriteCounts(c.Request.Context(), projectID(c), objectType, objectIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response := dto.ObjectCountsResponse{Counts: make(map[uuid.UUID]int64, len(objectIDs))}
+	for _, objectID := range objectIDs {
+		response.Counts[objectID] = counts[objectID]
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+const projectIDKey = "project_id"
+
+// RequireProject parses the project_id path parameter every project-scoped
+// route starts with and makes it available through projectID.
+func RequireProject() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("project_id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid project_id"})
+			return
+		}
+		c.Set(projectIDKey, id)
+		c.Next()
+	}
+}
+
+func projectID(c *gin.Context) uuid.UUID {
+	return c.MustGet(projectIDKey).(uuid.UUID)
+}
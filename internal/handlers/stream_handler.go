@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamBatchSize events are read from the log at a time.
+const streamBatchSize = 100
+
+// StreamFavorites godoc
+// @Summary       Stream favorite changes
+// @Description   Streams the favorites the owner gains and loses as Server-Sent Events, across all replicas of the service.
+// @Description   Every event is named favorite.created, favorite.deleted or favorite.hidden, carries a dto.FavoriteChangeEvent as data and the position in the event log as id;
+// @Description   favorite.hidden is a favorite lost because its object was deleted, and a restored favorite comes as favorite.created. Comment lines are sent as heartbeats while nothing changes.
+// @Description   A client reconnecting with the Last-Event-ID header first receives the events it missed, otherwise the stream starts with the next change.
+// @Description   The caller must represent the owner: be the USER itself or a member of the GROUP.
+// @Tags          favorites
+// @Produce       text/event-stream
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  owner_type  query    favorite.OwnerType  true  "type of owner"
+// @Param		  owner_id  query    string  true  "ID of owner in uuid format"
+// @Param		  Last-Event-ID  header    string  false  "id of the last event received, to resume from"
+// @Success       200  {object}  dto.FavoriteChangeEvent
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites/stream [get]
+func StreamFavorites(c *gin.Context) {
+	if !favorite.IsValidOwnerType(c.Query("owner_type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+		return
+	}
+	ownerType := favorite.OwnerType(c.Query("owner_type"))
+	ownerID, err := uuid.Parse(c.Query("owner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, ownerType, ownerID) {
+		return
+	}
+	ctx := c.Request.Context()
+	// Subscribing first makes sure no event falls between the last one looked
+	// up and the first one woken up for.
+	subscription := changes.Subscribe(projectID(c), ownerType, ownerID)
+	defer changes.Unsubscribe(subscription)
+	var lastSeq int64
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		lastSeq, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastSeq < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+	} else {
+		lastSeq, err = auditLog.GetLastEventSeq(ctx, projectID(c), ownerType, ownerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		// Every wake-up and heartbeat catches up with the log, so a lost
+		// notice only delays events until the next heartbeat.
+		for {
+			events, err := auditLog.GetEventsAfter(ctx, projectID(c), ownerType, ownerID, lastSeq, streamBatchSize)
+			if err != nil {
+				return
+			}
+			for _, e := range events {
+				if isFavoriteChange(e) {
+					if err = writeChangeEvent(c, e); err != nil {
+						return
+					}
+				}
+				lastSeq = e.Seq
+			}
+			if len(events) < streamBatchSize {
+				break
+			}
+		}
+		c.Writer.Flush()
+		select {
+		case <-ctx.Done():
+			return
+		case <-subscription.Wake():
+		case <-heartbeat.C:
+			if _, err = c.Writer.WriteString(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// isFavoriteChange reports whether e records the change of a favorite rather
+// than being an erasure receipt, which clients learn about from the deletions
+// recorded before it.
+func isFavoriteChange(e audit.Event) bool {
+	return e.Action != audit.ActionErase
+}
+
+// changeEventOf tells what the event of the log means to a client following
+// the owner's favorites: a restored favorite is created again.
+func changeEventOf(e audit.Event) dto.FavoriteChangeEvent {
+	change := dto.FavoriteChangeEvent{Type: outbox.TypeFavoriteCreated, ChangedAt: e.CreatedAt}
+	switch e.Action {
+	case audit.ActionDelete:
+		change.Type = outbox.TypeFavoriteDeleted
+	case audit.ActionHide:
+		change.Type = outbox.TypeFavoriteHidden
+	}
+	if e.After != nil {
+		change.Favorite = *e.After
+	}
+	return change
+}
+
+// writeChangeEvent writes e as a Server-Sent Event identified by its seq.
+func writeChangeEvent(c *gin.Context, e audit.Event) error {
+	change := changeEventOf(e)
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, change.Type, data)
+	return err
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"favorites/internal/pagetoken"
+	"favorites/internal/repository"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultChangesLimit = 100
+	maxChangesLimit     = 500
+)
+
+// changeTokenScope binds change tokens to the owner whose changes they list.
+func changeTokenScope(c *gin.Context, ownerType favorite.OwnerType, ownerID uuid.UUID) pagetoken.Scope {
+	return pagetoken.Scope{
+		"project_id": projectID(c).String(),
+		"changes":    string(ownerType) + ":" + ownerID.String(),
+	}
+}
+
+// encodeChangeToken returns the signed token of the position seq in the
+// owner's events. It expires after the sync retention.
+func encodeChangeToken(scope pagetoken.Scope, seq int64) string {
+	return changeTokens.Encode(&repository.Cursor{Seq: seq}, pagetoken.DirectionAfter, scope)
+}
+
+// decodeChangeToken returns the position of token, or pagetoken.ErrExpired
+// if it is older than the sync retention.
+func decodeChangeToken(token string, scope pagetoken.Scope) (int64, error) {
+	cursor, err := changeTokens.Decode(token, pagetoken.DirectionAfter, scope)
+	if err != nil {
+		return 0, err
+	}
+	return cursor.Seq, nil
+}
+
+// GetFavoriteChanges godoc
+// @Summary       Get favorite changes
+// @Description   Responds with the favorites the owner gained and lost since the change token, oldest first and one change per favorite,
+// @Description   so that clients keeping a copy of the favorites can catch up without downloading them all.
+// @Description   A favorite.deleted change is a tombstone carrying the favorite as it was deleted, and favorite.hidden one deleted because its object was;
+// @Description   a restored favorite comes as favorite.created.
+// @Description   Pass next_token as since to continue, right away while has_more is set and later for the following changes.
+// @Description   Tokens are signed and bound to the owner. Without since, with an expired token or with one older than the events still kept,
+// @Description   resync is set and no changes are listed:
+// @Description   the client should download the favorites with GET /favorites and then continue from next_token.
+// @Description   The caller must represent the owner: be the USER itself or a member of the GROUP.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  owner_type  query    favorite.OwnerType  true  "type of owner"
+// @Param		  owner_id  query    string  true  "ID of owner in uuid format"
+// @Param		  since  query    string  false  "next_token of the previous response"
+// @Param		  limit  query    number  false  "number of changes to scan, at most 500"  default(100)
+// @Success       200  {object}  dto.FavoriteChangesResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites/changes [get]
+func GetFavoriteChanges(c *gin.Context) {
+	if !favorite.IsValidOwnerType(c.Query("owner_type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+		return
+	}
+	ownerType := favorite.OwnerType(c.Query("owner_type"))
+	ownerID, err := uuid.Parse(c.Query("owner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, ownerType, ownerID) {
+		return
+	}
+	limit := defaultChangesLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(limit, maxChangesLimit)
+	}
+	scope := changeTokenScope(c, ownerType, ownerID)
+	resync := c.Query("since") == ""
+	var since int64
+	if !resync {
+		since, err = decodeChangeToken(c.Query("since"), scope)
+		if errors.Is(err, pagetoken.ErrExpired) {
+			resync = true
+		} else if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since"})
+			return
+		}
+	}
+	lastSeq, err := auditLog.GetLastEventSeq(c.Request.Context(), projectID(c), ownerType, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	firstSeq, err := auditLog.GetFirstEventSeq(c.Request.Context(), projectID(c), ownerType, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// A token from beyond the end of the log was not issued by this log, and
+	// one naming an event no longer kept has missed the events removed since.
+	if resync || since > lastSeq || since > 0 && since < firstSeq {
+		c.JSON(http.StatusOK, dto.FavoriteChangesResponse{
+			Changes:   []dto.FavoriteChangeEvent{},
+			NextToken: encodeChangeToken(scope, lastSeq),
+			Resync:    true,
+		})
+		return
+	}
+	events, err := auditLog.GetEventsAfter(c.Request.Context(), projectID(c), ownerType, ownerID, since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Only the latest change of every favorite is sent, in the order of
+	// those latest changes.
+	latest := make(map[uuid.UUID]int, len(events))
+	for i, e := range events {
+		latest[e.FavoriteID] = i
+	}
+	response := dto.FavoriteChangesResponse{Changes: []dto.FavoriteChangeEvent{}, HasMore: len(events) == limit}
+	for i, e := range events {
+		if latest[e.FavoriteID] == i && isFavoriteChange(e) {
+			response.Changes = append(response.Changes, changeEventOf(e))
+		}
+		since = e.Seq
+	}
+	response.NextToken = encodeChangeToken(scope, since)
+	c.JSON(http.StatusOK, response)
+}
+
+// PushFavoriteChanges godoc
+// @Summary       Push favorite changes
+// @Description   Applies the changes a client made to the owner's favorites while offline, in order, and responds with the outcome of each.
+// @Description   Changes name the object rather than the favorite. The last writer wins: a change made before the server last changed the
+// @Description   owner's favorite of the object is not applied and comes back as a conflict along with the server's favorite.
+// @Description   Changes dated in the future count as made now. A change that is already in effect is unchanged.
+// @Description   The caller must represent the owner: be the USER itself or a member of the GROUP.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  request  body    dto.PushFavoriteChangesRequest  true  "Owner and changes to apply"
+// @Success       200  {object}  dto.PushFavoriteChangesResponse
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/favorites/changes [post]
+func PushFavoriteChanges(c *gin.Context) {
+	var request dto.PushFavoriteChangesRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if !favorite.IsValidOwnerType(request.OwnerType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+		return
+	} else if !authorizeOwner(c, favorite.OwnerType(request.OwnerType), request.OwnerID) {
+		return
+	}
+	tags := make([][]string, len(request.Mutations))
+	for i, m := range request.Mutations {
+		if outbox.Type(m.Type) != outbox.TypeFavoriteCreated && outbox.Type(m.Type) != outbox.TypeFavoriteDeleted {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Incorrect type of mutation %d", i)})
+			return
+		} else if !favorite.IsValidObjectType(m.ObjectType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Incorrect object_type of mutation %d", i)})
+			return
+		}
+		var err error
+		if tags[i], err = parseTags(c, m.Tags); err != nil {
+			return
+		}
+	}
+	response := dto.PushFavoriteChangesResponse{Results: make([]dto.FavoriteMutationResult, 0, len(request.Mutations))}
+	// The log dates the changes of this batch by when they were applied, not
+	// by when the client made them, so the store is told about them.
+	pushed := make(map[favoriteObjectKey]*repository.PushResult)
+	now := time.Now()
+	for i, m := range request.Mutations {
+		fav := favorite.Favorite{
+			ProjectID:  projectID(c),
+			OwnerType:  favorite.OwnerType(request.OwnerType),
+			OwnerID:    request.OwnerID,
+			ObjectID:   m.ObjectID,
+			ObjectType: favorite.ObjectType(m.ObjectType),
+			Note:       m.Note,
+			Tags:       tags[i],
+		}
+		changedAt := m.ChangedAt
+		if changedAt.After(now) {
+			changedAt = now
+		}
+		key := favoriteObjectKey{objectType: fav.ObjectType, objectID: fav.ObjectID}
+		result, err := repo.PushFavoriteChange(c.Request.Context(), repository.PushedChange{
+			Favorite:  fav,
+			Deleted:   outbox.Type(m.Type) == outbox.TypeFavoriteDeleted,
+			ChangedAt: changedAt,
+			Previous:  pushed[key],
+		}, actor(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if result.Outcome != repository.PushConflict {
+			pushed[key] = &result
+		}
+		response.Results = append(response.Results, dto.FavoriteMutationResult{
+			Status:   string(result.Outcome),
+			Favorite: result.Favorite,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+type favoriteObjectKey struct {
+	objectType favorite.ObjectType
+	objectID   uuid.UUID
+}
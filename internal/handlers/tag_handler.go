@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"cmp"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/favorite"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+)
+
+// GetTags godoc
+// @Summary       Get tags of owner
+// @Description   Responds with every tag of the owner's favorites and how many favorites carry it as JSON, most used first.
+// @Description   The caller must represent the owner.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  owner_type  query    favorite.OwnerType  true  "type of owner"
+// @Param		  owner_id  query    string  true  "ID of owner in uuid format"
+// @Success       200  {array}  dto.TagCount
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/tags [get]
+func GetTags(c *gin.Context) {
+	if !favorite.IsValidOwnerType(c.Query("owner_type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect owner_type"})
+		return
+	}
+	ownerType := favorite.OwnerType(c.Query("owner_type"))
+	ownerID, err := uuid.Parse(c.Query("owner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, ownerType, ownerID) {
+		return
+	}
+	counts, err := repo.GetTagCounts(c.Request.Context(), projectID(c), ownerType, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tags := make([]dto.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, dto.TagCount{Tag: tag, Count: count})
+	}
+	slices.SortFunc(tags, func(a, b dto.TagCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Tag, b.Tag))
+	})
+	c.JSON(http.StatusOK, tags)
+}
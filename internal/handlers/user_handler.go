@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"favorites/internal/handlers/httputil"
+	"favorites/internal/models/favorite"
+	"favorites/internal/pagetoken"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+// GetEffectiveFavorites godoc
+// @Summary       Get effective favorites of user
+// @Description   Responds with the page of the user's own favorites merged with those of the user's groups as JSON, one favorite per object.
+// @Description   An object favorited several times is represented by the user's own favorite, otherwise by the newest group favorite.
+// @Description   Pass X-Next-Cursor as after to get the following page and X-Prev-Cursor as before to get the preceding one.
+// @Description   The caller must represent the user.
+// @Tags          favorites
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of user in uuid format"
+// @Param		  limit  query    number  true  "size of page"
+// @Param		  sort  query    string  false  "order of favorites"  Enums(newest, oldest, object_type)  default(newest)
+// @Param		  after  query   string  false  "signed cursor from X-Next-Cursor, returns the following page"
+// @Param		  before  query   string  false  "signed cursor from X-Prev-Cursor, returns the preceding page"
+// @Success       200  {array}  favorite.Favorite
+// @Header        200  {string}  X-Next-Cursor  "cursor of the following page, empty on the last page"
+// @Header        200  {string}  X-Prev-Cursor  "cursor of the preceding page, empty on the first page"
+// @Header        200  {string}  X-Has-More  "whether a following page exists"
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/users/{id}/effective-favorites [get]
+func GetEffectiveFavorites(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !authorizeOwner(c, favorite.OwnerTypeUser, userID) {
+		return
+	}
+	scope := pagetoken.Scope{
+		"project_id": projectID(c).String(),
+		"effective":  userID.String(),
+	}
+	sort, err := httputil.ParseSort(c, scope)
+	if err != nil {
+		return
+	}
+	if sort == repository.SortManual {
+		// Every owner has its own manual order, they cannot be merged.
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect sort, manual order is kept per owner"})
+		return
+	}
+	pageRequest, err := httputil.ParsePageRequest(c, cursors, scope)
+	if err != nil {
+		return
+	}
+	pageRequest.Sort = sort
+	groupIDs, err := memberships.GetGroupsOfUser(c.Request.Context(), projectID(c), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := repo.GetPageOfEffectiveFavorites(c.Request.Context(), projectID(c), userID, groupIDs, pageRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(page.Favorites) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No favorites found"})
+		return
+	}
+	httputil.SetPageHeaders(c, cursors, page, scope)
+	c.JSON(http.StatusOK, page.Favorites)
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"favorites/internal/models/webhook"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultDeliveriesLimit = 20
+	maxDeliveriesLimit     = 100
+)
+
+// CreateWebhook godoc
+// @Summary       Create webhook
+// @Description   Subscribes url to changes of the project's favorites and responds with the subscription.
+// @Description   Every delivery is posted as JSON with the X-Webhook-Event and X-Webhook-ID headers, and X-Webhook-Signature "t=<unix time>,v1=<signature>",
+// @Description   where the signature is the hex HMAC-SHA256 of "<unix time>.<body>" keyed with secret.
+// @Description   Failed deliveries are retried with exponential backoff and end up DEAD after the last attempt.
+// @Description   Requires the admin scope.
+// @Tags          webhooks
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  request  body    dto.CreateWebhookRequest  true  "Subscription to create"
+// @Success       201  {object}  webhook.Subscription
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/webhooks [post]
+func CreateWebhook(c *gin.Context) {
+	var request dto.CreateWebhookRequest
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, err := url.Parse(request.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect url, expected an absolute http or https URL"})
+		return
+	}
+	s := webhook.Subscription{
+		ProjectID:   projectID(c),
+		URL:         request.URL,
+		Secret:      request.Secret,
+		Events:      []outbox.Type{},
+		ObjectTypes: []favorite.ObjectType{},
+	}
+	for _, event := range request.Events {
+		if !webhook.IsValidEvent(event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect event " + event})
+			return
+		}
+		if !slices.Contains(s.Events, outbox.Type(event)) {
+			s.Events = append(s.Events, outbox.Type(event))
+		}
+	}
+	for _, objectType := range request.ObjectTypes {
+		if !favorite.IsValidObjectType(objectType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Incorrect object type " + objectType})
+			return
+		}
+		if !slices.Contains(s.ObjectTypes, favorite.ObjectType(objectType)) {
+			s.ObjectTypes = append(s.ObjectTypes, favorite.ObjectType(objectType))
+		}
+	}
+	if err = webhooks.CreateSubscription(c.Request.Context(), &s); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+// GetWebhooks godoc
+// @Summary       Get webhooks
+// @Description   Responds with all webhook subscriptions of the project, oldest first. Requires the admin scope.
+// @Tags          webhooks
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Success       200  {array}  webhook.Subscription
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/webhooks [get]
+func GetWebhooks(c *gin.Context) {
+	subscriptions, err := webhooks.GetSubscriptions(c.Request.Context(), projectID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// DeleteWebhook godoc
+// @Summary       Delete webhook
+// @Description   Deletes the webhook subscription together with its deliveries and responses with NoContent Code.
+// @Description   Requires the admin scope.
+// @Tags          webhooks
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of webhook in uuid format"
+// @Success       204
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/webhooks/{id} [delete]
+func DeleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err = webhooks.DeleteSubscription(c.Request.Context(), projectID(c), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary       Get webhook deliveries
+// @Description   Responds with the recent deliveries to the webhook, newest first, with the outcome of their latest attempt.
+// @Description   Requires the admin scope.
+// @Tags          webhooks
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of webhook in uuid format"
+// @Param		  limit  query    number  false  "number of deliveries, at most 100"  default(20)
+// @Success       200  {array}  webhook.Delivery
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = min(limit, maxDeliveriesLimit)
+	}
+	_, err = webhooks.GetSubscription(c.Request.Context(), projectID(c), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	deliveries, err := webhooks.GetDeliveries(c.Request.Context(), projectID(c), id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverWebhookDelivery godoc
+// @Summary       Redeliver webhook delivery
+// @Description   Makes a failed or dead delivery pending again with a fresh set of attempts and responds with it.
+// @Description   A delivery that succeeded cannot be redelivered. Requires the admin scope.
+// @Tags          webhooks
+// @Produce       json
+// @Param		  project_id  path    string  true  "ID of project in uuid format"
+// @Param		  id  path    string  true  "ID of webhook in uuid format"
+// @Param		  delivery_id  path    string  true  "ID of delivery in uuid format"
+// @Success       202  {object}  webhook.Delivery
+// @Security      BearerAuth
+// @Security      APIKeyAuth
+// @Failure       400       {object}  gin.H
+// @Failure       401       {object}  gin.H
+// @Failure       403       {object}  gin.H
+// @Failure       404       {object}  gin.H
+// @Failure       409       {object}  gin.H
+// @Failure       500       {object}  gin.H
+// @Router        /projects/{project_id}/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func RedeliverWebhookDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	d, err := webhooks.RedeliverDelivery(c.Request.Context(), projectID(c), id, deliveryID, time.Now().UTC())
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrAlreadyDelivered) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Delivery already succeeded"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
@@ -0,0 +1,20 @@
+package apikey
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// APIKey is a credential of a backend service, valid for a single project.
+// Only a hash of its secret is stored.
+type APIKey struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	ProjectID  uuid.UUID  `db:"project_id" json:"project_id"`
+	Name       string     `db:"name" json:"name"`
+	SecretHash string     `db:"secret_hash" json:"-"`
+	Scopes     []Scope    `db:"-" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package apikey
+
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+func IsValidScope(scope string) bool {
+	switch Scope(scope) {
+	case ScopeRead, ScopeWrite, ScopeAdmin:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,24 @@
+package audit
+
+type Action string
+
+const (
+	ActionCreate Action = "CREATE"
+	ActionDelete Action = "DELETE"
+	// ActionHide is the deletion of a favorite hidden by the deletion of its
+	// object.
+	ActionHide    Action = "HIDE"
+	ActionRestore Action = "RESTORE"
+	// ActionErase is the receipt of erasing every favorite of an owner or
+	// an object.
+	ActionErase Action = "ERASE"
+)
+
+type ActorType string
+
+const (
+	ActorTypeUser    ActorType = "USER"
+	ActorTypeService ActorType = "SERVICE"
+	// ActorTypeSystem is this service acting on its own, with a nil ID.
+	ActorTypeSystem ActorType = "SYSTEM"
+)
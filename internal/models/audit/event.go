@@ -0,0 +1,67 @@
+// Package audit describes the append-only record of changes to favorites,
+// kept to tell who created or removed a favorite and when.
+package audit
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Actor is the caller a change is attributed to and the request it made the
+// change with. ID is the user id, or the API key id for services.
+type Actor struct {
+	Type      ActorType
+	ID        uuid.UUID
+	RequestID string
+}
+
+// Event records one change to a favorite. The owner and object are copied
+// from the favorite so events stay searchable after it is purged.
+type Event struct {
+	ID uuid.UUID `db:"id" json:"id"`
+	// Seq grows in the order the events of a project were committed.
+	Seq        int64               `db:"seq" json:"seq"`
+	ProjectID  uuid.UUID           `db:"project_id" json:"project_id"`
+	FavoriteID uuid.UUID           `db:"favorite_id" json:"favorite_id"`
+	OwnerType  favorite.OwnerType  `db:"owner_type" json:"owner_type"`
+	OwnerID    uuid.UUID           `db:"owner_id" json:"owner_id"`
+	ObjectType favorite.ObjectType `db:"object_type" json:"object_type"`
+	ObjectID   uuid.UUID           `db:"object_id" json:"object_id"`
+	Action     Action              `db:"action" json:"action"`
+	ActorType  ActorType           `db:"actor_type" json:"actor_type"`
+	ActorID    uuid.UUID           `db:"actor_id" json:"actor_id"`
+	RequestID  string              `db:"request_id" json:"request_id"`
+	// Before and After are snapshots of the favorite around the change. Before
+	// is nil for a creation.
+	Before *favorite.Favorite `db:"-" json:"before"`
+	After  *favorite.Favorite `db:"-" json:"after"`
+	// Erased is the number of favorites an ERASE receipt covers. A receipt
+	// names the owner or the object whose favorites were erased and has no
+	// favorite id or snapshots.
+	Erased    int64     `db:"erased" json:"erased,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// NewEvent records the change of a favorite from before to after by actor.
+// Either snapshot may be nil, but not both.
+func NewEvent(action Action, actor Actor, before, after *favorite.Favorite) Event {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+	return Event{
+		ProjectID:  subject.ProjectID,
+		FavoriteID: subject.ID,
+		OwnerType:  subject.OwnerType,
+		OwnerID:    subject.OwnerID,
+		ObjectType: subject.ObjectType,
+		ObjectID:   subject.ObjectID,
+		Action:     action,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		RequestID:  actor.RequestID,
+		Before:     before,
+		After:      after,
+	}
+}
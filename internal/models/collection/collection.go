@@ -0,0 +1,19 @@
+package collection
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Collection is a named list an owner organises its favorites into. A
+// favorite can be in any number of collections of its owner.
+type Collection struct {
+	ID        uuid.UUID          `db:"id" json:"id"`
+	ProjectID uuid.UUID          `db:"project_id" json:"project_id"`
+	OwnerType favorite.OwnerType `db:"owner_type" json:"owner_type"`
+	OwnerID   uuid.UUID          `db:"owner_id" json:"owner_id"`
+	Name      string             `db:"name" json:"name"`
+	CreatedAt time.Time          `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `db:"updated_at" json:"updated_at"`
+}
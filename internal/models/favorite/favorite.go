@@ -12,5 +12,15 @@ type Favorite struct {
 	OwnerID    uuid.UUID  `db:"owner_id" json:"owner_id"`
 	ObjectID   uuid.UUID  `db:"object_id" json:"object_id"`
 	ObjectType ObjectType `db:"object_type" json:"object_type"`
-	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	// Position is the rank of the favorite in its owner's manual order,
+	// which lists pinned favorites first.
+	Position string `db:"position" json:"position"`
+	Pinned   bool   `db:"pinned" json:"pinned"`
+	Note     string `db:"note" json:"note"`
+	// Tags are kept in the favorite_tags table and sorted.
+	Tags      []string  `db:"-" json:"tags"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	// DeletedAt is set while the favorite is soft-deleted and can still be
+	// restored or waits to be purged.
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,23 @@
+package favorite
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxTags       = 20
+	MaxTagLength  = 50
+	MaxNoteLength = 500
+)
+
+// NormalizeTag trims and lowercases tag, so that tags differing only in case
+// or surrounding spaces are the same tag. It reports false for empty or too
+// long tags and for tags with commas, which separate tags in query strings.
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength || strings.Contains(tag, ",") {
+		return "", false
+	}
+	return tag, true
+}
@@ -0,0 +1,53 @@
+// Package object describes what is known of the objects favorites point at.
+// Objects belong to other services, which announce when one is deleted or
+// restored so that its favorites are hidden or brought back.
+package object
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventDeleted  EventType = "object.deleted"
+	EventRestored EventType = "object.restored"
+)
+
+func IsValidEventType(eventType string) bool {
+	switch EventType(eventType) {
+	case EventDeleted, EventRestored:
+		return true
+	default:
+		return false
+	}
+}
+
+// Ref names an object of a project.
+type Ref struct {
+	ProjectID  uuid.UUID           `db:"project_id" json:"project_id"`
+	ObjectType favorite.ObjectType `db:"object_type" json:"object_type"`
+	ObjectID   uuid.UUID           `db:"object_id" json:"object_id"`
+}
+
+// Event announces that the object was deleted or restored at OccurredAt, by
+// the clock of the service owning it.
+type Event struct {
+	Type EventType `json:"type"`
+	Ref
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// State is the outcome of the latest event of an object.
+type State struct {
+	Ref
+	Deleted bool `db:"deleted"`
+	// ChangedAt is when the latest event occurred.
+	ChangedAt time.Time `db:"changed_at"`
+	// HiddenAt is when the favorites of the object were last hidden because
+	// it was deleted, by the clock of this service. It is kept once the
+	// object is restored.
+	HiddenAt *time.Time `db:"hidden_at"`
+}
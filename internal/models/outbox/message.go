@@ -0,0 +1,43 @@
+// Package outbox describes the messages announcing changes to favorites to
+// downstream services. They are stored with the change itself and relayed
+// afterwards, so a change is never lost nor announced without happening.
+package outbox
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Type string
+
+const (
+	TypeFavoriteCreated Type = "favorite.created"
+	TypeFavoriteUpdated Type = "favorite.updated"
+	TypeFavoriteMoved   Type = "favorite.moved"
+	TypeFavoriteDeleted Type = "favorite.deleted"
+	// TypeFavoriteHidden announces a favorite deleted because its object was.
+	// It comes back as favorite.restored once the object is restored.
+	TypeFavoriteHidden   Type = "favorite.hidden"
+	TypeFavoriteRestored Type = "favorite.restored"
+)
+
+// Message announces one change to a favorite. IDs of the messages of a
+// project grow in the order the changes were committed, which is the order
+// they are published in.
+// Consumers should expect a message more than once and can tell repeats by
+// ID.
+type Message struct {
+	ID         int64     `db:"id" json:"id"`
+	Type       Type      `db:"type" json:"type"`
+	ProjectID  uuid.UUID `db:"project_id" json:"project_id"`
+	FavoriteID uuid.UUID `db:"favorite_id" json:"favorite_id"`
+	// Favorite is the favorite as the change left it.
+	Favorite    favorite.Favorite `db:"-" json:"favorite"`
+	CreatedAt   time.Time         `db:"created_at" json:"created_at"`
+	PublishedAt *time.Time        `db:"published_at" json:"-"`
+}
+
+func NewMessage(messageType Type, f favorite.Favorite) Message {
+	return Message{Type: messageType, ProjectID: f.ProjectID, FavoriteID: f.ID, Favorite: f}
+}
@@ -0,0 +1,24 @@
+package webhook
+
+import "favorites/internal/models/outbox"
+
+type Status string
+
+const (
+	// StatusPending deliveries wait for their next attempt.
+	StatusPending   Status = "PENDING"
+	StatusDelivered Status = "DELIVERED"
+	// StatusDead deliveries ran out of attempts and are only retried when
+	// redelivered by hand.
+	StatusDead Status = "DEAD"
+)
+
+// IsValidEvent reports whether subscriptions may filter on the message type.
+func IsValidEvent(event string) bool {
+	switch outbox.Type(event) {
+	case outbox.TypeFavoriteCreated, outbox.TypeFavoriteDeleted, outbox.TypeFavoriteHidden:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,61 @@
+// Package webhook describes the subscriptions of projects to changes of their
+// favorites and the deliveries of those changes to the subscribed URLs.
+package webhook
+
+import (
+	"encoding/json"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// Subscription asks for the project's changes to be posted to URL, signed
+// with Secret. Empty Events match both created and deleted favorites and empty
+// ObjectTypes match every object type.
+type Subscription struct {
+	ID          uuid.UUID             `db:"id" json:"id"`
+	ProjectID   uuid.UUID             `db:"project_id" json:"project_id"`
+	URL         string                `db:"url" json:"url"`
+	Secret      string                `db:"secret" json:"-"`
+	Events      []outbox.Type         `db:"-" json:"events"`
+	ObjectTypes []favorite.ObjectType `db:"-" json:"object_types"`
+	CreatedAt   time.Time             `db:"created_at" json:"created_at"`
+}
+
+// Matches reports whether the message should be delivered to the
+// subscription.
+func (s Subscription) Matches(m outbox.Message) bool {
+	if m.ProjectID != s.ProjectID {
+		return false
+	}
+	if len(s.Events) == 0 {
+		// Without a filter only the events a filter could name are sent.
+		if !IsValidEvent(string(m.Type)) {
+			return false
+		}
+	} else if !slices.Contains(s.Events, m.Type) {
+		return false
+	}
+	return len(s.ObjectTypes) == 0 || slices.Contains(s.ObjectTypes, m.Favorite.ObjectType)
+}
+
+// Delivery is one outbox message on its way to one subscription.
+type Delivery struct {
+	ID             uuid.UUID   `db:"id" json:"id"`
+	ProjectID      uuid.UUID   `db:"project_id" json:"project_id"`
+	SubscriptionID uuid.UUID   `db:"subscription_id" json:"subscription_id"`
+	MessageID      int64       `db:"message_id" json:"message_id"`
+	Event          outbox.Type `db:"event" json:"event"`
+	// Payload is the JSON body posted to the subscriber.
+	Payload       json.RawMessage `db:"payload" json:"payload" swaggertype:"object"`
+	Status        Status          `db:"status" json:"status"`
+	Attempts      int             `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	// LastStatusCode and LastError describe the latest failed attempt.
+	LastStatusCode *int       `db:"last_status_code" json:"last_status_code"`
+	LastError      *string    `db:"last_error" json:"last_error"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
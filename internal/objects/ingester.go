@@ -0,0 +1,86 @@
+// Package objects keeps favorites in step with the objects they point at,
+// which other services own. Those services announce deleted and restored
+// objects as events, and a Reconciler asks them about objects that changed
+// without one.
+package objects
+
+import (
+	"context"
+	"errors"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/object"
+	"favorites/internal/repository"
+	"time"
+)
+
+// Sink takes object events, from the HTTP endpoint or from a queue consumer.
+// Ingest returns nil once the event is applied or skipped as stale, so a
+// consumer acknowledges the message then and lets it be redelivered
+// otherwise. Ingesting an event again is harmless.
+type Sink interface {
+	Ingest(ctx context.Context, e object.Event, actor audit.Actor) (Result, error)
+}
+
+// Result tells what became of an event.
+type Result struct {
+	// Stale is set for an event older than the latest one of its object,
+	// which is skipped.
+	Stale bool
+	// Favorites is how many favorites were hidden or restored.
+	Favorites int64
+}
+
+// Ingester hides the favorites of deleted objects by soft-deleting them like
+// FavoriteStore.EraseFavorites, marked as hidden so that their owners cannot
+// restore them, and restores them once the object is restored. Hidden
+// favorites are purged with the other deleted favorites, after which a
+// restored object gets none back.
+type Ingester struct {
+	favorites repository.FavoriteStore
+	objects   repository.ObjectStore
+	chunkSize int
+	now       func() time.Time
+}
+
+var _ Sink = (*Ingester)(nil)
+
+// NewIngester returns an ingester changing favorites in transactions of at
+// most chunkSize favorites.
+func NewIngester(favorites repository.FavoriteStore, objects repository.ObjectStore, chunkSize int) *Ingester {
+	return &Ingester{favorites: favorites, objects: objects, chunkSize: chunkSize, now: time.Now}
+}
+
+// Ingest orders events of an object by OccurredAt. An event without it, or
+// claiming to occur in the future, counts as occurring now.
+func (i *Ingester) Ingest(ctx context.Context, e object.Event, actor audit.Actor) (Result, error) {
+	e.OccurredAt = e.OccurredAt.UTC()
+	if now := i.now().UTC(); e.OccurredAt.IsZero() || e.OccurredAt.After(now) {
+		e.OccurredAt = now
+	}
+	state, err := i.objects.RecordObjectEvent(ctx, e)
+	if errors.Is(err, repository.ErrStaleEvent) {
+		return Result{Stale: true}, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	// The favorites change after the event is recorded, so an event failing
+	// in between is redelivered and applied again rather than found stale.
+	var changed int64
+	switch {
+	case state.Deleted:
+		erasure := repository.Erasure{ObjectType: e.ObjectType, ObjectID: e.ObjectID, Hide: true}
+		changed, err = i.favorites.EraseFavorites(ctx, e.ProjectID, erasure, i.chunkSize, actor)
+	case state.HiddenAt != nil:
+		changed, err = i.favorites.RestoreObjectFavorites(
+			ctx,
+			e.ProjectID,
+			e.ObjectType,
+			e.ObjectID,
+			*state.HiddenAt,
+			i.chunkSize,
+			actor,
+		)
+	}
+	return Result{Favorites: changed}, err
+}
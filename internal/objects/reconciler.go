@@ -0,0 +1,155 @@
+package objects
+
+import (
+	"context"
+	"errors"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/object"
+	"favorites/internal/repository"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+// reconcilerActor is who the changes of a Reconciler are attributed to.
+var reconcilerActor = audit.Actor{Type: audit.ActorTypeSystem, RequestID: "reconciler"}
+
+// Report counts the objects a reconciliation found deleted or restored.
+type Report struct {
+	Deleted  int
+	Restored int
+}
+
+// Reconciler catches up with object events that never arrived. It asks an
+// ObjectResolver whether favorited objects still exist and whether deleted
+// ones are back, and feeds the events that implies to a Sink.
+type Reconciler struct {
+	favorites repository.FavoriteStore
+	objects   repository.ObjectStore
+	resolver  ObjectResolver
+	sink      Sink
+	batchSize int
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewReconciler returns a reconciler resolving up to batchSize objects at a
+// time every interval.
+func NewReconciler(
+	favorites repository.FavoriteStore,
+	objects repository.ObjectStore,
+	resolver ObjectResolver,
+	sink Sink,
+	batchSize int,
+	interval time.Duration,
+) *Reconciler {
+	return &Reconciler{
+		favorites: favorites,
+		objects:   objects,
+		resolver:  resolver,
+		sink:      sink,
+		batchSize: batchSize,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// ReconcileOnce walks every favorited object and every deleted one. Objects
+// the resolver fails on are left as they are and the failures are returned
+// together after the walk.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) (Report, error) {
+	deleted, errs := r.reconcile(ctx, r.favorites.GetFavoritedObjects, object.EventDeleted)
+	restored, restoreErrs := r.reconcile(ctx, r.objects.GetDeletedObjects, object.EventRestored)
+	return Report{Deleted: deleted, Restored: restored}, errors.Join(append(errs, restoreErrs...)...)
+}
+
+// reconcile walks the objects list returns batch by batch and ingests an
+// event of eventType for every one it is news for: object.deleted for
+// objects that no longer exist, object.restored for those that do. It
+// returns how many events it ingested.
+func (r *Reconciler) reconcile(
+	ctx context.Context,
+	list func(ctx context.Context, after object.Ref, limit int) ([]object.Ref, error),
+	eventType object.EventType,
+) (int, []error) {
+	ingested := 0
+	var errs []error
+	var after object.Ref
+	for {
+		refs, err := list(ctx, after, r.batchSize)
+		if err != nil {
+			return ingested, append(errs, err)
+		}
+		// Objects come sorted, so those of one project and type are adjacent
+		// and resolved together.
+		for start := 0; start < len(refs); {
+			end := start + 1
+			for end < len(refs) && refs[end].ProjectID == refs[start].ProjectID && refs[end].ObjectType == refs[start].ObjectType {
+				end++
+			}
+			n, err := r.resolve(ctx, refs[start:end], eventType)
+			ingested += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+			start = end
+		}
+		if len(refs) < r.batchSize {
+			return ingested, errs
+		}
+		after = refs[len(refs)-1]
+	}
+}
+
+// resolve asks the resolver about refs of one project and type and ingests
+// an event of eventType for those it is news for.
+func (r *Reconciler) resolve(ctx context.Context, refs []object.Ref, eventType object.EventType) (int, error) {
+	objectIDs := make([]uuid.UUID, len(refs))
+	for i, ref := range refs {
+		objectIDs[i] = ref.ObjectID
+	}
+	existing, err := r.resolver.ExistingObjects(ctx, refs[0].ProjectID, refs[0].ObjectType, objectIDs)
+	if err != nil {
+		return 0, err
+	}
+	exists := make(map[uuid.UUID]bool, len(existing))
+	for _, objectID := range existing {
+		exists[objectID] = true
+	}
+	ingested := 0
+	for _, ref := range refs {
+		if exists[ref.ObjectID] != (eventType == object.EventRestored) {
+			continue
+		}
+		e := object.Event{Type: eventType, Ref: ref, OccurredAt: r.now().UTC()}
+		result, err := r.sink.Ingest(ctx, e, reconcilerActor)
+		if err != nil {
+			return ingested, err
+		}
+		if !result.Stale {
+			ingested++
+		}
+	}
+	return ingested, nil
+}
+
+// Run reconciles right away and then every interval until ctx is done.
+// Failures are logged and retried on the next tick.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		report, err := r.ReconcileOnce(ctx)
+		if err != nil {
+			log.Printf("Failed to reconcile objects: %v", err)
+		}
+		if report.Deleted > 0 || report.Restored > 0 {
+			log.Printf("Reconciled objects: %d deleted, %d restored", report.Deleted, report.Restored)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package objects
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"favorites/internal/models/favorite"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+// ObjectResolver asks the services owning objects which of them exist.
+type ObjectResolver interface {
+	// ExistingObjects returns those of objectIDs of the project that exist.
+	// On error nothing is known about any of them.
+	ExistingObjects(
+		ctx context.Context,
+		projectID uuid.UUID,
+		objectType favorite.ObjectType,
+		objectIDs []uuid.UUID,
+	) ([]uuid.UUID, error)
+}
+
+// resolveRequest is the body HTTPResolver posts, and resolveResponse the one
+// it expects back.
+type resolveRequest struct {
+	ProjectID  uuid.UUID           `json:"project_id"`
+	ObjectType favorite.ObjectType `json:"object_type"`
+	ObjectIDs  []uuid.UUID         `json:"object_ids"`
+}
+
+type resolveResponse struct {
+	Existing []uuid.UUID `json:"existing"`
+}
+
+// HTTPResolver posts the project, object type and object ids as JSON to a URL
+// and reads the ids of the existing objects from the "existing" array of a
+// 2xx response.
+type HTTPResolver struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPResolver(url string, timeout time.Duration) *HTTPResolver {
+	return &HTTPResolver{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (r *HTTPResolver) ExistingObjects(
+	ctx context.Context,
+	projectID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectIDs []uuid.UUID,
+) ([]uuid.UUID, error) {
+	body, err := json.Marshal(resolveRequest{ProjectID: projectID, ObjectType: objectType, ObjectIDs: objectIDs})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("object resolver responded with %s", resp.Status)
+	}
+	var response resolveResponse
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid object resolver response: %w", err)
+	}
+	return response.Existing, nil
+}
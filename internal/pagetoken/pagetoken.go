@@ -0,0 +1,138 @@
+// Package pagetoken issues and verifies the opaque pagination cursors handed
+// out by the API. A token is HMAC-signed and carries the keyset position, the
+// direction it may be used in, the query it belongs to and an expiry, so a
+// client can neither forge a position nor replay a cursor against a different
+// listing.
+package pagetoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"favorites/internal/repository"
+	"maps"
+	"strings"
+	"time"
+)
+
+type Direction string
+
+const (
+	DirectionAfter  Direction = "after"
+	DirectionBefore Direction = "before"
+)
+
+var (
+	ErrMalformed     = errors.New("malformed cursor")
+	ErrBadSignature  = errors.New("cursor signature is invalid")
+	ErrExpired       = errors.New("cursor has expired")
+	ErrQueryMismatch = errors.New("cursor belongs to a different query")
+)
+
+// Key is a named HMAC secret. The key id is stored in every token so old
+// keys can keep verifying tokens while a new key signs them.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Scope identifies the query a token belongs to: owner, filters, sort and so
+// on. A token only verifies against an identical scope.
+type Scope map[string]string
+
+type payload struct {
+	Cursor    repository.Cursor `json:"c"`
+	Direction Direction         `json:"d"`
+	Scope     Scope             `json:"s"`
+	ExpiresAt int64             `json:"e"`
+}
+
+// Signer signs tokens with its first key and verifies them with any of its
+// keys, which allows rotating keys without invalidating cursors in flight.
+type Signer struct {
+	keys []Key
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func NewSigner(keys []Key, ttl time.Duration) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one cursor signing key is required")
+	}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ".") || len(key.Secret) == 0 {
+			return nil, errors.New("cursor signing keys need a non-empty id without dots and a secret")
+		}
+	}
+	return &Signer{keys: keys, ttl: ttl, now: time.Now}, nil
+}
+
+// RandomKey returns a key that only lives as long as the process, for setups
+// that do not configure signing keys.
+func RandomKey() Key {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return Key{ID: "ephemeral", Secret: secret}
+}
+
+func (s *Signer) Encode(cursor *repository.Cursor, direction Direction, scope Scope) string {
+	if cursor == nil {
+		return ""
+	}
+	encoded, _ := json.Marshal(payload{
+		Cursor:    *cursor,
+		Direction: direction,
+		Scope:     scope,
+		ExpiresAt: s.now().Add(s.ttl).Unix(),
+	})
+	body := base64.RawURLEncoding.EncodeToString(encoded)
+	key := s.keys[0]
+	return body + "." + key.ID + "." + sign(key, body)
+}
+
+// Decode verifies token and returns its position. The token must have been
+// issued for the same direction and scope it is used with.
+func (s *Signer) Decode(token string, direction Direction, scope Scope) (*repository.Cursor, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	body, keyID, signature := parts[0], parts[1], parts[2]
+	key, ok := s.key(keyID)
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(key, body))) {
+		return nil, ErrBadSignature
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var p payload
+	if err = json.Unmarshal(decoded, &p); err != nil {
+		return nil, ErrMalformed
+	}
+	if s.now().Unix() > p.ExpiresAt {
+		return nil, ErrExpired
+	}
+	if p.Direction != direction || !maps.Equal(p.Scope, scope) {
+		return nil, ErrQueryMismatch
+	}
+	return &p.Cursor, nil
+}
+
+func (s *Signer) key(id string) (Key, bool) {
+	for _, key := range s.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+func sign(key Key, body string) string {
+	mac := hmac.New(sha256.New, key.Secret)
+	mac.Write([]byte(key.ID + "." + body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
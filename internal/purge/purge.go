@@ -0,0 +1,49 @@
+// Package purge permanently removes soft-deleted favorites once their
+// retention period is over.
+package purge
+
+import (
+	"context"
+	"favorites/internal/repository"
+	"log"
+	"time"
+)
+
+type Purger struct {
+	favorites repository.FavoriteStore
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewPurger returns a purger removing favorites deleted more than retention
+// ago every interval.
+func NewPurger(favorites repository.FavoriteStore, retention time.Duration, interval time.Duration) *Purger {
+	return &Purger{favorites: favorites, retention: retention, interval: interval, now: time.Now}
+}
+
+// PurgeOnce removes the favorites whose retention is over and returns how
+// many it removed.
+func (p *Purger) PurgeOnce(ctx context.Context) (int64, error) {
+	return p.favorites.PurgeDeletedFavorites(ctx, p.now().UTC().Add(-p.retention))
+}
+
+// Run purges right away and then every interval until ctx is done. Failures
+// are logged and retried on the next tick.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		purged, err := p.PurgeOnce(ctx)
+		if err != nil {
+			log.Printf("Failed to purge deleted favorites: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted favorites", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package rank generates lexicographic ranks for manually ordered lists. A
+// rank is a string of base-36 digits compared byte by byte, like a fraction
+// written without its leading "0.". Between always finds a rank strictly
+// between two others, so moving an item only rewrites the moved item.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+var (
+	ErrInvalid   = errors.New("rank must be a non-empty string of base-36 digits not ending in 0")
+	ErrUnordered = errors.New("lower rank must sort before upper rank")
+)
+
+// Initial is the rank of the first item of an empty list.
+const Initial = "i"
+
+// Valid reports whether r is a rank. Ranks never end in "0", which keeps room
+// for another rank before every rank.
+func Valid(r string) bool {
+	if r == "" || r[len(r)-1] == '0' {
+		return false
+	}
+	for i := 0; i < len(r); i++ {
+		if strings.IndexByte(digits, r[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns a rank sorting strictly after lower and strictly before
+// upper. An empty lower means the start of the list and an empty upper its
+// end.
+func Between(lower, upper string) (string, error) {
+	if (lower != "" && !Valid(lower)) || (upper != "" && !Valid(upper)) {
+		return "", ErrInvalid
+	}
+	if lower != "" && upper != "" && lower >= upper {
+		return "", ErrUnordered
+	}
+	return midpoint(lower, upper), nil
+}
+
+// Spread returns n ranks in increasing order strictly between lower and
+// upper, with the same meaning of empty bounds as Between. The ranks are
+// placed by repeated halving, so they stay short where n calls to Between
+// pushing onto one end would grow one digit every few ranks.
+func Spread(lower, upper string, n int) ([]string, error) {
+	if (lower != "" && !Valid(lower)) || (upper != "" && !Valid(upper)) {
+		return nil, ErrInvalid
+	}
+	if lower != "" && upper != "" && lower >= upper {
+		return nil, ErrUnordered
+	}
+	return spread(make([]string, 0, n), lower, upper, n), nil
+}
+
+func spread(ranks []string, lower, upper string, n int) []string {
+	if n <= 0 {
+		return ranks
+	}
+	middle := midpoint(lower, upper)
+	ranks = spread(ranks, lower, middle, (n-1)/2)
+	ranks = append(ranks, middle)
+	return spread(ranks, middle, upper, n-1-(n-1)/2)
+}
+
+// midpoint assumes lower < upper, with "" standing for zero and for infinity
+// respectively.
+func midpoint(lower, upper string) string {
+	if upper != "" {
+		// Copy the prefix both share, padding lower with zeros.
+		n := 0
+		for n < len(upper) && digitAt(lower, n) == upper[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(lower) {
+				rest = lower[n:]
+			}
+			return upper[:n] + midpoint(rest, upper[n:])
+		}
+	}
+	low := strings.IndexByte(digits, digitAt(lower, 0))
+	high := len(digits)
+	if upper != "" {
+		high = strings.IndexByte(digits, upper[0])
+	}
+	if high-low > 1 {
+		return string(digits[(low+high+1)/2])
+	}
+	// The first digits are adjacent: a longer upper is already above lower
+	// once cut to its first digit, otherwise extend lower.
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+	rest := ""
+	if len(lower) > 1 {
+		rest = lower[1:]
+	}
+	return string(digits[low]) + midpoint(rest, "")
+}
+
+func digitAt(r string, i int) byte {
+	if i < len(r) {
+		return r[i]
+	}
+	return digits[0]
+}
@@ -0,0 +1,95 @@
+// Package relay ships the outbox messages written along with every change to
+// favorites to a Publisher. Messages leave in order and are only marked as
+// published once the publisher accepted them, so delivery is at least once.
+package relay
+
+import (
+	"context"
+	"favorites/internal/models/outbox"
+	"favorites/internal/repository"
+	"log"
+	"time"
+)
+
+// Publisher delivers a message to downstream services. It returns nil only
+// once the message is safely delivered; on error the message is offered
+// again later, before any message following it.
+type Publisher interface {
+	Publish(ctx context.Context, m outbox.Message) error
+}
+
+// Publishers publishes every message to each of the publishers in turn. A
+// publisher failing makes the whole message be offered again, so those
+// before it must tolerate seeing it twice.
+type Publishers []Publisher
+
+func (p Publishers) Publish(ctx context.Context, m outbox.Message) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Relay struct {
+	messages  repository.OutboxStore
+	publisher Publisher
+	batchSize int
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewRelay returns a relay publishing up to batchSize messages at a time
+// every interval and forgetting published messages after retention.
+func NewRelay(
+	messages repository.OutboxStore,
+	publisher Publisher,
+	batchSize int,
+	interval time.Duration,
+	retention time.Duration,
+) *Relay {
+	return &Relay{
+		messages:  messages,
+		publisher: publisher,
+		batchSize: batchSize,
+		interval:  interval,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// RelayOnce publishes pending messages batch by batch until none are left or
+// publishing fails, and returns how many it published.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		published, err := r.messages.PublishMessages(ctx, r.batchSize, r.publisher.Publish)
+		total += published
+		if err != nil || published < r.batchSize {
+			return total, err
+		}
+	}
+}
+
+// Run relays right away and then every interval until ctx is done. Failures
+// are logged and retried on the next tick.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RelayOnce(ctx); err != nil {
+			log.Printf("Failed to relay outbox messages: %v", err)
+		}
+		_, err := r.messages.DeletePublishedMessages(ctx, r.now().UTC().Add(-r.retention))
+		if err != nil {
+			log.Printf("Failed to delete published outbox messages: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"favorites/internal/models/apikey"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+type APIKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// apiKeyRow carries the scopes in a form the driver can scan.
+type apiKeyRow struct {
+	apikey.APIKey
+	Scopes pq.StringArray `db:"scopes"`
+}
+
+func (row apiKeyRow) apiKey() apikey.APIKey {
+	k := row.APIKey
+	k.Scopes = make([]apikey.Scope, len(row.Scopes))
+	for i, scope := range row.Scopes {
+		k.Scopes[i] = apikey.Scope(scope)
+	}
+	return k
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, k *apikey.APIKey) error {
+	scopes := make(pq.StringArray, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+	query := `INSERT INTO api_keys (project_id, name, secret_hash, scopes, expires_at)
+	          VALUES ($1, $2, $3, $4, $5)
+	          RETURNING id, created_at;`
+	return r.db.QueryRowxContext(ctx, query, k.ProjectID, k.Name, k.SecretHash, scopes, k.ExpiresAt).
+		Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *APIKeyRepository) GetAPIKey(ctx context.Context, id uuid.UUID) (apikey.APIKey, error) {
+	var row apiKeyRow
+	query := `SELECT id, project_id, name, secret_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	          FROM api_keys
+	          WHERE id = $1;`
+	err := r.db.GetContext(ctx, &row, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apikey.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return apikey.APIKey{}, err
+	}
+	return row.apiKey(), nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	query := `UPDATE api_keys
+	          SET revoked_at = NOW()
+	          WHERE id = $1
+	            AND project_id = $2
+	            AND revoked_at IS NULL;`
+	result, err := r.db.ExecContext(ctx, query, id, projectID)
+	if err != nil {
+		return err
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys
+	          SET last_used_at = $2
+	          WHERE id = $1
+	            AND (last_used_at IS NULL OR last_used_at < $3);`
+	_, err := r.db.ExecContext(ctx, query, id, usedAt, usedAt.Add(-apiKeyTouchInterval))
+	return err
+}
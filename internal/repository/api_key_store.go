@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"favorites/internal/models/apikey"
+	"github.com/google/uuid"
+	"time"
+)
+
+type APIKeyStore interface {
+	// CreateAPIKey stores k, filling in its id and creation time.
+	CreateAPIKey(ctx context.Context, k *apikey.APIKey) error
+	// GetAPIKey returns the key with the given id, revoked or not, and
+	// ErrNotFound if there is none.
+	GetAPIKey(ctx context.Context, id uuid.UUID) (apikey.APIKey, error)
+	// RevokeAPIKey revokes the key of the project with the given id and
+	// returns ErrNotFound if there is no such active key.
+	RevokeAPIKey(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error
+	// TouchAPIKey records that the key was used at usedAt. Updates closer than
+	// a minute to the previous one are skipped to spare writes.
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// apiKeyTouchInterval is how stale last_used_at may get before a use of the
+// key updates it.
+const apiKeyTouchInterval = time.Minute
+
+var (
+	_ APIKeyStore = (*APIKeyRepository)(nil)
+	_ APIKeyStore = (*MemoryAPIKeyRepository)(nil)
+)
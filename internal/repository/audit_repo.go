@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"slices"
+	"strings"
+	"time"
+)
+
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// eventRow carries the snapshots in a form the driver can scan.
+type eventRow struct {
+	audit.Event
+	Before []byte `db:"before"`
+	After  []byte `db:"after"`
+}
+
+func (row eventRow) event() (audit.Event, error) {
+	e := row.Event
+	for _, snapshot := range []struct {
+		raw    []byte
+		target **favorite.Favorite
+	}{{row.Before, &e.Before}, {row.After, &e.After}} {
+		if snapshot.raw == nil {
+			continue
+		}
+		if err := json.Unmarshal(snapshot.raw, snapshot.target); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// eventChannel is the channel notified of every event appended to the log,
+// with the EventNotice of the event as payload.
+const eventChannel = "favorite_events"
+
+// recordEvent appends e to the audit log within tx, filling in its id, seq
+// and creation time, and notifies eventChannel of it once tx commits.
+func recordEvent(ctx context.Context, tx *sqlx.Tx, e *audit.Event) error {
+	return recordEvents(ctx, tx, []*audit.Event{e})
+}
+
+// recordEvents appends events to the audit log with a single insert, giving
+// them seqs in the order they are passed, like recordEvent does for one.
+func recordEvents(ctx context.Context, tx *sqlx.Tx, events []*audit.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var ids, projectIDs, favoriteIDs, ownerIDs, objectIDs, actorIDs []uuid.UUID
+	var ownerTypes, objectTypes, actions, actorTypes, requestIDs pq.StringArray
+	var befores, afters []*string
+	var erased pq.Int64Array
+	for _, e := range events {
+		var snapshots [2]*string
+		for i, f := range []*favorite.Favorite{e.Before, e.After} {
+			if f == nil {
+				continue
+			}
+			raw, err := json.Marshal(f)
+			if err != nil {
+				return err
+			}
+			snapshot := string(raw)
+			snapshots[i] = &snapshot
+		}
+		// Ids are assigned up front to match the returned rows to events.
+		e.ID = uuid.New()
+		ids = append(ids, e.ID)
+		projectIDs = append(projectIDs, e.ProjectID)
+		favoriteIDs = append(favoriteIDs, e.FavoriteID)
+		ownerTypes = append(ownerTypes, string(e.OwnerType))
+		ownerIDs = append(ownerIDs, e.OwnerID)
+		objectTypes = append(objectTypes, string(e.ObjectType))
+		objectIDs = append(objectIDs, e.ObjectID)
+		actions = append(actions, string(e.Action))
+		actorTypes = append(actorTypes, string(e.ActorType))
+		actorIDs = append(actorIDs, e.ActorID)
+		requestIDs = append(requestIDs, e.RequestID)
+		befores = append(befores, snapshots[0])
+		afters = append(afters, snapshots[1])
+		erased = append(erased, e.Erased)
+	}
+	if err := lockProjectWrites(ctx, tx, projectIDs); err != nil {
+		return err
+	}
+	var rows []struct {
+		ID        uuid.UUID `db:"id"`
+		Seq       int64     `db:"seq"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	query := `INSERT INTO favorite_events (id, project_id, favorite_id, owner_type, owner_id, object_type, object_id,
+	                                       action, actor_type, actor_id, request_id, before, after, erased)
+	          SELECT id, project_id, favorite_id, owner_type, owner_id, object_type, object_id,
+	                 action, actor_type, actor_id, request_id, before, after, erased
+	          FROM UNNEST($1::uuid[], $2::uuid[], $3::uuid[], $4::TEXT[], $5::uuid[], $6::TEXT[], $7::uuid[],
+	                      $8::TEXT[], $9::TEXT[], $10::uuid[], $11::TEXT[], $12::JSONB[], $13::JSONB[], $14::BIGINT[])
+	               WITH ORDINALITY AS e (id, project_id, favorite_id, owner_type, owner_id, object_type, object_id,
+	                                     action, actor_type, actor_id, request_id, before, after, erased, n)
+	          ORDER BY n
+	          RETURNING id, seq, created_at;`
+	err := tx.SelectContext(
+		ctx,
+		&rows,
+		query,
+		uuidArray(ids),
+		uuidArray(projectIDs),
+		uuidArray(favoriteIDs),
+		ownerTypes,
+		uuidArray(ownerIDs),
+		objectTypes,
+		uuidArray(objectIDs),
+		actions,
+		actorTypes,
+		uuidArray(actorIDs),
+		requestIDs,
+		pq.Array(befores),
+		pq.Array(afters),
+		erased,
+	)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]*audit.Event, len(events))
+	for _, e := range events {
+		byID[e.ID] = e
+	}
+	for _, row := range rows {
+		byID[row.ID].Seq = row.Seq
+		byID[row.ID].CreatedAt = row.CreatedAt
+	}
+	notices := make(pq.StringArray, len(events))
+	for i, e := range events {
+		notice, err := json.Marshal(NoticeOf(*e))
+		if err != nil {
+			return err
+		}
+		notices[i] = string(notice)
+	}
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, notice) FROM UNNEST($2::TEXT[]) AS notice;`, eventChannel, notices)
+	return err
+}
+
+func (r *AuditRepository) GetPageOfEvents(
+	ctx context.Context,
+	projectID uuid.UUID,
+	filter AuditFilter,
+	pageRequest PageRequest,
+) (EventPage, error) {
+	conditions := []string{"project_id = ?"}
+	args := []interface{}{projectID}
+	if filter.OwnerType != "" {
+		conditions = append(conditions, "owner_type = ?", "owner_id = ?")
+		args = append(args, filter.OwnerType, filter.OwnerID)
+	}
+	if filter.ObjectType != "" {
+		conditions = append(conditions, "object_type = ?", "object_id = ?")
+		args = append(args, filter.ObjectType, filter.ObjectID)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "created_at > ?")
+		args = append(args, filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedBefore)
+	}
+	where := strings.Join(conditions, " AND ")
+	keys := SortNewest.keys()
+	query := `SELECT * FROM favorite_events WHERE ` + where
+	queryArgs := slices.Clone(args)
+	backward := pageRequest.Before != nil
+	if cursor := cmp.Or(pageRequest.Before, pageRequest.After); cursor != nil {
+		condition, conditionArgs := keysetCondition(keys, cursor, backward)
+		query += ` AND ` + condition
+		queryArgs = append(queryArgs, conditionArgs...)
+	}
+	// One extra row tells whether another page follows in the direction of travel.
+	query += ` ` + orderByClause(keys, backward) + ` LIMIT ?`
+	queryArgs = append(queryArgs, pageRequest.Limit+1)
+	var rows []eventRow
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), queryArgs...); err != nil {
+		return EventPage{}, err
+	}
+	hasMore := uint64(len(rows)) > pageRequest.Limit
+	if hasMore {
+		rows = rows[:pageRequest.Limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+	var page EventPage
+	for _, row := range rows {
+		e, err := row.event()
+		if err != nil {
+			return EventPage{}, err
+		}
+		page.Events = append(page.Events, e)
+	}
+	if len(page.Events) == 0 {
+		return page, nil
+	}
+	first, last := CursorOfEvent(page.Events[0]), CursorOfEvent(page.Events[len(page.Events)-1])
+	switch {
+	case pageRequest.Before != nil:
+		if hasMore {
+			page.Prev = first
+		}
+		hasFollowing, err := r.hasEventsBeyond(ctx, where, args, keys, last, false)
+		if err != nil {
+			return EventPage{}, err
+		}
+		if hasFollowing {
+			page.Next = last
+		}
+	case pageRequest.After != nil:
+		if hasMore {
+			page.Next = last
+		}
+		hasPreceding, err := r.hasEventsBeyond(ctx, where, args, keys, first, true)
+		if err != nil {
+			return EventPage{}, err
+		}
+		if hasPreceding {
+			page.Prev = first
+		}
+	default:
+		if hasMore {
+			page.Next = last
+		}
+	}
+	return page, nil
+}
+
+// hasEventsBeyond reports whether events matching where exist after the
+// cursor in the order of keys, or before it when backward is set.
+func (r *AuditRepository) hasEventsBeyond(
+	ctx context.Context,
+	where string,
+	args []interface{},
+	keys []sortKey,
+	cursor *Cursor,
+	backward bool,
+) (bool, error) {
+	var exists bool
+	condition, conditionArgs := keysetCondition(keys, cursor, backward)
+	query := `SELECT EXISTS (SELECT 1 FROM favorite_events WHERE ` + where + ` AND ` + condition + `)`
+	queryArgs := append(slices.Clone(args), conditionArgs...)
+	err := r.db.GetContext(ctx, &exists, r.db.Rebind(query), queryArgs...)
+	return exists, err
+}
+
+func (r *AuditRepository) GetEventsAfter(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+	afterSeq int64,
+	limit int,
+) ([]audit.Event, error) {
+	var rows []eventRow
+	query := `SELECT * FROM favorite_events
+	          WHERE project_id = $1 AND owner_type = $2 AND owner_id = $3 AND seq > $4
+	          ORDER BY seq
+	          LIMIT $5;`
+	if err := r.db.SelectContext(ctx, &rows, query, projectID, ownerType, ownerID, afterSeq, limit); err != nil {
+		return nil, err
+	}
+	events := make([]audit.Event, 0, len(rows))
+	for _, row := range rows {
+		e, err := row.event()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (r *AuditRepository) GetLastEventSeq(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) (int64, error) {
+	var seq int64
+	query := `SELECT COALESCE(MAX(seq), 0) FROM favorite_events
+	          WHERE project_id = $1 AND owner_type = $2 AND owner_id = $3;`
+	err := r.db.GetContext(ctx, &seq, query, projectID, ownerType, ownerID)
+	return seq, err
+}
+
+func (r *AuditRepository) GetFirstEventSeq(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) (int64, error) {
+	var seq int64
+	query := `SELECT COALESCE(MIN(seq), 0) FROM favorite_events
+	          WHERE project_id = $1 AND owner_type = $2 AND owner_id = $3;`
+	err := r.db.GetContext(ctx, &seq, query, projectID, ownerType, ownerID)
+	return seq, err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"time"
+)
+
+// AuditFilter narrows the events of a project. Zero values match everything;
+// an owner or object only narrows the events when both of its fields are set.
+type AuditFilter struct {
+	OwnerType     favorite.OwnerType
+	OwnerID       uuid.UUID
+	ObjectType    favorite.ObjectType
+	ObjectID      uuid.UUID
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+func (f AuditFilter) Matches(e audit.Event) bool {
+	if f.OwnerType != "" && (e.OwnerType != f.OwnerType || e.OwnerID != f.OwnerID) {
+		return false
+	}
+	if f.ObjectType != "" && (e.ObjectType != f.ObjectType || e.ObjectID != f.ObjectID) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !e.CreatedAt.After(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !e.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// EventPage is a slice of events, newest first, with cursors like Page.
+type EventPage struct {
+	Events []audit.Event
+	Next   *Cursor
+	Prev   *Cursor
+}
+
+func CursorOfEvent(e audit.Event) *Cursor {
+	return &Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+}
+
+// AuditStore reads the audit log. Events are written by FavoriteStore in the
+// same transaction as the change they record and are never modified.
+type AuditStore interface {
+	// GetPageOfEvents lists the events of the project newest first. The Sort
+	// of pageRequest is ignored.
+	GetPageOfEvents(ctx context.Context, projectID uuid.UUID, filter AuditFilter, pageRequest PageRequest) (EventPage, error)
+	// GetEventsAfter returns up to limit events of the owner with a seq
+	// greater than afterSeq, in seq order.
+	GetEventsAfter(
+		ctx context.Context,
+		projectID uuid.UUID,
+		ownerType favorite.OwnerType,
+		ownerID uuid.UUID,
+		afterSeq int64,
+		limit int,
+	) ([]audit.Event, error)
+	// GetLastEventSeq returns the greatest seq of the owner's events, or zero
+	// if there are none.
+	GetLastEventSeq(ctx context.Context, projectID uuid.UUID, ownerType favorite.OwnerType, ownerID uuid.UUID) (int64, error)
+	// GetFirstEventSeq returns the smallest seq of the owner's events still
+	// kept in the log, or zero if there are none.
+	GetFirstEventSeq(ctx context.Context, projectID uuid.UUID, ownerType favorite.OwnerType, ownerID uuid.UUID) (int64, error)
+}
+
+var (
+	_ AuditStore = (*AuditRepository)(nil)
+	_ AuditStore = (*MemoryAuditRepository)(nil)
+)
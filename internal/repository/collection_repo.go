@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"favorites/internal/models/collection"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type CollectionRepository struct {
+	db *sqlx.DB
+	// favorites pages through the favorites of a collection.
+	favorites *FavoriteRepository
+}
+
+func NewCollectionRepository(db *sqlx.DB) *CollectionRepository {
+	return &CollectionRepository{db: db, favorites: NewFavoriteRepository(db)}
+}
+
+// uniqueViolation is the PostgreSQL error code of a violated unique constraint.
+const uniqueViolation = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+func (r *CollectionRepository) CreateCollection(ctx context.Context, c *collection.Collection) error {
+	query := `INSERT INTO collections (project_id, owner_type, owner_id, name)
+	          VALUES ($1, $2, $3, $4)
+	          RETURNING id, project_id, owner_type, owner_id, name, created_at, updated_at;`
+	err := r.db.QueryRowxContext(ctx, query, c.ProjectID, c.OwnerType, c.OwnerID, c.Name).StructScan(c)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *CollectionRepository) GetCollection(
+	ctx context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+) (collection.Collection, error) {
+	var c collection.Collection
+	query := `SELECT id, project_id, owner_type, owner_id, name, created_at, updated_at
+	          FROM collections
+	          WHERE id = $1
+	            AND project_id = $2;`
+	err := r.db.GetContext(ctx, &c, query, id, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrNotFound
+	}
+	return c, err
+}
+
+func (r *CollectionRepository) GetCollectionsByOwner(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) ([]collection.Collection, error) {
+	var collections []collection.Collection
+	query := `SELECT id, project_id, owner_type, owner_id, name, created_at, updated_at
+	          FROM collections
+	          WHERE project_id = $1
+	            AND owner_type = $2
+	            AND owner_id = $3
+	          ORDER BY name, id;`
+	err := r.db.SelectContext(ctx, &collections, query, projectID, ownerType, ownerID)
+	return collections, err
+}
+
+func (r *CollectionRepository) RenameCollection(ctx context.Context, c *collection.Collection, name string) error {
+	query := `UPDATE collections
+	          SET name = $3, updated_at = NOW()
+	          WHERE id = $1
+	            AND project_id = $2
+	          RETURNING id, project_id, owner_type, owner_id, name, created_at, updated_at;`
+	err := r.db.QueryRowxContext(ctx, query, c.ID, c.ProjectID, name).StructScan(c)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *CollectionRepository) DeleteCollection(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM collections WHERE id = $1 AND project_id = $2;`, id, projectID)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *CollectionRepository) AddFavoriteToCollection(
+	ctx context.Context,
+	c collection.Collection,
+	favoriteID uuid.UUID,
+) (bool, error) {
+	added := false
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var f favorite.Favorite
+		query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+		          FROM favorites
+		          WHERE id = $1
+		            AND project_id = $2
+		            AND deleted_at IS NULL
+		          FOR SHARE;`
+		err := tx.GetContext(ctx, &f, query, favoriteID, c.ProjectID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if f.OwnerType != c.OwnerType || f.OwnerID != c.OwnerID {
+			return ErrOwnerMismatch
+		}
+		query = `INSERT INTO collection_favorites (collection_id, favorite_id)
+		         VALUES ($1, $2)
+		         ON CONFLICT (collection_id, favorite_id) DO NOTHING;`
+		result, err := tx.ExecContext(ctx, query, c.ID, favoriteID)
+		if err != nil {
+			return err
+		}
+		inserted, err := result.RowsAffected()
+		added = inserted > 0
+		return err
+	})
+	return added, err
+}
+
+func (r *CollectionRepository) RemoveFavoriteFromCollection(
+	ctx context.Context,
+	c collection.Collection,
+	favoriteID uuid.UUID,
+) error {
+	query := `DELETE FROM collection_favorites
+	          WHERE collection_id = $1
+	            AND favorite_id = $2;`
+	result, err := r.db.ExecContext(ctx, query, c.ID, favoriteID)
+	if err != nil {
+		return err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *CollectionRepository) GetPageOfCollectionFavorites(
+	ctx context.Context,
+	c collection.Collection,
+	pageRequest PageRequest,
+) (Page, error) {
+	return r.favorites.selectPage(
+		ctx,
+		`favorites`,
+		`project_id = ? AND id IN (SELECT favorite_id FROM collection_favorites WHERE collection_id = ?)`,
+		[]interface{}{c.ProjectID, c.ID},
+		pageRequest,
+	)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"favorites/internal/models/collection"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrAlreadyExists is returned when the owner already has a collection
+	// with the same name, or when a favorite to restore was favorited again.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrOwnerMismatch is returned when a favorite is added to a collection
+	// of another owner or placed next to a favorite of another owner.
+	ErrOwnerMismatch = errors.New("favorite belongs to another owner")
+)
+
+// CollectionStore keeps collections and their favorites. Like FavoriteStore it
+// always takes the project it acts on.
+type CollectionStore interface {
+	// CreateCollection stores c, filling in its id and timestamps.
+	CreateCollection(ctx context.Context, c *collection.Collection) error
+	// GetCollection returns the collection of the project with the given id
+	// and ErrNotFound if there is none.
+	GetCollection(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (collection.Collection, error)
+	// GetCollectionsByOwner returns all collections of the owner by name.
+	GetCollectionsByOwner(
+		ctx context.Context,
+		projectID uuid.UUID,
+		ownerType favorite.OwnerType,
+		ownerID uuid.UUID,
+	) ([]collection.Collection, error)
+	// RenameCollection renames the collection and loads the result into c.
+	RenameCollection(ctx context.Context, c *collection.Collection, name string) error
+	// DeleteCollection removes the collection but not the favorites in it.
+	DeleteCollection(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error
+	// AddFavoriteToCollection links the favorite to the collection and
+	// reports whether it was not linked yet. The favorite must belong to the
+	// collection's owner.
+	AddFavoriteToCollection(ctx context.Context, c collection.Collection, favoriteID uuid.UUID) (bool, error)
+	// RemoveFavoriteFromCollection unlinks the favorite and returns
+	// ErrNotFound if it was not in the collection.
+	RemoveFavoriteFromCollection(ctx context.Context, c collection.Collection, favoriteID uuid.UUID) error
+	// GetPageOfCollectionFavorites lists the favorites of the collection.
+	GetPageOfCollectionFavorites(ctx context.Context, c collection.Collection, pageRequest PageRequest) (Page, error)
+}
+
+var (
+	_ CollectionStore = (*CollectionRepository)(nil)
+	_ CollectionStore = (*MemoryCollectionRepository)(nil)
+)
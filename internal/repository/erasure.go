@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+)
+
+// Erasure selects every favorite of an owner or, when OwnerType is empty,
+// every favorite of an object.
+type Erasure struct {
+	OwnerType  favorite.OwnerType
+	OwnerID    uuid.UUID
+	ObjectType favorite.ObjectType
+	ObjectID   uuid.UUID
+	// Hide marks the favorites of an object hidden by the deletion of the
+	// object: they are announced as hidden rather than deleted, and only
+	// RestoreObjectFavorites brings them back.
+	Hide bool
+}
+
+// Matches reports whether f is one of the favorites the erasure selects.
+func (e Erasure) Matches(f favorite.Favorite) bool {
+	if e.OwnerType != "" {
+		return f.OwnerType == e.OwnerType && f.OwnerID == e.OwnerID
+	}
+	return f.ObjectType == e.ObjectType && f.ObjectID == e.ObjectID
+}
+
+// receipt is the audit event recording that actor erased count favorites
+// selected by the erasure. It names the owner or the object only, so it
+// shows up among the events of that owner or object.
+func (e Erasure) receipt(projectID uuid.UUID, count int64, actor audit.Actor) audit.Event {
+	return audit.Event{
+		ProjectID:  projectID,
+		OwnerType:  e.OwnerType,
+		OwnerID:    e.OwnerID,
+		ObjectType: e.ObjectType,
+		ObjectID:   e.ObjectID,
+		Action:     audit.ActionErase,
+		ActorType:  actor.Type,
+		ActorID:    actor.ID,
+		RequestID:  actor.RequestID,
+		Erased:     count,
+	}
+}
+
+// redact returns a copy of f without its note and tags. Erasing an owner
+// redacts every copy of its favorites the service keeps after the erasure.
+func redact(f *favorite.Favorite) *favorite.Favorite {
+	if f == nil {
+		return nil
+	}
+	redacted := *f
+	redacted.Note = ""
+	redacted.Tags = []string{}
+	return &redacted
+}
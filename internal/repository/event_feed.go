@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+)
+
+// EventNotice announces that an event was appended to the audit log of an
+// owner. The zero notice means notices may have been lost, so listeners
+// should catch up on every owner they follow.
+type EventNotice struct {
+	ProjectID uuid.UUID          `json:"project_id"`
+	OwnerType favorite.OwnerType `json:"owner_type"`
+	OwnerID   uuid.UUID          `json:"owner_id"`
+	Seq       int64              `json:"seq"`
+}
+
+func NoticeOf(e audit.Event) EventNotice {
+	return EventNotice{ProjectID: e.ProjectID, OwnerType: e.OwnerType, OwnerID: e.OwnerID, Seq: e.Seq}
+}
+
+// EventFeed tells about events as they are committed, across all replicas
+// sharing the storage. Notices only wake listeners up: the events themselves
+// are read from the AuditStore.
+type EventFeed interface {
+	// Listen delivers the notices of events committed from now on. The
+	// channel is closed once ctx is done or the feed fails.
+	Listen(ctx context.Context) (<-chan EventNotice, error)
+}
+
+var (
+	_ EventFeed = (*EventListener)(nil)
+	_ EventFeed = (*MemoryAuditRepository)(nil)
+)
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lib/pq"
+	"log"
+	"time"
+)
+
+// listenerPingInterval is how often an idle listener checks its connection.
+const listenerPingInterval = time.Minute
+
+// EventListener is an EventFeed over PostgreSQL LISTEN/NOTIFY. Every Listen
+// holds a connection of its own.
+type EventListener struct {
+	dbURL string
+}
+
+func NewEventListener(dbURL string) *EventListener {
+	return &EventListener{dbURL: dbURL}
+}
+
+func (l *EventListener) Listen(ctx context.Context) (<-chan EventNotice, error) {
+	listener := pq.NewListener(l.dbURL, time.Second, time.Minute, nil)
+	if err := listener.Listen(eventChannel); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	notices := make(chan EventNotice, 64)
+	go func() {
+		defer close(notices)
+		defer listener.Close()
+		ping := time.NewTicker(listenerPingInterval)
+		defer ping.Stop()
+		for {
+			var notice EventNotice
+			select {
+			case <-ctx.Done():
+				return
+			case <-ping.C:
+				go func() { _ = listener.Ping() }()
+				continue
+			case n := <-listener.Notify:
+				// A nil notification follows a reconnect, after which the
+				// notices sent meanwhile are gone; it passes on as the zero
+				// notice.
+				if n != nil {
+					if err := json.Unmarshal([]byte(n.Extra), &notice); err != nil {
+						log.Printf("Failed to decode event notice: %v", err)
+						continue
+					}
+				}
+			}
+			select {
+			case notices <- notice:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return notices, nil
+}
@@ -1,9 +1,19 @@
 package repository
 
 import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"favorites/internal/models/audit"
 	"favorites/internal/models/favorite"
+	"favorites/internal/models/object"
+	"favorites/internal/models/outbox"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"slices"
+	"time"
 )
 
 type FavoriteRepository struct {
@@ -15,62 +25,1471 @@ func NewFavoriteRepository(db *sqlx.DB) *FavoriteRepository {
 }
 
 func (r *FavoriteRepository) GetPageOfFavoritesByOwnerTypeAndOwnerID(
+	ctx context.Context,
+	projectID uuid.UUID,
 	ownerType favorite.OwnerType,
 	ownerID uuid.UUID,
-	limit uint64,
-	cursorID uuid.UUID,
-) ([]favorite.Favorite, uuid.UUID, error) {
+	filter FavoriteFilter,
+	pageRequest PageRequest,
+) (Page, error) {
+	where := `project_id = ? AND owner_type = ? AND owner_id = ?`
+	args := []interface{}{projectID, ownerType, ownerID}
+	if len(filter.ObjectTypes) > 0 {
+		objectTypes := make(pq.StringArray, len(filter.ObjectTypes))
+		for i, objectType := range filter.ObjectTypes {
+			objectTypes[i] = string(objectType)
+		}
+		where += ` AND object_type = ANY(?)`
+		args = append(args, objectTypes)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where += ` AND created_at > ?`
+		args = append(args, filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where += ` AND created_at < ?`
+		args = append(args, filter.CreatedBefore)
+	}
+	if len(filter.Tags) > 0 {
+		where += ` AND id IN (SELECT favorite_id
+		                      FROM favorite_tags
+		                      WHERE tag = ANY(?)
+		                      GROUP BY favorite_id
+		                      HAVING COUNT(*) = ?)`
+		args = append(args, pq.StringArray(filter.Tags), len(filter.Tags))
+	}
+	return r.selectPage(ctx, `favorites`, where, args, pageRequest)
+}
+
+func (r *FavoriteRepository) GetPageOfFavoritesByObject(
+	ctx context.Context,
+	projectID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectID uuid.UUID,
+	pageRequest PageRequest,
+) (Page, error) {
+	return r.selectPage(
+		ctx,
+		`favorites`,
+		`object_type = ? AND object_id = ? AND project_id = ?`,
+		[]interface{}{objectType, objectID, projectID},
+		pageRequest,
+	)
+}
+
+func (r *FavoriteRepository) GetPageOfEffectiveFavorites(
+	ctx context.Context,
+	projectID uuid.UUID,
+	userID uuid.UUID,
+	groupIDs []uuid.UUID,
+	pageRequest PageRequest,
+) (Page, error) {
+	// One row per object: the user's own favorite if there is one, otherwise
+	// the newest favorite of the user's groups.
+	effective := `(
+		SELECT DISTINCT ON (object_type, object_id) *
+		FROM favorites
+		WHERE project_id = ?
+		  AND deleted_at IS NULL
+		  AND ((owner_type = ? AND owner_id = ?) OR (owner_type = ? AND owner_id = ANY(?::uuid[])))
+		ORDER BY object_type, object_id, owner_type = ? DESC, created_at DESC, id DESC
+	) AS effective`
+	args := []interface{}{
+		projectID,
+		favorite.OwnerTypeUser,
+		userID,
+		favorite.OwnerTypeGroup,
+		uuidArray(groupIDs),
+		favorite.OwnerTypeUser,
+	}
+	return r.selectPage(ctx, effective, `TRUE`, args, pageRequest)
+}
+
+// selectPage runs a keyset paginated listing of the favorites in from, a table
+// or subquery, matching where. Both are written with ? placeholders for args.
+// Soft-deleted favorites are never listed.
+func (r *FavoriteRepository) selectPage(
+	ctx context.Context,
+	from string,
+	where string,
+	args []interface{},
+	pageRequest PageRequest,
+) (Page, error) {
 	var favorites []favorite.Favorite
-	var args []interface{}
+	keys := pageRequest.Sort.keys()
 	query := `
 		SELECT *
-		FROM favorites
-		WHERE owner_type = $1
-		  AND owner_id = $2
+		FROM ` + from + `
+		WHERE deleted_at IS NULL
+		  AND (` + where + `)`
+	queryArgs := slices.Clone(args)
+	backward := pageRequest.Before != nil
+	if cursor := cmp.Or(pageRequest.Before, pageRequest.After); cursor != nil {
+		condition, conditionArgs := keysetCondition(keys, cursor, backward)
+		query += ` AND ` + condition
+		queryArgs = append(queryArgs, conditionArgs...)
+	}
+	// One extra row tells whether another page follows in the direction of travel.
+	query += ` ` + orderByClause(keys, backward) + ` LIMIT ?`
+	queryArgs = append(queryArgs, pageRequest.Limit+1)
+	err := r.db.SelectContext(ctx, &favorites, r.db.Rebind(query), queryArgs...)
+	if err != nil {
+		return Page{}, err
+	}
+	hasMore := uint64(len(favorites)) > pageRequest.Limit
+	if hasMore {
+		favorites = favorites[:pageRequest.Limit]
+	}
+	if backward {
+		slices.Reverse(favorites)
+	}
+	if err = loadTags(ctx, r.db, favorites); err != nil {
+		return Page{}, err
+	}
+	page := Page{Favorites: favorites}
+	if len(favorites) == 0 {
+		return page, nil
+	}
+	first, last := CursorOf(favorites[0]), CursorOf(favorites[len(favorites)-1])
+	switch {
+	case pageRequest.Before != nil:
+		if hasMore {
+			page.Prev = first
+		}
+		hasFollowing, err := r.hasFavoritesBeyond(ctx, from, where, args, keys, last, false)
+		if err != nil {
+			return Page{}, err
+		}
+		if hasFollowing {
+			page.Next = last
+		}
+	case pageRequest.After != nil:
+		if hasMore {
+			page.Next = last
+		}
+		hasPreceding, err := r.hasFavoritesBeyond(ctx, from, where, args, keys, first, true)
+		if err != nil {
+			return Page{}, err
+		}
+		if hasPreceding {
+			page.Prev = first
+		}
+	default:
+		if hasMore {
+			page.Next = last
+		}
+	}
+	return page, nil
+}
+
+// hasFavoritesBeyond reports whether rows of from matching where exist after
+// the cursor in the order of keys, or before it when backward is set.
+func (r *FavoriteRepository) hasFavoritesBeyond(
+	ctx context.Context,
+	from string,
+	where string,
+	args []interface{},
+	keys []sortKey,
+	cursor *Cursor,
+	backward bool,
+) (bool, error) {
+	var exists bool
+	condition, conditionArgs := keysetCondition(keys, cursor, backward)
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM ` + from + `
+			WHERE deleted_at IS NULL
+			  AND (` + where + `)
+			  AND ` + condition + `
+		)
 	`
-	args = append(args, ownerType, ownerID)
-	if cursorID != uuid.Nil {
-		query += `
-			AND created_at < (SELECT created_at FROM favorites WHERE id = $3)
-			ORDER BY created_at DESC
-			LIMIT $4
-		`
-		args = append(args, cursorID)
-	} else {
-		query += `
-			ORDER BY created_at DESC
-			LIMIT $3
-		`
+	queryArgs := append(slices.Clone(args), conditionArgs...)
+	err := r.db.GetContext(ctx, &exists, r.db.Rebind(query), queryArgs...)
+	return exists, err
+}
+
+func (r *FavoriteRepository) GetFavorite(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (favorite.Favorite, error) {
+	var f favorite.Favorite
+	query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+	          FROM favorites
+	          WHERE id = $1
+	            AND project_id = $2
+	            AND deleted_at IS NULL;`
+	err := r.db.GetContext(ctx, &f, query, id, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, ErrNotFound
 	}
-	args = append(args, limit)
-	err := r.db.Select(&favorites, query, args...)
 	if err != nil {
-		return nil, uuid.Nil, err
+		return f, err
+	}
+	return f, loadTagsOf(ctx, r.db, &f)
+}
+
+func (r *FavoriteRepository) GetFavoritesByIDs(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ids []uuid.UUID,
+) (map[uuid.UUID]favorite.Favorite, error) {
+	var favorites []favorite.Favorite
+	query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+	          FROM favorites
+	          WHERE project_id = $1
+	            AND id = ANY($2::uuid[])
+	            AND deleted_at IS NULL;`
+	if err := r.db.SelectContext(ctx, &favorites, query, projectID, uuidArray(ids)); err != nil {
+		return nil, err
 	}
-	var nextCursor uuid.UUID
-	if len(favorites) > 0 {
-		nextCursor = favorites[len(favorites)-1].ID
+	if err := loadTags(ctx, r.db, favorites); err != nil {
+		return nil, err
 	}
-	return favorites, nextCursor, err
+	byID := make(map[uuid.UUID]favorite.Favorite, len(favorites))
+	for _, f := range favorites {
+		byID[f.ID] = f
+	}
+	return byID, nil
+}
+
+func (r *FavoriteRepository) CreateFavorite(ctx context.Context, f *favorite.Favorite, actor audit.Actor) (bool, error) {
+	created := false
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		created, err = createFavorite(ctx, tx, f, actor)
+		return err
+	})
+	return created, err
 }
 
-func (r *FavoriteRepository) CreateFavorite(f *favorite.Favorite) error {
-	query := `INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type)
-	          VALUES ($1, $2, $3, $4, $5)
-	          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, created_at;`
-	err := r.db.QueryRowx(
+// createFavorite does the work of CreateFavorite within tx.
+func createFavorite(ctx context.Context, tx *sqlx.Tx, f *favorite.Favorite, actor audit.Actor) (bool, error) {
+	tags := sortedTags(f.Tags)
+	if err := lockOwner(ctx, tx, f.ProjectID, f.OwnerType, f.OwnerID); err != nil {
+		return false, err
+	}
+	// New favorites go to the top of the owner's unpinned favorites.
+	top, err := sectionEdge(ctx, tx, *f, false, uuid.Nil)
+	if err != nil {
+		return false, err
+	}
+	if f.Position, err = positionBetween("", top); err != nil {
+		return false, err
+	}
+	query := `INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, position, note)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	          ON CONFLICT (project_id, owner_type, owner_id, object_id, object_type) WHERE deleted_at IS NULL DO NOTHING
+	          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+	err = tx.QueryRowxContext(
+		ctx,
 		query,
 		f.ProjectID,
 		f.OwnerType,
 		f.OwnerID,
 		f.ObjectID,
 		f.ObjectType,
+		f.Position,
+		f.Note,
 	).StructScan(f)
+	if errors.Is(err, sql.ErrNoRows) {
+		query = `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+		         FROM favorites
+		         WHERE project_id = $1
+		           AND owner_type = $2
+		           AND owner_id = $3
+		           AND object_id = $4
+		           AND object_type = $5
+		           AND deleted_at IS NULL;`
+		err = tx.GetContext(ctx, f, query, f.ProjectID, f.OwnerType, f.OwnerID, f.ObjectID, f.ObjectType)
+		if err != nil {
+			return false, err
+		}
+		return false, loadTagsOf(ctx, tx, f)
+	}
+	if err != nil {
+		return false, err
+	}
+	if err = saveTags(ctx, tx, f.ID, tags); err != nil {
+		return false, err
+	}
+	f.Tags = tags
+	if err = adjustFavoriteCount(ctx, tx, *f, 1); err != nil {
+		return false, err
+	}
+	e := audit.NewEvent(audit.ActionCreate, actor, nil, f)
+	if err = recordEvent(ctx, tx, &e); err != nil {
+		return false, err
+	}
+	return true, enqueueMessage(ctx, tx, outbox.NewMessage(outbox.TypeFavoriteCreated, *f))
+}
+
+// favoriteKey identifies the favorite of an object by an owner, of which
+// there is at most one that is not soft-deleted.
+type favoriteKey struct {
+	projectID  uuid.UUID
+	ownerType  favorite.OwnerType
+	ownerID    uuid.UUID
+	objectType favorite.ObjectType
+	objectID   uuid.UUID
+}
+
+func favoriteKeyOf(f favorite.Favorite) favoriteKey {
+	return favoriteKey{f.ProjectID, f.OwnerType, f.OwnerID, f.ObjectType, f.ObjectID}
+}
+
+func (r *FavoriteRepository) CreateFavorites(ctx context.Context, fs []favorite.Favorite, actor audit.Actor) ([]bool, error) {
+	created := make([]bool, len(fs))
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := lockOwners(ctx, tx, fs); err != nil {
+			return err
+		}
+		positions, err := topPositions(fs, func(f favorite.Favorite) (string, error) {
+			return sectionEdge(ctx, tx, f, false, uuid.Nil)
+		})
+		if err != nil {
+			return err
+		}
+		var ids, projectIDs, ownerIDs, objectIDs []uuid.UUID
+		var ownerTypes, objectTypes, notes pq.StringArray
+		for i := range fs {
+			// Ids are assigned up front to tell the inserted rows apart.
+			fs[i].ID = uuid.New()
+			fs[i].Position = positions[i]
+			fs[i].Tags = sortedTags(fs[i].Tags)
+			ids = append(ids, fs[i].ID)
+			projectIDs = append(projectIDs, fs[i].ProjectID)
+			ownerTypes = append(ownerTypes, string(fs[i].OwnerType))
+			ownerIDs = append(ownerIDs, fs[i].OwnerID)
+			objectIDs = append(objectIDs, fs[i].ObjectID)
+			objectTypes = append(objectTypes, string(fs[i].ObjectType))
+			notes = append(notes, fs[i].Note)
+		}
+		// Of favorites repeating each other the first one is inserted.
+		var inserted []favorite.Favorite
+		query := `INSERT INTO favorites (id, project_id, owner_type, owner_id, object_id, object_type, position, note)
+		          SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, note
+		          FROM UNNEST($1::uuid[], $2::uuid[], $3::TEXT[], $4::uuid[], $5::uuid[], $6::TEXT[], $7::TEXT[], $8::TEXT[])
+		               WITH ORDINALITY AS f (id, project_id, owner_type, owner_id, object_id, object_type, position, note, n)
+		          ORDER BY n
+		          ON CONFLICT (project_id, owner_type, owner_id, object_id, object_type) WHERE deleted_at IS NULL DO NOTHING
+		          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+		err = tx.SelectContext(
+			ctx,
+			&inserted,
+			query,
+			uuidArray(ids),
+			uuidArray(projectIDs),
+			ownerTypes,
+			uuidArray(ownerIDs),
+			uuidArray(objectIDs),
+			objectTypes,
+			pq.StringArray(positions),
+			notes,
+		)
+		if err != nil {
+			return err
+		}
+		insertedIDs := make(map[uuid.UUID]favorite.Favorite, len(inserted))
+		for _, f := range inserted {
+			insertedIDs[f.ID] = f
+		}
+		var news []favorite.Favorite
+		for i := range fs {
+			if f, ok := insertedIDs[fs[i].ID]; ok {
+				f.Tags = fs[i].Tags
+				fs[i] = f
+				created[i] = true
+				news = append(news, f)
+			}
+		}
+		if err = saveTagsOf(ctx, tx, news); err != nil {
+			return err
+		}
+		if len(news) < len(fs) {
+			var existing []favorite.Favorite
+			query = `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+			         FROM favorites
+			         WHERE (project_id, owner_type, owner_id, object_id, object_type) IN (
+			             SELECT * FROM UNNEST($1::uuid[], $2::TEXT[], $3::uuid[], $4::uuid[], $5::TEXT[])
+			         )
+			           AND deleted_at IS NULL;`
+			err = tx.SelectContext(
+				ctx,
+				&existing,
+				query,
+				uuidArray(projectIDs),
+				ownerTypes,
+				uuidArray(ownerIDs),
+				uuidArray(objectIDs),
+				objectTypes,
+			)
+			if err != nil {
+				return err
+			}
+			if err = loadTags(ctx, tx, existing); err != nil {
+				return err
+			}
+			byKey := make(map[favoriteKey]favorite.Favorite, len(existing))
+			for _, f := range existing {
+				byKey[favoriteKeyOf(f)] = f
+			}
+			for i := range fs {
+				if !created[i] {
+					fs[i] = byKey[favoriteKeyOf(fs[i])]
+				}
+			}
+		}
+		if err = adjustFavoriteCounts(ctx, tx, news, 1); err != nil {
+			return err
+		}
+		events := make([]*audit.Event, len(news))
+		messages := make([]outbox.Message, len(news))
+		for i := range news {
+			e := audit.NewEvent(audit.ActionCreate, actor, nil, &news[i])
+			events[i] = &e
+			messages[i] = outbox.NewMessage(outbox.TypeFavoriteCreated, news[i])
+		}
+		if err = recordEvents(ctx, tx, events); err != nil {
+			return err
+		}
+		return enqueueMessages(ctx, tx, messages)
+	})
+	return created, err
+}
+
+func (r *FavoriteRepository) MoveFavorite(
+	ctx context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	move Move,
+) (favorite.Favorite, error) {
+	var f favorite.Favorite
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		if f, err = getFavorite(ctx, tx, projectID, id); err != nil {
+			return err
+		}
+		// Moves of one owner are serialized, so two of them cannot pick the
+		// same position. The favorite is read again under the lock.
+		if err = lockOwner(ctx, tx, f.ProjectID, f.OwnerType, f.OwnerID); err != nil {
+			return err
+		}
+		if f, err = getFavorite(ctx, tx, projectID, id); err != nil {
+			return err
+		}
+		var after, before *favorite.Favorite
+		for _, anchor := range []struct {
+			id     *uuid.UUID
+			target **favorite.Favorite
+		}{{move.After, &after}, {move.Before, &before}} {
+			if anchor.id == nil {
+				continue
+			}
+			a, err := getFavorite(ctx, tx, projectID, *anchor.id)
+			if err != nil {
+				return err
+			}
+			*anchor.target = &a
+		}
+		pinned, err := movedPinned(f, after, before, move)
+		if err != nil {
+			return err
+		}
+		var lower, upper string
+		switch {
+		case after != nil && before != nil:
+			lower, upper = after.Position, before.Position
+		case after != nil:
+			lower = after.Position
+			upper, err = neighbourPosition(ctx, tx, f, *after, false)
+		case before != nil:
+			upper = before.Position
+			lower, err = neighbourPosition(ctx, tx, f, *before, true)
+		default:
+			upper, err = sectionEdge(ctx, tx, f, pinned, f.ID)
+		}
+		if err != nil {
+			return err
+		}
+		position, err := positionBetween(lower, upper)
+		if err != nil {
+			return err
+		}
+		query := `UPDATE favorites
+		          SET position = $1,
+		              pinned   = $2
+		          WHERE id = $3
+		          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+		if err = tx.GetContext(ctx, &f, query, position, pinned, f.ID); err != nil {
+			return err
+		}
+		if err = loadTagsOf(ctx, tx, &f); err != nil {
+			return err
+		}
+		return enqueueMessage(ctx, tx, outbox.NewMessage(outbox.TypeFavoriteMoved, f))
+	})
+	return f, err
+}
+
+func (r *FavoriteRepository) UpdateFavorite(
+	ctx context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	update FavoriteUpdate,
+) (favorite.Favorite, error) {
+	var f favorite.Favorite
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		query := `UPDATE favorites
+		          SET note = COALESCE($1, note)
+		          WHERE id = $2
+		            AND project_id = $3
+		            AND deleted_at IS NULL
+		          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+		err := tx.GetContext(ctx, &f, query, update.Note, id, projectID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if update.Tags != nil {
+			_, err = tx.ExecContext(ctx, `DELETE FROM favorite_tags WHERE favorite_id = $1;`, f.ID)
+			if err != nil {
+				return err
+			}
+			if err = saveTags(ctx, tx, f.ID, sortedTags(*update.Tags)); err != nil {
+				return err
+			}
+		}
+		if err = loadTagsOf(ctx, tx, &f); err != nil {
+			return err
+		}
+		return enqueueMessage(ctx, tx, outbox.NewMessage(outbox.TypeFavoriteUpdated, f))
+	})
+	return f, err
+}
+
+func (r *FavoriteRepository) GetTagCounts(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) (map[string]int64, error) {
+	var rows []struct {
+		Tag   string `db:"tag"`
+		Count int64  `db:"count"`
+	}
+	query := `
+		SELECT t.tag, COUNT(*) AS count
+		FROM favorite_tags t
+		         JOIN favorites f ON f.id = t.favorite_id
+		WHERE f.project_id = $1
+		  AND f.owner_type = $2
+		  AND f.owner_id = $3
+		  AND f.deleted_at IS NULL
+		GROUP BY t.tag
+	`
+	err := r.db.SelectContext(ctx, &rows, query, projectID, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Tag] = row.Count
+	}
+	return counts, nil
+}
+
+// saveTags adds tags to the favorite with the given id.
+func saveTags(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	query := `INSERT INTO favorite_tags (favorite_id, tag)
+	          SELECT $1, UNNEST($2::TEXT[])
+	          ON CONFLICT DO NOTHING;`
+	_, err := tx.ExecContext(ctx, query, id, pq.StringArray(tags))
+	return err
+}
+
+// saveTagsOf adds the tags of favorites with a single insert.
+func saveTagsOf(ctx context.Context, tx *sqlx.Tx, favorites []favorite.Favorite) error {
+	var ids []uuid.UUID
+	var tags pq.StringArray
+	for _, f := range favorites {
+		for _, tag := range f.Tags {
+			ids = append(ids, f.ID)
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	query := `INSERT INTO favorite_tags (favorite_id, tag)
+	          SELECT * FROM UNNEST($1::uuid[], $2::TEXT[])
+	          ON CONFLICT DO NOTHING;`
+	_, err := tx.ExecContext(ctx, query, uuidArray(ids), tags)
+	return err
+}
+
+// loadTags fills in the tags of favorites with a single query.
+func loadTags(ctx context.Context, q sqlx.QueryerContext, favorites []favorite.Favorite) error {
+	if len(favorites) == 0 {
+		return nil
+	}
+	ids := idsOf(favorites)
+	var rows []struct {
+		FavoriteID uuid.UUID `db:"favorite_id"`
+		Tag        string    `db:"tag"`
+	}
+	query := `SELECT favorite_id, tag
+	          FROM favorite_tags
+	          WHERE favorite_id = ANY($1::uuid[])
+	          ORDER BY tag;`
+	err := sqlx.SelectContext(ctx, q, &rows, query, uuidArray(ids))
+	if err != nil {
+		return err
+	}
+	tags := make(map[uuid.UUID][]string)
+	for _, row := range rows {
+		tags[row.FavoriteID] = append(tags[row.FavoriteID], row.Tag)
+	}
+	for i := range favorites {
+		favorites[i].Tags = sortedTags(tags[favorites[i].ID])
+	}
+	return nil
+}
+
+func loadTagsOf(ctx context.Context, q sqlx.QueryerContext, f *favorite.Favorite) error {
+	favorites := []favorite.Favorite{*f}
+	err := loadTags(ctx, q, favorites)
+	f.Tags = favorites[0].Tags
 	return err
 }
 
-func (r *FavoriteRepository) DeleteFavorite(id uuid.UUID) error {
-	query := `DELETE FROM favorites WHERE id = $1;`
-	_, err := r.db.Exec(query, id)
+func getFavorite(ctx context.Context, tx *sqlx.Tx, projectID uuid.UUID, id uuid.UUID) (favorite.Favorite, error) {
+	var f favorite.Favorite
+	query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+	          FROM favorites
+	          WHERE id = $1
+	            AND project_id = $2
+	            AND deleted_at IS NULL;`
+	err := tx.GetContext(ctx, &f, query, id, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, ErrNotFound
+	}
+	return f, err
+}
+
+// lockOwner takes a transaction scoped lock on the manual order of the owner.
+func lockOwner(ctx context.Context, tx *sqlx.Tx, projectID uuid.UUID, ownerType favorite.OwnerType, ownerID uuid.UUID) error {
+	key := projectID.String() + "/" + string(ownerType) + "/" + ownerID.String()
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0));`, key)
 	return err
 }
+
+// lockOwners takes the locks of lockOwner on the owners of the favorites in a
+// fixed order, so that transactions sharing owners cannot deadlock.
+func lockOwners(ctx context.Context, tx *sqlx.Tx, fs []favorite.Favorite) error {
+	owners := slices.Clone(fs)
+	slices.SortFunc(owners, func(a, b favorite.Favorite) int {
+		return cmp.Or(
+			cmp.Compare(a.ProjectID.String(), b.ProjectID.String()),
+			cmp.Compare(a.OwnerType, b.OwnerType),
+			cmp.Compare(a.OwnerID.String(), b.OwnerID.String()),
+		)
+	})
+	for _, f := range slices.CompactFunc(owners, sameOwner) {
+		if err := lockOwner(ctx, tx, f.ProjectID, f.OwnerType, f.OwnerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sectionEdge returns the first position among the owner's pinned or unpinned
+// favorites other than the one with id exclude, or "" if there is none.
+// Soft-deleted favorites count too, so a restored favorite never shares its
+// position with another one.
+func sectionEdge(ctx context.Context, tx *sqlx.Tx, f favorite.Favorite, pinned bool, exclude uuid.UUID) (string, error) {
+	var position sql.NullString
+	query := `SELECT MIN(position)
+	          FROM favorites
+	          WHERE project_id = $1
+	            AND owner_type = $2
+	            AND owner_id = $3
+	            AND pinned = $4
+	            AND id <> $5;`
+	err := tx.GetContext(ctx, &position, query, f.ProjectID, f.OwnerType, f.OwnerID, pinned, exclude)
+	return position.String, err
+}
+
+// neighbourPosition returns the position of the favorite directly following
+// anchor in its section, or directly preceding it when backward is set,
+// skipping the moved favorite f. It returns "" at the end of the section.
+// Like sectionEdge it takes soft-deleted favorites into account.
+func neighbourPosition(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	f favorite.Favorite,
+	anchor favorite.Favorite,
+	backward bool,
+) (string, error) {
+	keys := []sortKey{positionKey(false), idKey(false)}
+	condition, args := keysetCondition(keys, CursorOf(anchor), backward)
+	query := `
+		SELECT position
+		FROM favorites
+		WHERE project_id = ?
+		  AND owner_type = ?
+		  AND owner_id = ?
+		  AND pinned = ?
+		  AND id <> ?
+		  AND ` + condition + `
+		` + orderByClause(keys, backward) + `
+		LIMIT 1`
+	args = append([]interface{}{f.ProjectID, f.OwnerType, f.OwnerID, anchor.Pinned, f.ID}, args...)
+	var position string
+	err := tx.GetContext(ctx, &position, tx.Rebind(query), args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return position, err
+}
+
+func (r *FavoriteRepository) DeleteFavorite(
+	ctx context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	actor audit.Actor,
+) error {
+	return withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		return deleteFavorite(ctx, tx, projectID, id, actor)
+	})
+}
+
+// deleteFavorite does the work of DeleteFavorite within tx.
+func deleteFavorite(ctx context.Context, tx *sqlx.Tx, projectID uuid.UUID, id uuid.UUID, actor audit.Actor) error {
+	var deleted favorite.Favorite
+	query := `UPDATE favorites
+	          SET deleted_at = NOW()
+	          WHERE id = $1
+	            AND project_id = $2
+	            AND deleted_at IS NULL
+	          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+	err := tx.QueryRowxContext(ctx, query, id, projectID).StructScan(&deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err = adjustFavoriteCount(ctx, tx, deleted, -1); err != nil {
+		return err
+	}
+	if err = loadTagsOf(ctx, tx, &deleted); err != nil {
+		return err
+	}
+	before := deleted
+	before.DeletedAt = nil
+	e := audit.NewEvent(audit.ActionDelete, actor, &before, &deleted)
+	if err = recordEvent(ctx, tx, &e); err != nil {
+		return err
+	}
+	return enqueueMessage(ctx, tx, outbox.NewMessage(outbox.TypeFavoriteDeleted, deleted))
+}
+
+func (r *FavoriteRepository) DeleteFavorites(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ids []uuid.UUID,
+	actor audit.Actor,
+) ([]favorite.Favorite, error) {
+	var deleted []favorite.Favorite
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		query := `UPDATE favorites
+		          SET deleted_at = NOW()
+		          WHERE project_id = $1
+		            AND id = ANY($2::uuid[])
+		            AND deleted_at IS NULL
+		          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+		if err := tx.SelectContext(ctx, &deleted, query, projectID, uuidArray(ids)); err != nil {
+			return err
+		}
+		// Changes are recorded in the order of ids, not of the returned rows.
+		order := make(map[uuid.UUID]int, len(ids))
+		for i := len(ids) - 1; i >= 0; i-- {
+			order[ids[i]] = i
+		}
+		slices.SortFunc(deleted, func(a, b favorite.Favorite) int {
+			return cmp.Compare(order[a.ID], order[b.ID])
+		})
+		return recordDeletions(ctx, tx, deleted, false, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+func (r *FavoriteRepository) PushFavoriteChange(
+	ctx context.Context,
+	change PushedChange,
+	actor audit.Actor,
+) (PushResult, error) {
+	result := PushResult{ChangedAt: change.ChangedAt}
+	f := change.Favorite
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := lockOwner(ctx, tx, f.ProjectID, f.OwnerType, f.OwnerID); err != nil {
+			return err
+		}
+		last, err := lastFavoriteEvent(ctx, tx, f)
+		if err != nil {
+			return err
+		}
+		current, err := currentFavorite(ctx, tx, f)
+		if err != nil {
+			return err
+		}
+		result.Seq = last.Seq
+		if change.lastChangedAt(last.Seq, last.CreatedAt).After(change.ChangedAt) {
+			result.Outcome, result.Favorite = PushConflict, current
+			return nil
+		}
+		result.Outcome = PushUnchanged
+		if !change.Deleted {
+			created, err := createFavorite(ctx, tx, &f, actor)
+			if err != nil {
+				return err
+			}
+			if created {
+				result.Outcome = PushApplied
+			}
+			result.Favorite = &f
+		} else if current != nil {
+			if err = deleteFavorite(ctx, tx, f.ProjectID, current.ID, actor); err != nil {
+				return err
+			}
+			result.Outcome = PushApplied
+		}
+		if result.Outcome == PushApplied {
+			last, err = lastFavoriteEvent(ctx, tx, f)
+			result.Seq = last.Seq
+		}
+		return err
+	})
+	return result, err
+}
+
+// lastFavoriteEvent returns the seq and creation time of the last event of
+// the owner's favorite of the object within tx, or zeros if there is none.
+func lastFavoriteEvent(ctx context.Context, tx *sqlx.Tx, f favorite.Favorite) (audit.Event, error) {
+	var last audit.Event
+	query := `SELECT seq, created_at
+	          FROM favorite_events
+	          WHERE project_id = $1
+	            AND owner_type = $2
+	            AND owner_id = $3
+	            AND object_type = $4
+	            AND object_id = $5
+	          ORDER BY seq DESC
+	          LIMIT 1;`
+	err := tx.GetContext(ctx, &last, query, f.ProjectID, f.OwnerType, f.OwnerID, f.ObjectType, f.ObjectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return last, nil
+	}
+	return last, err
+}
+
+// currentFavorite returns the owner's favorite of the object within tx, or
+// nil if the owner has not favorited it.
+func currentFavorite(ctx context.Context, tx *sqlx.Tx, f favorite.Favorite) (*favorite.Favorite, error) {
+	var current favorite.Favorite
+	query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+	          FROM favorites
+	          WHERE project_id = $1
+	            AND owner_type = $2
+	            AND owner_id = $3
+	            AND object_id = $4
+	            AND object_type = $5
+	            AND deleted_at IS NULL;`
+	err := tx.GetContext(ctx, &current, query, f.ProjectID, f.OwnerType, f.OwnerID, f.ObjectID, f.ObjectType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &current, loadTagsOf(ctx, tx, &current)
+}
+
+// recordDeletions does the bookkeeping of the favorites soft-deleted within
+// tx: it adjusts the counters, loads the tags of the tombstones and records
+// the deletions in the audit log and the outbox, as hidden if they were
+// hidden by the deletion of their object.
+func recordDeletions(ctx context.Context, tx *sqlx.Tx, deleted []favorite.Favorite, hidden bool, actor audit.Actor) error {
+	if err := adjustFavoriteCounts(ctx, tx, deleted, -1); err != nil {
+		return err
+	}
+	if err := loadTags(ctx, tx, deleted); err != nil {
+		return err
+	}
+	action, messageType := audit.ActionDelete, outbox.TypeFavoriteDeleted
+	if hidden {
+		action, messageType = audit.ActionHide, outbox.TypeFavoriteHidden
+	}
+	events := make([]*audit.Event, len(deleted))
+	messages := make([]outbox.Message, len(deleted))
+	for i := range deleted {
+		before := deleted[i]
+		before.DeletedAt = nil
+		e := audit.NewEvent(action, actor, &before, &deleted[i])
+		events[i] = &e
+		messages[i] = outbox.NewMessage(messageType, deleted[i])
+	}
+	if err := recordEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	return enqueueMessages(ctx, tx, messages)
+}
+
+func (r *FavoriteRepository) EraseFavorites(
+	ctx context.Context,
+	projectID uuid.UUID,
+	erasure Erasure,
+	chunkSize int,
+	actor audit.Actor,
+) (int64, error) {
+	hidden := erasure.Hide && erasure.OwnerType == ""
+	where := `project_id = $1 AND object_type = $2 AND object_id = $3`
+	args := []interface{}{projectID, erasure.ObjectType, erasure.ObjectID, chunkSize, hidden}
+	if erasure.OwnerType != "" {
+		where = `project_id = $1 AND owner_type = $2 AND owner_id = $3`
+		args = []interface{}{projectID, erasure.OwnerType, erasure.OwnerID, chunkSize, hidden}
+	}
+	if erasure.OwnerType != "" {
+		// The owner's favorites hidden with their objects must not come back
+		// with them. They are released first, so that one restored meanwhile
+		// is erased below.
+		release := `UPDATE favorites
+		            SET hidden_by_object = FALSE
+		            WHERE project_id = $1
+		              AND owner_type = $2
+		              AND owner_id = $3
+		              AND hidden_by_object;`
+		if _, err := r.db.ExecContext(ctx, release, projectID, erasure.OwnerType, erasure.OwnerID); err != nil {
+			return 0, err
+		}
+	}
+	// Every chunk commits on its own so that erasing a large owner neither
+	// holds locks nor grows a transaction for long. A failed erasure can be
+	// repeated and carries on with the favorites left.
+	query := `UPDATE favorites
+	          SET deleted_at = NOW(),
+	              hidden_by_object = $5
+	          WHERE id IN (
+	              SELECT id
+	              FROM favorites
+	              WHERE ` + where + `
+	                AND deleted_at IS NULL
+	              ORDER BY id
+	              LIMIT $4
+	              FOR UPDATE
+	          )
+	            AND deleted_at IS NULL
+	          RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+	var total int64
+	for {
+		var deleted []favorite.Favorite
+		err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+			if err := tx.SelectContext(ctx, &deleted, query, args...); err != nil {
+				return err
+			}
+			slices.SortFunc(deleted, func(a, b favorite.Favorite) int {
+				return cmp.Compare(a.ID.String(), b.ID.String())
+			})
+			return recordDeletions(ctx, tx, deleted, hidden, actor)
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(len(deleted))
+		if len(deleted) < chunkSize {
+			break
+		}
+	}
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if erasure.OwnerType != "" {
+			if err := redactOwner(ctx, tx, projectID, erasure.OwnerType, erasure.OwnerID); err != nil {
+				return err
+			}
+		}
+		receipt := erasure.receipt(projectID, total, actor)
+		return recordEvent(ctx, tx, &receipt)
+	})
+	return total, err
+}
+
+// redactOwnerQueries remove the notes and tags of the owner's deleted
+// favorites from the favorites themselves and from their snapshots in the
+// audit log, the outbox and the webhook deliveries. Favorites the owner
+// created again since the erasure are left alone.
+var redactOwnerQueries = []string{
+	`UPDATE favorites
+	 SET note = ''
+	 WHERE project_id = $1
+	   AND owner_type = $2
+	   AND owner_id = $3
+	   AND deleted_at IS NOT NULL
+	   AND note <> '';`,
+	`DELETE FROM favorite_tags
+	 WHERE favorite_id IN (
+	     SELECT id
+	     FROM favorites
+	     WHERE project_id = $1
+	       AND owner_type = $2
+	       AND owner_id = $3
+	       AND deleted_at IS NOT NULL
+	 );`,
+	`UPDATE favorite_events
+	 SET before = redact_favorite_snapshot(before),
+	     after  = redact_favorite_snapshot(after)
+	 WHERE project_id = $1
+	   AND owner_type = $2
+	   AND owner_id = $3
+	   AND (before IS NOT NULL OR after IS NOT NULL)
+	   AND favorite_id NOT IN (` + liveOwnerFavorites + `);`,
+	`UPDATE favorite_outbox
+	 SET payload = redact_favorite_snapshot(payload)
+	 WHERE project_id = $1
+	   AND payload ->> 'owner_type' = $2
+	   AND (payload ->> 'owner_id')::UUID = $3
+	   AND favorite_id NOT IN (` + liveOwnerFavorites + `);`,
+	`UPDATE webhook_deliveries
+	 SET payload = jsonb_set(payload, '{favorite}', redact_favorite_snapshot(payload -> 'favorite'))
+	 WHERE project_id = $1
+	   AND payload -> 'favorite' ->> 'owner_type' = $2
+	   AND (payload -> 'favorite' ->> 'owner_id')::UUID = $3
+	   AND (payload ->> 'favorite_id')::UUID NOT IN (` + liveOwnerFavorites + `);`,
+}
+
+const liveOwnerFavorites = `SELECT id
+	     FROM favorites
+	     WHERE project_id = $1
+	       AND owner_type = $2
+	       AND owner_id = $3
+	       AND deleted_at IS NULL`
+
+// redactOwner runs redactOwnerQueries for the owner within tx.
+func redactOwner(ctx context.Context, tx *sqlx.Tx, projectID uuid.UUID, ownerType favorite.OwnerType, ownerID uuid.UUID) error {
+	for _, query := range redactOwnerQueries {
+		if _, err := tx.ExecContext(ctx, query, projectID, ownerType, ownerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *FavoriteRepository) GetDeletedFavorite(
+	ctx context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	deletedAfter time.Time,
+) (favorite.Favorite, error) {
+	var f favorite.Favorite
+	query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+	          FROM favorites
+	          WHERE id = $1
+	            AND project_id = $2
+	            AND deleted_at >= $3;`
+	err := r.db.GetContext(ctx, &f, query, id, projectID, deletedAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, ErrNotFound
+	}
+	if err != nil {
+		return f, err
+	}
+	return f, loadTagsOf(ctx, r.db, &f)
+}
+
+func (r *FavoriteRepository) RestoreFavorite(
+	ctx context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	deletedAfter time.Time,
+	actor audit.Actor,
+) (favorite.Favorite, error) {
+	var f favorite.Favorite
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var tombstone struct {
+			favorite.Favorite
+			HiddenByObject bool `db:"hidden_by_object"`
+		}
+		query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at,
+		                 hidden_by_object
+		          FROM favorites
+		          WHERE id = $1
+		            AND project_id = $2
+		            AND deleted_at >= $3`
+		get := func(suffix string) error {
+			err := tx.GetContext(ctx, &tombstone, query+suffix, id, projectID, deletedAfter)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := get(";"); err != nil {
+			return err
+		}
+		// Restores of one owner are serialized with its other writes, like
+		// moves. The favorite is locked and read again under the lock.
+		if err := lockOwner(ctx, tx, projectID, tombstone.OwnerType, tombstone.OwnerID); err != nil {
+			return err
+		}
+		if err := get(" FOR UPDATE;"); err != nil {
+			return err
+		}
+		if tombstone.HiddenByObject {
+			return ErrHiddenByObject
+		}
+		before := tombstone.Favorite
+		query = `UPDATE favorites
+		         SET deleted_at = NULL
+		         WHERE id = $1
+		         RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+		err := tx.QueryRowxContext(ctx, query, id).StructScan(&f)
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		if err != nil {
+			return err
+		}
+		if err = adjustFavoriteCount(ctx, tx, f, 1); err != nil {
+			return err
+		}
+		if err = loadTagsOf(ctx, tx, &f); err != nil {
+			return err
+		}
+		before.Tags = f.Tags
+		e := audit.NewEvent(audit.ActionRestore, actor, &before, &f)
+		if err = recordEvent(ctx, tx, &e); err != nil {
+			return err
+		}
+		return enqueueMessage(ctx, tx, outbox.NewMessage(outbox.TypeFavoriteRestored, f))
+	})
+	return f, err
+}
+
+func (r *FavoriteRepository) RestoreObjectFavorites(
+	ctx context.Context,
+	projectID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectID uuid.UUID,
+	deletedAfter time.Time,
+	chunkSize int,
+	actor audit.Actor,
+) (int64, error) {
+	// Restored favorites no longer qualify, so every chunk picks up where the
+	// previous one ended, like in EraseFavorites.
+	query := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+	          FROM favorites
+	          WHERE id IN (
+	              SELECT id
+	              FROM (
+	                  SELECT DISTINCT ON (owner_type, owner_id) id
+	                  FROM favorites d
+	                  WHERE project_id = $1
+	                    AND object_type = $2
+	                    AND object_id = $3
+	                    AND deleted_at >= $4
+	                    AND hidden_by_object
+	                    AND ` + notFavoritedAgain + `
+	                  ORDER BY owner_type, owner_id, deleted_at DESC
+	              ) latest
+	              ORDER BY id
+	              LIMIT $5
+	          )
+	          ORDER BY id;`
+	// Under the locks of their owners the favorites are read again, skipping
+	// those favorited again in the meantime.
+	lockedQuery := `SELECT id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at
+	                FROM favorites d
+	                WHERE id = ANY($1::uuid[])
+	                  AND deleted_at IS NOT NULL
+	                  AND hidden_by_object
+	                  AND ` + notFavoritedAgain + `
+	                ORDER BY id
+	                FOR UPDATE;`
+	var total int64
+	for {
+		var candidates, restored []favorite.Favorite
+		err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+			err := tx.SelectContext(ctx, &candidates, query, projectID, objectType, objectID, deletedAfter, chunkSize)
+			if err != nil || len(candidates) == 0 {
+				return err
+			}
+			if err = lockOwners(ctx, tx, candidates); err != nil {
+				return err
+			}
+			var before []favorite.Favorite
+			err = tx.SelectContext(ctx, &before, lockedQuery, uuidArray(idsOf(candidates)))
+			if err != nil || len(before) == 0 {
+				return err
+			}
+			update := `UPDATE favorites
+			           SET deleted_at = NULL,
+			               hidden_by_object = FALSE
+			           WHERE id = ANY($1::uuid[])
+			           RETURNING id, project_id, owner_type, owner_id, object_id, object_type, position, pinned, note, created_at, deleted_at;`
+			if err = tx.SelectContext(ctx, &restored, update, uuidArray(idsOf(before))); err != nil {
+				return err
+			}
+			slices.SortFunc(restored, func(a, b favorite.Favorite) int {
+				return cmp.Compare(a.ID.String(), b.ID.String())
+			})
+			return recordRestorations(ctx, tx, before, restored, actor)
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(len(restored))
+		if len(candidates) < chunkSize {
+			break
+		}
+	}
+	// The hidden favorites left were favorited again by their owners, who
+	// may restore them like any deleted favorite from now on.
+	query = `UPDATE favorites
+	         SET hidden_by_object = FALSE
+	         WHERE project_id = $1
+	           AND object_type = $2
+	           AND object_id = $3
+	           AND hidden_by_object;`
+	_, err := r.db.ExecContext(ctx, query, projectID, objectType, objectID)
+	return total, err
+}
+
+// notFavoritedAgain holds for a deleted favorite d whose owner has no
+// favorite of the same object.
+const notFavoritedAgain = `NOT EXISTS (
+	                        SELECT 1
+	                        FROM favorites l
+	                        WHERE l.project_id = d.project_id
+	                          AND l.owner_type = d.owner_type
+	                          AND l.owner_id = d.owner_id
+	                          AND l.object_type = d.object_type
+	                          AND l.object_id = d.object_id
+	                          AND l.deleted_at IS NULL
+	                    )`
+
+// idsOf returns the ids of the favorites in their order.
+func idsOf(fs []favorite.Favorite) []uuid.UUID {
+	ids := make([]uuid.UUID, len(fs))
+	for i, f := range fs {
+		ids[i] = f.ID
+	}
+	return ids
+}
+
+// recordRestorations does the bookkeeping of the favorites restored within
+// tx like recordDeletions. before holds the tombstones of the restored
+// favorites, in the same order.
+func recordRestorations(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	before []favorite.Favorite,
+	restored []favorite.Favorite,
+	actor audit.Actor,
+) error {
+	if err := adjustFavoriteCounts(ctx, tx, restored, 1); err != nil {
+		return err
+	}
+	if err := loadTags(ctx, tx, restored); err != nil {
+		return err
+	}
+	events := make([]*audit.Event, len(restored))
+	messages := make([]outbox.Message, len(restored))
+	for i := range restored {
+		before[i].Tags = restored[i].Tags
+		e := audit.NewEvent(audit.ActionRestore, actor, &before[i], &restored[i])
+		events[i] = &e
+		messages[i] = outbox.NewMessage(outbox.TypeFavoriteRestored, restored[i])
+	}
+	if err := recordEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	return enqueueMessages(ctx, tx, messages)
+}
+
+func (r *FavoriteRepository) PurgeDeletedFavorites(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM favorites WHERE deleted_at < $1;`, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// adjustFavoriteCount applies delta to the denormalized counter of the
+// favorite's object. Counters that drop to zero are removed.
+func adjustFavoriteCount(ctx context.Context, tx *sqlx.Tx, f favorite.Favorite, delta int64) error {
+	return adjustFavoriteCounts(ctx, tx, []favorite.Favorite{f}, delta)
+}
+
+// adjustFavoriteCounts applies delta once per favorite to the counters of
+// their objects with a single upsert.
+func adjustFavoriteCounts(ctx context.Context, tx *sqlx.Tx, favorites []favorite.Favorite, delta int64) error {
+	deltas := make(map[objectKey]int64)
+	var keys []objectKey
+	for _, f := range favorites {
+		key := objectKeyOf(f)
+		if _, ok := deltas[key]; !ok {
+			keys = append(keys, key)
+		}
+		deltas[key] += delta
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	// One row per object, since an upsert cannot touch the same row twice.
+	var projectIDs, objectIDs []uuid.UUID
+	var objectTypes pq.StringArray
+	var counts pq.Int64Array
+	for _, key := range keys {
+		projectIDs = append(projectIDs, key.projectID)
+		objectTypes = append(objectTypes, string(key.objectType))
+		objectIDs = append(objectIDs, key.objectID)
+		counts = append(counts, deltas[key])
+	}
+	query := `INSERT INTO favorite_counts (project_id, object_type, object_id, count)
+	          SELECT * FROM UNNEST($1::uuid[], $2::TEXT[], $3::uuid[], $4::BIGINT[])
+	          ON CONFLICT (project_id, object_type, object_id)
+	          DO UPDATE SET count = favorite_counts.count + EXCLUDED.count;`
+	_, err := tx.ExecContext(ctx, query, uuidArray(projectIDs), objectTypes, uuidArray(objectIDs), counts)
+	if err != nil || delta > 0 {
+		return err
+	}
+	query = `DELETE FROM favorite_counts
+	         WHERE (project_id, object_type, object_id) IN (
+	             SELECT * FROM UNNEST($1::uuid[], $2::TEXT[], $3::uuid[])
+	         )
+	           AND count <= 0;`
+	_, err = tx.ExecContext(ctx, query, uuidArray(projectIDs), objectTypes, uuidArray(objectIDs))
+	return err
+}
+
+func (r *FavoriteRepository) GetFavoriteCounts(
+	ctx context.Context,
+	projectID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectIDs []uuid.UUID,
+) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		ObjectID uuid.UUID `db:"object_id"`
+		Count    int64     `db:"count"`
+	}
+	query := `
+		SELECT object_id, count
+		FROM favorite_counts
+		WHERE project_id = $1
+		  AND object_type = $2
+		  AND object_id = ANY($3::uuid[])
+	`
+	err := r.db.SelectContext(ctx, &rows, query, projectID, objectType, uuidArray(objectIDs))
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uuid.UUID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ObjectID] = row.Count
+	}
+	return counts, nil
+}
+
+// GetFavoritedObjects walks the counters, which hold a row for every object
+// with favorites.
+func (r *FavoriteRepository) GetFavoritedObjects(ctx context.Context, after object.Ref, limit int) ([]object.Ref, error) {
+	refs := []object.Ref{}
+	query := `SELECT project_id, object_type, object_id
+	          FROM favorite_counts
+	          WHERE (project_id, object_type, object_id) > ($1, $2, $3)
+	          ORDER BY project_id, object_type, object_id
+	          LIMIT $4;`
+	err := r.db.SelectContext(ctx, &refs, query, after.ProjectID, after.ObjectType, after.ObjectID, limit)
+	return refs, err
+}
+
+func (r *FavoriteRepository) RepairFavoriteCounts(ctx context.Context) (int64, error) {
+	var drifted int64
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		// Block writers so the counters cannot change while they are rebuilt.
+		_, err := tx.ExecContext(ctx, `LOCK TABLE favorites IN SHARE MODE;`)
+		if err != nil {
+			return err
+		}
+		query := `
+			SELECT COUNT(*)
+			FROM (SELECT project_id, object_type, object_id, COUNT(*) AS count
+			      FROM favorites
+			      WHERE deleted_at IS NULL
+			      GROUP BY project_id, object_type, object_id) actual
+			         FULL JOIN favorite_counts stored USING (project_id, object_type, object_id)
+			WHERE COALESCE(actual.count, 0) <> COALESCE(stored.count, 0)
+		`
+		err = tx.GetContext(ctx, &drifted, query)
+		if err != nil || drifted == 0 {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM favorite_counts;`)
+		if err != nil {
+			return err
+		}
+		query = `INSERT INTO favorite_counts (project_id, object_type, object_id, count)
+		         SELECT project_id, object_type, object_id, COUNT(*)
+		         FROM favorites
+		         WHERE deleted_at IS NULL
+		         GROUP BY project_id, object_type, object_id;`
+		_, err = tx.ExecContext(ctx, query)
+		return err
+	})
+	return drifted, err
+}
+
+func (r *FavoriteRepository) LookupFavorites(
+	ctx context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectIDs []uuid.UUID,
+) (map[uuid.UUID]uuid.UUID, error) {
+	var rows []struct {
+		ID       uuid.UUID `db:"id"`
+		ObjectID uuid.UUID `db:"object_id"`
+	}
+	query := `
+		SELECT id, object_id
+		FROM favorites
+		WHERE object_type = $1
+		  AND object_id = ANY($2::uuid[])
+		  AND project_id = $3
+		  AND owner_type = $4
+		  AND owner_id = $5
+		  AND deleted_at IS NULL
+	`
+	err := r.db.SelectContext(ctx, &rows, query, objectType, uuidArray(objectIDs), projectID, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	favoriteIDs := make(map[uuid.UUID]uuid.UUID, len(rows))
+	for _, row := range rows {
+		favoriteIDs[row.ObjectID] = row.ID
+	}
+	return favoriteIDs, nil
+}
+
+// uuidArray passes ids as a text array, to be cast with ::uuid[] in SQL.
+func uuidArray(ids []uuid.UUID) pq.StringArray {
+	values := make(pq.StringArray, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/object"
+	"github.com/google/uuid"
+	"time"
+)
+
+// ErrNotFound is returned when the requested row does not exist, including
+// when it belongs to another project.
+var ErrNotFound = errors.New("not found")
+
+// ErrHiddenByObject is returned when a favorite to restore was hidden by the
+// deletion of its object, which restores it together with the object.
+var ErrHiddenByObject = errors.New("hidden by the deletion of its object")
+
+// FavoriteStore is the storage backend used by the HTTP layer. Reads and
+// writes always take the project they act on, so one tenant can never see or
+// change another tenant's favorites. FavoriteRepository implements it on top
+// of PostgreSQL and MemoryFavoriteRepository keeps everything in process memory.
+// Creations, deletions and restores are attributed to an actor and recorded
+// in the audit log atomically with the change, and every change is announced
+// through the outbox (see OutboxStore) in the same way.
+type FavoriteStore interface {
+	GetPageOfFavoritesByOwnerTypeAndOwnerID(
+		ctx context.Context,
+		projectID uuid.UUID,
+		ownerType favorite.OwnerType,
+		ownerID uuid.UUID,
+		filter FavoriteFilter,
+		pageRequest PageRequest,
+	) (Page, error)
+	// GetPageOfFavoritesByObject lists the favorites of every owner that
+	// favorited the object within the project.
+	GetPageOfFavoritesByObject(
+		ctx context.Context,
+		projectID uuid.UUID,
+		objectType favorite.ObjectType,
+		objectID uuid.UUID,
+		pageRequest PageRequest,
+	) (Page, error)
+	// GetFavorite returns the favorite of the project with the given id and
+	// ErrNotFound if there is none.
+	GetFavorite(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (favorite.Favorite, error)
+	// GetFavoritesByIDs returns the favorites of the project with the given
+	// ids keyed by id, leaving out ids with none.
+	GetFavoritesByIDs(ctx context.Context, projectID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]favorite.Favorite, error)
+	// GetPageOfEffectiveFavorites lists the favorites of the user merged with
+	// those of groupIDs, one per object. The user's own favorite stands for
+	// an object favorited several times, otherwise the newest group favorite.
+	GetPageOfEffectiveFavorites(
+		ctx context.Context,
+		projectID uuid.UUID,
+		userID uuid.UUID,
+		groupIDs []uuid.UUID,
+		pageRequest PageRequest,
+	) (Page, error)
+	// CreateFavorite stores f with its note and tags or, if the same owner
+	// already favorited the same object, loads the existing row into f. It
+	// reports whether f is new. Only a new favorite is recorded in the audit log.
+	CreateFavorite(ctx context.Context, f *favorite.Favorite, actor audit.Actor) (bool, error)
+	// CreateFavorites stores or loads each of fs like CreateFavorite, all in
+	// one transaction, and reports for each whether it is new. Later entries
+	// go above earlier ones of the same owner, and an entry repeating an
+	// earlier one loads the favorite stored for it.
+	CreateFavorites(ctx context.Context, fs []favorite.Favorite, actor audit.Actor) ([]bool, error)
+	// MoveFavorite changes the place of the favorite of the project with the
+	// given id in its owner's manual order and returns it. Only the moved
+	// favorite is rewritten. It returns ErrNotFound if the favorite or an
+	// anchor does not exist.
+	MoveFavorite(ctx context.Context, projectID uuid.UUID, id uuid.UUID, move Move) (favorite.Favorite, error)
+	// UpdateFavorite edits the note and tags of the favorite of the project
+	// with the given id and returns it, or ErrNotFound if there is none.
+	UpdateFavorite(ctx context.Context, projectID uuid.UUID, id uuid.UUID, update FavoriteUpdate) (favorite.Favorite, error)
+	// DeleteFavorite soft-deletes the favorite of the project with the given
+	// id and returns ErrNotFound if there is none. Soft-deleted favorites are
+	// left out of every read until they are restored.
+	DeleteFavorite(ctx context.Context, projectID uuid.UUID, id uuid.UUID, actor audit.Actor) error
+	// DeleteFavorites soft-deletes the favorites of the project with the
+	// given ids in one transaction and returns them in the order of ids,
+	// skipping ids with no favorite.
+	DeleteFavorites(ctx context.Context, projectID uuid.UUID, ids []uuid.UUID, actor audit.Actor) ([]favorite.Favorite, error)
+	// PushFavoriteChange applies the change unless the favorite changed after
+	// it was made, deciding and applying it in one transaction so that
+	// concurrent pushes are judged one after another.
+	PushFavoriteChange(ctx context.Context, change PushedChange, actor audit.Actor) (PushResult, error)
+	// EraseFavorites soft-deletes every favorite of the project the erasure
+	// selects, in transactions of at most chunkSize favorites recorded like
+	// DeleteFavorites, then appends an erasure receipt with the total to the
+	// audit log and returns the total. Erasing an owner also removes the notes
+	// and tags of its deleted favorites from them and from their snapshots in
+	// the audit log and the announcing messages, and turns its favorites
+	// hidden with their objects into ordinary deleted favorites, so that they
+	// do not come back with the objects. Favorites hidden by the erasure are
+	// recorded as hidden instead.
+	EraseFavorites(ctx context.Context, projectID uuid.UUID, erasure Erasure, chunkSize int, actor audit.Actor) (int64, error)
+	// GetDeletedFavorite returns the favorite of the project with the given
+	// id if it was soft-deleted no earlier than deletedAfter, and ErrNotFound
+	// otherwise.
+	GetDeletedFavorite(ctx context.Context, projectID uuid.UUID, id uuid.UUID, deletedAfter time.Time) (favorite.Favorite, error)
+	// RestoreFavorite undoes the soft delete of the favorite if it happened no
+	// earlier than deletedAfter and returns the favorite. It returns
+	// ErrNotFound if there is no such favorite, ErrHiddenByObject if it was
+	// hidden with its object and ErrAlreadyExists if the owner has favorited
+	// the object again since.
+	RestoreFavorite(
+		ctx context.Context,
+		projectID uuid.UUID,
+		id uuid.UUID,
+		deletedAfter time.Time,
+		actor audit.Actor,
+	) (favorite.Favorite, error)
+	// RestoreObjectFavorites restores the favorites of the object hidden no
+	// earlier than deletedAfter, in transactions of at most chunkSize
+	// favorites recorded like RestoreFavorite, and returns how many it
+	// restored. A favorite whose owner has favorited the object again is
+	// skipped, and left an ordinary deleted favorite like the other hidden
+	// favorites of the object.
+	RestoreObjectFavorites(
+		ctx context.Context,
+		projectID uuid.UUID,
+		objectType favorite.ObjectType,
+		objectID uuid.UUID,
+		deletedAfter time.Time,
+		chunkSize int,
+		actor audit.Actor,
+	) (int64, error)
+	// PurgeDeletedFavorites permanently removes favorites soft-deleted before
+	// deletedBefore in every project and returns how many it removed.
+	PurgeDeletedFavorites(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// LookupFavorites returns favorite ids keyed by object id for those of
+	// objectIDs the owner has favorited.
+	LookupFavorites(
+		ctx context.Context,
+		projectID uuid.UUID,
+		ownerType favorite.OwnerType,
+		ownerID uuid.UUID,
+		objectType favorite.ObjectType,
+		objectIDs []uuid.UUID,
+	) (map[uuid.UUID]uuid.UUID, error)
+	// GetFavoriteCounts returns how many owners favorited each of objectIDs,
+	// leaving out objects nobody favorited.
+	GetFavoriteCounts(
+		ctx context.Context,
+		projectID uuid.UUID,
+		objectType favorite.ObjectType,
+		objectIDs []uuid.UUID,
+	) (map[uuid.UUID]int64, error)
+	// GetTagCounts returns how many favorites of the owner carry each tag.
+	GetTagCounts(
+		ctx context.Context,
+		projectID uuid.UUID,
+		ownerType favorite.OwnerType,
+		ownerID uuid.UUID,
+	) (map[string]int64, error)
+	// GetFavoritedObjects returns up to limit objects of any project with
+	// favorites, following after in project, type and id order. The zero Ref
+	// starts from the first object.
+	GetFavoritedObjects(ctx context.Context, after object.Ref, limit int) ([]object.Ref, error)
+	// RepairFavoriteCounts recomputes the per-object counters from the
+	// favorites themselves and returns how many counters had drifted.
+	RepairFavoriteCounts(ctx context.Context) (int64, error)
+}
+
+var (
+	_ FavoriteStore = (*FavoriteRepository)(nil)
+	_ FavoriteStore = (*MemoryFavoriteRepository)(nil)
+)
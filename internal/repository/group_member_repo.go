@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type GroupMemberRepository struct {
+	db *sqlx.DB
+}
+
+func NewGroupMemberRepository(db *sqlx.DB) *GroupMemberRepository {
+	return &GroupMemberRepository{db: db}
+}
+
+func (r *GroupMemberRepository) GetGroupsOfUser(
+	ctx context.Context,
+	projectID uuid.UUID,
+	userID uuid.UUID,
+) ([]uuid.UUID, error) {
+	var groupIDs []uuid.UUID
+	query := `SELECT group_id
+	          FROM group_members
+	          WHERE project_id = $1
+	            AND user_id = $2
+	          ORDER BY group_id;`
+	err := r.db.SelectContext(ctx, &groupIDs, query, projectID, userID)
+	return groupIDs, err
+}
+
+func (r *GroupMemberRepository) AddGroupMember(
+	ctx context.Context,
+	projectID uuid.UUID,
+	groupID uuid.UUID,
+	userID uuid.UUID,
+) (bool, error) {
+	query := `INSERT INTO group_members (project_id, group_id, user_id)
+	          VALUES ($1, $2, $3)
+	          ON CONFLICT (project_id, group_id, user_id) DO NOTHING;`
+	result, err := r.db.ExecContext(ctx, query, projectID, groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	added, err := result.RowsAffected()
+	return added > 0, err
+}
+
+func (r *GroupMemberRepository) RemoveGroupMember(
+	ctx context.Context,
+	projectID uuid.UUID,
+	groupID uuid.UUID,
+	userID uuid.UUID,
+) error {
+	query := `DELETE FROM group_members
+	          WHERE project_id = $1
+	            AND group_id = $2
+	            AND user_id = $3;`
+	result, err := r.db.ExecContext(ctx, query, projectID, groupID, userID)
+	if err != nil {
+		return err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
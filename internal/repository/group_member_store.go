@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// MembershipSource tells which groups a user belongs to. The local
+// group_members table is one source; a directory service could be another.
+type MembershipSource interface {
+	GetGroupsOfUser(ctx context.Context, projectID uuid.UUID, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// GroupMemberStore is the membership source maintained through the API.
+type GroupMemberStore interface {
+	MembershipSource
+	// AddGroupMember adds the user to the group and reports whether the user
+	// was not a member yet.
+	AddGroupMember(ctx context.Context, projectID uuid.UUID, groupID uuid.UUID, userID uuid.UUID) (bool, error)
+	// RemoveGroupMember removes the user from the group and returns
+	// ErrNotFound if the user was not a member.
+	RemoveGroupMember(ctx context.Context, projectID uuid.UUID, groupID uuid.UUID, userID uuid.UUID) error
+}
+
+var (
+	_ GroupMemberStore = (*GroupMemberRepository)(nil)
+	_ GroupMemberStore = (*MemoryGroupMemberRepository)(nil)
+)
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+func (r *IdempotencyRepository) GetIdempotentResponse(
+	ctx context.Context,
+	key, scope string,
+	notBefore time.Time,
+) (*IdempotentResponse, error) {
+	var response IdempotentResponse
+	query := `
+		SELECT key, scope, request_hash, status_code, response_body, created_at
+		FROM idempotency_keys
+		WHERE key = $1
+		  AND scope = $2
+		  AND created_at >= $3
+	`
+	err := r.db.GetContext(ctx, &response, query, key, scope, notBefore)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (r *IdempotencyRepository) SaveIdempotentResponse(
+	ctx context.Context,
+	response IdempotentResponse,
+	notBefore time.Time,
+) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1;`, notBefore)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO idempotency_keys (key, scope, request_hash, status_code, response_body)
+	          VALUES ($1, $2, $3, $4, $5)
+	          ON CONFLICT (key, scope) DO NOTHING;`
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		response.Key,
+		response.Scope,
+		response.RequestHash,
+		response.StatusCode,
+		response.ResponseBody,
+	)
+	return err
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotentResponse is a stored response replayed for requests repeating
+// the same Idempotency-Key within the idempotency window.
+type IdempotentResponse struct {
+	Key          string    `db:"key"`
+	Scope        string    `db:"scope"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   int       `db:"status_code"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+type IdempotencyStore interface {
+	// GetIdempotentResponse returns the response stored for key and scope no
+	// earlier than notBefore, or nil when there is none.
+	GetIdempotentResponse(ctx context.Context, key, scope string, notBefore time.Time) (*IdempotentResponse, error)
+	// SaveIdempotentResponse stores response, replacing an entry for the same
+	// key and scope that is older than notBefore and dropping expired ones.
+	SaveIdempotentResponse(ctx context.Context, response IdempotentResponse, notBefore time.Time) error
+}
+
+var (
+	_ IdempotencyStore = (*IdempotencyRepository)(nil)
+	_ IdempotencyStore = (*MemoryIdempotencyRepository)(nil)
+)
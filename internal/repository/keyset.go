@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"bytes"
+	"strings"
+)
+
+// sortKey is one column of a keyset sort order.
+type sortKey struct {
+	column  string
+	desc    bool
+	value   func(c *Cursor) interface{}
+	compare func(a, b *Cursor) int
+}
+
+func createdAtKey(desc bool) sortKey {
+	return sortKey{
+		column:  "created_at",
+		desc:    desc,
+		value:   func(c *Cursor) interface{} { return c.CreatedAt },
+		compare: func(a, b *Cursor) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	}
+}
+
+func idKey(desc bool) sortKey {
+	return sortKey{
+		column:  "id",
+		desc:    desc,
+		value:   func(c *Cursor) interface{} { return c.ID },
+		compare: func(a, b *Cursor) int { return bytes.Compare(a.ID[:], b.ID[:]) },
+	}
+}
+
+func objectTypeKey(desc bool) sortKey {
+	return sortKey{
+		column:  "object_type",
+		desc:    desc,
+		value:   func(c *Cursor) interface{} { return c.ObjectType },
+		compare: func(a, b *Cursor) int { return strings.Compare(string(a.ObjectType), string(b.ObjectType)) },
+	}
+}
+
+func pinnedKey(desc bool) sortKey {
+	return sortKey{
+		column: "pinned",
+		desc:   desc,
+		value:  func(c *Cursor) interface{} { return c.Pinned },
+		compare: func(a, b *Cursor) int {
+			switch {
+			case a.Pinned == b.Pinned:
+				return 0
+			case b.Pinned:
+				return -1
+			default:
+				return 1
+			}
+		},
+	}
+}
+
+func positionKey(desc bool) sortKey {
+	return sortKey{
+		column:  "position",
+		desc:    desc,
+		value:   func(c *Cursor) interface{} { return c.Position },
+		compare: func(a, b *Cursor) int { return strings.Compare(a.Position, b.Position) },
+	}
+}
+
+// keys returns the columns rows are ordered by, always ending with id so the
+// order is total.
+func (s Sort) keys() []sortKey {
+	switch s {
+	case SortOldest:
+		return []sortKey{createdAtKey(false), idKey(false)}
+	case SortObjectType:
+		return []sortKey{objectTypeKey(false), createdAtKey(true), idKey(true)}
+	case SortManual:
+		return []sortKey{pinnedKey(true), positionKey(false), idKey(false)}
+	default:
+		return []sortKey{createdAtKey(true), idKey(true)}
+	}
+}
+
+// compareInOrder returns a negative number when a comes before b in the order
+// of keys, zero when they are equal and a positive number otherwise.
+func compareInOrder(keys []sortKey, a, b *Cursor) int {
+	for _, key := range keys {
+		if c := key.compare(a, b); c != 0 {
+			if key.desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// orderByClause orders rows by keys, or by their reverse when backward is set.
+func orderByClause(keys []sortKey, backward bool) string {
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		direction := "ASC"
+		if key.desc != backward {
+			direction = "DESC"
+		}
+		columns[i] = key.column + " " + direction
+	}
+	return "ORDER BY " + strings.Join(columns, ", ")
+}
+
+// keysetCondition selects rows strictly after cursor in the order of keys, or
+// strictly before it when backward is set. It uses ? placeholders.
+func keysetCondition(keys []sortKey, cursor *Cursor, backward bool) (string, []interface{}) {
+	uniform := true
+	for _, key := range keys[1:] {
+		uniform = uniform && key.desc == keys[0].desc
+	}
+	operator := func(key sortKey) string {
+		if key.desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+	if uniform {
+		// A row comparison lets PostgreSQL walk a matching index directly.
+		columns := make([]string, len(keys))
+		placeholders := make([]string, len(keys))
+		args := make([]interface{}, len(keys))
+		for i, key := range keys {
+			columns[i], placeholders[i], args[i] = key.column, "?", key.value(cursor)
+		}
+		condition := "(" + strings.Join(columns, ", ") + ") " + operator(keys[0]) +
+			" (" + strings.Join(placeholders, ", ") + ")"
+		return condition, args
+	}
+	var alternatives []string
+	var args []interface{}
+	for i, key := range keys {
+		var terms []string
+		for _, equal := range keys[:i] {
+			terms = append(terms, equal.column+" = ?")
+			args = append(args, equal.value(cursor))
+		}
+		terms = append(terms, key.column+" "+operator(key)+" ?")
+		args = append(args, key.value(cursor))
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"favorites/internal/models/apikey"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+	"time"
+)
+
+type MemoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[uuid.UUID]apikey.APIKey
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{keys: make(map[uuid.UUID]apikey.APIKey)}
+}
+
+func (r *MemoryAPIKeyRepository) CreateAPIKey(_ context.Context, k *apikey.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k.ID = uuid.New()
+	k.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	k.Scopes = slices.Clone(k.Scopes)
+	r.keys[k.ID] = *k
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) GetAPIKey(_ context.Context, id uuid.UUID) (apikey.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[id]
+	if !ok {
+		return apikey.APIKey{}, ErrNotFound
+	}
+	return k, nil
+}
+
+func (r *MemoryAPIKeyRepository) RevokeAPIKey(_ context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok || k.ProjectID != projectID || k.RevokedAt != nil {
+		return ErrNotFound
+	}
+	revokedAt := time.Now().UTC()
+	k.RevokedAt = &revokedAt
+	r.keys[id] = k
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) TouchAPIKey(_ context.Context, id uuid.UUID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok || (k.LastUsedAt != nil && !k.LastUsedAt.Before(usedAt.Add(-apiKeyTouchInterval))) {
+		return nil
+	}
+	k.LastUsedAt = &usedAt
+	r.keys[id] = k
+	return nil
+}
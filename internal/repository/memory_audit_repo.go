@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"sync"
+)
+
+// MemoryAuditRepository is an AuditStore reading the events recorded by a
+// MemoryFavoriteRepository, and the EventFeed announcing them.
+type MemoryAuditRepository struct {
+	favorites *MemoryFavoriteRepository
+}
+
+func NewMemoryAuditRepository(favorites *MemoryFavoriteRepository) *MemoryAuditRepository {
+	return &MemoryAuditRepository{favorites: favorites}
+}
+
+func (r *MemoryAuditRepository) GetPageOfEvents(
+	_ context.Context,
+	projectID uuid.UUID,
+	filter AuditFilter,
+	pageRequest PageRequest,
+) (EventPage, error) {
+	r.favorites.mu.RLock()
+	defer r.favorites.mu.RUnlock()
+	var events []audit.Event
+	for _, e := range r.favorites.events {
+		if e.ProjectID == projectID && filter.Matches(e) {
+			events = append(events, e)
+		}
+	}
+	page := EventPage{}
+	page.Events, page.Next, page.Prev = paginateBy(events, CursorOfEvent, SortNewest.keys(), pageRequest)
+	return page, nil
+}
+
+func (r *MemoryAuditRepository) GetEventsAfter(
+	_ context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+	afterSeq int64,
+	limit int,
+) ([]audit.Event, error) {
+	r.favorites.mu.RLock()
+	defer r.favorites.mu.RUnlock()
+	events := []audit.Event{}
+	// Events are kept in seq order and the seq of an event is its position.
+	for _, e := range r.favorites.events[min(max(afterSeq, 0), int64(len(r.favorites.events))):] {
+		if len(events) == limit {
+			break
+		}
+		if e.ProjectID == projectID && e.OwnerType == ownerType && e.OwnerID == ownerID {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *MemoryAuditRepository) GetLastEventSeq(
+	_ context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) (int64, error) {
+	r.favorites.mu.RLock()
+	defer r.favorites.mu.RUnlock()
+	for i := len(r.favorites.events) - 1; i >= 0; i-- {
+		e := r.favorites.events[i]
+		if e.ProjectID == projectID && e.OwnerType == ownerType && e.OwnerID == ownerID {
+			return e.Seq, nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *MemoryAuditRepository) GetFirstEventSeq(
+	_ context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) (int64, error) {
+	r.favorites.mu.RLock()
+	defer r.favorites.mu.RUnlock()
+	for _, e := range r.favorites.events {
+		if e.ProjectID == projectID && e.OwnerType == ownerType && e.OwnerID == ownerID {
+			return e.Seq, nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *MemoryAuditRepository) Listen(ctx context.Context) (<-chan EventNotice, error) {
+	return r.favorites.notices.listen(ctx), nil
+}
+
+// noticeBroadcast hands every notice to all current listeners. A listener
+// too slow to take a notice gets the zero notice instead once it has room,
+// telling it to catch up.
+type noticeBroadcast struct {
+	mu        sync.Mutex
+	listeners map[*noticeListener]struct{}
+}
+
+type noticeListener struct {
+	notices chan EventNotice
+	missed  chan struct{}
+}
+
+func (b *noticeBroadcast) listen(ctx context.Context) <-chan EventNotice {
+	l := &noticeListener{notices: make(chan EventNotice, 64), missed: make(chan struct{}, 1)}
+	b.mu.Lock()
+	if b.listeners == nil {
+		b.listeners = make(map[*noticeListener]struct{})
+	}
+	b.listeners[l] = struct{}{}
+	b.mu.Unlock()
+	out := make(chan EventNotice)
+	go func() {
+		defer close(out)
+		defer func() {
+			b.mu.Lock()
+			delete(b.listeners, l)
+			b.mu.Unlock()
+		}()
+		for {
+			var notice EventNotice
+			select {
+			case <-ctx.Done():
+				return
+			case notice = <-l.notices:
+			case <-l.missed:
+			}
+			select {
+			case out <- notice:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (b *noticeBroadcast) send(notice EventNotice) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for l := range b.listeners {
+		select {
+		case l.notices <- notice:
+		default:
+			select {
+			case l.missed <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
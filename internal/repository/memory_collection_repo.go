@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"favorites/internal/models/collection"
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryCollectionRepository keeps collections in process memory. Links to
+// favorites deleted from favorites are ignored, as if they had cascaded.
+type MemoryCollectionRepository struct {
+	mu          sync.RWMutex
+	favorites   *MemoryFavoriteRepository
+	collections map[uuid.UUID]collection.Collection
+	links       map[uuid.UUID]map[uuid.UUID]bool
+}
+
+func NewMemoryCollectionRepository(favorites *MemoryFavoriteRepository) *MemoryCollectionRepository {
+	return &MemoryCollectionRepository{
+		favorites:   favorites,
+		collections: make(map[uuid.UUID]collection.Collection),
+		links:       make(map[uuid.UUID]map[uuid.UUID]bool),
+	}
+}
+
+func (r *MemoryCollectionRepository) CreateCollection(_ context.Context, c *collection.Collection) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nameTaken(*c, c.Name) {
+		return ErrAlreadyExists
+	}
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	c.UpdatedAt = c.CreatedAt
+	r.collections[c.ID] = *c
+	r.links[c.ID] = make(map[uuid.UUID]bool)
+	return nil
+}
+
+// nameTaken reports whether another collection of c's owner is called name.
+func (r *MemoryCollectionRepository) nameTaken(c collection.Collection, name string) bool {
+	for _, existing := range r.collections {
+		if existing.ID != c.ID &&
+			existing.ProjectID == c.ProjectID &&
+			existing.OwnerType == c.OwnerType &&
+			existing.OwnerID == c.OwnerID &&
+			existing.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryCollectionRepository) GetCollection(
+	_ context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+) (collection.Collection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.collections[id]
+	if !ok || c.ProjectID != projectID {
+		return collection.Collection{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (r *MemoryCollectionRepository) GetCollectionsByOwner(
+	_ context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) ([]collection.Collection, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var collections []collection.Collection
+	for _, c := range r.collections {
+		if c.ProjectID == projectID && c.OwnerType == ownerType && c.OwnerID == ownerID {
+			collections = append(collections, c)
+		}
+	}
+	slices.SortFunc(collections, func(a, b collection.Collection) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID.String(), b.ID.String()))
+	})
+	return collections, nil
+}
+
+func (r *MemoryCollectionRepository) RenameCollection(_ context.Context, c *collection.Collection, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.collections[c.ID]
+	if !ok || stored.ProjectID != c.ProjectID {
+		return ErrNotFound
+	}
+	if r.nameTaken(stored, name) {
+		return ErrAlreadyExists
+	}
+	stored.Name = name
+	stored.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	r.collections[c.ID] = stored
+	*c = stored
+	return nil
+}
+
+func (r *MemoryCollectionRepository) DeleteCollection(_ context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.collections[id]
+	if !ok || c.ProjectID != projectID {
+		return ErrNotFound
+	}
+	delete(r.collections, id)
+	delete(r.links, id)
+	return nil
+}
+
+func (r *MemoryCollectionRepository) AddFavoriteToCollection(
+	ctx context.Context,
+	c collection.Collection,
+	favoriteID uuid.UUID,
+) (bool, error) {
+	f, err := r.favorites.GetFavorite(ctx, c.ProjectID, favoriteID)
+	if err != nil {
+		return false, err
+	}
+	if f.OwnerType != c.OwnerType || f.OwnerID != c.OwnerID {
+		return false, ErrOwnerMismatch
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	links, ok := r.links[c.ID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if links[favoriteID] {
+		return false, nil
+	}
+	links[favoriteID] = true
+	return true, nil
+}
+
+func (r *MemoryCollectionRepository) RemoveFavoriteFromCollection(
+	_ context.Context,
+	c collection.Collection,
+	favoriteID uuid.UUID,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.links[c.ID][favoriteID] {
+		return ErrNotFound
+	}
+	delete(r.links[c.ID], favoriteID)
+	return nil
+}
+
+func (r *MemoryCollectionRepository) GetPageOfCollectionFavorites(
+	_ context.Context,
+	c collection.Collection,
+	pageRequest PageRequest,
+) (Page, error) {
+	r.mu.RLock()
+	links := maps.Clone(r.links[c.ID])
+	r.mu.RUnlock()
+	return r.favorites.selectPage(func(f favorite.Favorite) bool {
+		return f.ProjectID == c.ProjectID && links[f.ID]
+	}, pageRequest), nil
+}
@@ -0,0 +1,829 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/object"
+	"favorites/internal/models/outbox"
+	"github.com/google/uuid"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryFavoriteRepository is a FavoriteStore that keeps favorites in process
+// memory. It is safe for concurrent use and mirrors the ordering and cursor
+// semantics of FavoriteRepository, so it can stand in for PostgreSQL in tests
+// and local demos.
+type MemoryFavoriteRepository struct {
+	mu        sync.RWMutex
+	favorites map[uuid.UUID]favorite.Favorite
+	counts    map[objectKey]int64
+	// hidden holds the ids of the favorites hidden by the deletion of their
+	// object.
+	hidden map[uuid.UUID]bool
+	events []audit.Event
+	// messages is the outbox, in ID order.
+	messages      []outbox.Message
+	lastMessageID int64
+	// notices announces the events to the listeners of the audit feed.
+	notices noticeBroadcast
+}
+
+type objectKey struct {
+	projectID  uuid.UUID
+	objectType favorite.ObjectType
+	objectID   uuid.UUID
+}
+
+func objectKeyOf(f favorite.Favorite) objectKey {
+	return objectKey{projectID: f.ProjectID, objectType: f.ObjectType, objectID: f.ObjectID}
+}
+
+func NewMemoryFavoriteRepository() *MemoryFavoriteRepository {
+	return &MemoryFavoriteRepository{
+		favorites: make(map[uuid.UUID]favorite.Favorite),
+		counts:    make(map[objectKey]int64),
+		hidden:    make(map[uuid.UUID]bool),
+	}
+}
+
+func (r *MemoryFavoriteRepository) GetPageOfFavoritesByOwnerTypeAndOwnerID(
+	_ context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+	filter FavoriteFilter,
+	pageRequest PageRequest,
+) (Page, error) {
+	return r.selectPage(func(f favorite.Favorite) bool {
+		return f.ProjectID == projectID && f.OwnerType == ownerType && f.OwnerID == ownerID && filter.Matches(f)
+	}, pageRequest), nil
+}
+
+func (r *MemoryFavoriteRepository) GetPageOfFavoritesByObject(
+	_ context.Context,
+	projectID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectID uuid.UUID,
+	pageRequest PageRequest,
+) (Page, error) {
+	return r.selectPage(func(f favorite.Favorite) bool {
+		return f.ObjectType == objectType && f.ObjectID == objectID && f.ProjectID == projectID
+	}, pageRequest), nil
+}
+
+func (r *MemoryFavoriteRepository) GetPageOfEffectiveFavorites(
+	_ context.Context,
+	projectID uuid.UUID,
+	userID uuid.UUID,
+	groupIDs []uuid.UUID,
+	pageRequest PageRequest,
+) (Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	effective := make(map[objectKey]favorite.Favorite)
+	for _, f := range r.favorites {
+		own := f.OwnerType == favorite.OwnerTypeUser && f.OwnerID == userID
+		group := f.OwnerType == favorite.OwnerTypeGroup && slices.Contains(groupIDs, f.OwnerID)
+		if f.ProjectID != projectID || f.DeletedAt != nil || !(own || group) {
+			continue
+		}
+		key := objectKeyOf(f)
+		if current, ok := effective[key]; !ok || preferEffective(f, current, userID) {
+			effective[key] = f
+		}
+	}
+	favorites := make([]favorite.Favorite, 0, len(effective))
+	for _, f := range effective {
+		favorites = append(favorites, f)
+	}
+	return paginate(favorites, pageRequest), nil
+}
+
+// preferEffective reports whether f rather than current stands for their
+// object among the effective favorites: the user's own favorite wins,
+// otherwise the newest one.
+func preferEffective(f, current favorite.Favorite, userID uuid.UUID) bool {
+	fOwn := f.OwnerType == favorite.OwnerTypeUser && f.OwnerID == userID
+	currentOwn := current.OwnerType == favorite.OwnerTypeUser && current.OwnerID == userID
+	if fOwn != currentOwn {
+		return fOwn
+	}
+	return compareInOrder(SortNewest.keys(), CursorOf(f), CursorOf(current)) < 0
+}
+
+func (r *MemoryFavoriteRepository) selectPage(match func(f favorite.Favorite) bool, pageRequest PageRequest) Page {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var favorites []favorite.Favorite
+	for _, f := range r.favorites {
+		if f.DeletedAt == nil && match(f) {
+			favorites = append(favorites, f)
+		}
+	}
+	return paginate(favorites, pageRequest)
+}
+
+func (r *MemoryFavoriteRepository) GetFavorite(_ context.Context, projectID uuid.UUID, id uuid.UUID) (favorite.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.favorites[id]
+	if !ok || f.ProjectID != projectID || f.DeletedAt != nil {
+		return favorite.Favorite{}, ErrNotFound
+	}
+	return f, nil
+}
+
+func (r *MemoryFavoriteRepository) GetFavoritesByIDs(
+	_ context.Context,
+	projectID uuid.UUID,
+	ids []uuid.UUID,
+) (map[uuid.UUID]favorite.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	byID := make(map[uuid.UUID]favorite.Favorite)
+	for _, id := range ids {
+		if f, ok := r.favorites[id]; ok && f.ProjectID == projectID && f.DeletedAt == nil {
+			byID[id] = f
+		}
+	}
+	return byID, nil
+}
+
+func (r *MemoryFavoriteRepository) CreateFavorite(_ context.Context, f *favorite.Favorite, actor audit.Actor) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// New favorites go to the top of the owner's unpinned favorites.
+	position, err := positionBetween("", r.sectionEdge(*f, false))
+	if err != nil {
+		return false, err
+	}
+	return r.insertFavorite(f, position, actor), nil
+}
+
+func (r *MemoryFavoriteRepository) CreateFavorites(_ context.Context, fs []favorite.Favorite, actor audit.Actor) ([]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	positions, err := topPositions(fs, func(f favorite.Favorite) (string, error) {
+		return r.sectionEdge(f, false), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	created := make([]bool, len(fs))
+	for i := range fs {
+		created[i] = r.insertFavorite(&fs[i], positions[i], actor)
+	}
+	return created, nil
+}
+
+// insertFavorite stores f at position unless the owner already favorited
+// the object, in which case it loads the existing favorite into f. It
+// reports whether f is new. The caller must hold the write lock.
+func (r *MemoryFavoriteRepository) insertFavorite(f *favorite.Favorite, position string, actor audit.Actor) bool {
+	for _, existing := range r.favorites {
+		if existing.DeletedAt == nil &&
+			existing.ProjectID == f.ProjectID &&
+			existing.OwnerType == f.OwnerType &&
+			existing.OwnerID == f.OwnerID &&
+			existing.ObjectID == f.ObjectID &&
+			existing.ObjectType == f.ObjectType {
+			*f = existing
+			return false
+		}
+	}
+	f.ID = uuid.New()
+	f.Position = position
+	f.Tags = sortedTags(f.Tags)
+	// PostgreSQL TIMESTAMP columns keep microsecond precision.
+	f.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	r.favorites[f.ID] = *f
+	r.counts[objectKeyOf(*f)]++
+	r.recordEvent(audit.ActionCreate, actor, nil, f, f.CreatedAt)
+	r.enqueueMessage(outbox.TypeFavoriteCreated, *f)
+	return true
+}
+
+func (r *MemoryFavoriteRepository) MoveFavorite(
+	_ context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	move Move,
+) (favorite.Favorite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.favorites[id]
+	if !ok || f.ProjectID != projectID || f.DeletedAt != nil {
+		return favorite.Favorite{}, ErrNotFound
+	}
+	var after, before *favorite.Favorite
+	for _, anchor := range []struct {
+		id     *uuid.UUID
+		target **favorite.Favorite
+	}{{move.After, &after}, {move.Before, &before}} {
+		if anchor.id == nil {
+			continue
+		}
+		a, ok := r.favorites[*anchor.id]
+		if !ok || a.ProjectID != projectID || a.DeletedAt != nil {
+			return favorite.Favorite{}, ErrNotFound
+		}
+		*anchor.target = &a
+	}
+	pinned, err := movedPinned(f, after, before, move)
+	if err != nil {
+		return favorite.Favorite{}, err
+	}
+	var lower, upper string
+	switch {
+	case after != nil && before != nil:
+		lower, upper = after.Position, before.Position
+	case after != nil:
+		lower, upper = after.Position, r.neighbourPosition(f, *after, false)
+	case before != nil:
+		lower, upper = r.neighbourPosition(f, *before, true), before.Position
+	default:
+		upper = r.sectionEdge(f, pinned)
+	}
+	position, err := positionBetween(lower, upper)
+	if err != nil {
+		return favorite.Favorite{}, err
+	}
+	f.Position, f.Pinned = position, pinned
+	r.favorites[f.ID] = f
+	r.enqueueMessage(outbox.TypeFavoriteMoved, f)
+	return f, nil
+}
+
+func (r *MemoryFavoriteRepository) UpdateFavorite(
+	_ context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	update FavoriteUpdate,
+) (favorite.Favorite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.favorites[id]
+	if !ok || f.ProjectID != projectID || f.DeletedAt != nil {
+		return favorite.Favorite{}, ErrNotFound
+	}
+	if update.Note != nil {
+		f.Note = *update.Note
+	}
+	if update.Tags != nil {
+		f.Tags = sortedTags(*update.Tags)
+	}
+	r.favorites[id] = f
+	r.enqueueMessage(outbox.TypeFavoriteUpdated, f)
+	return f, nil
+}
+
+func (r *MemoryFavoriteRepository) GetTagCounts(
+	_ context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+) (map[string]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts := make(map[string]int64)
+	for _, f := range r.favorites {
+		if f.ProjectID == projectID && f.OwnerType == ownerType && f.OwnerID == ownerID && f.DeletedAt == nil {
+			for _, tag := range f.Tags {
+				counts[tag]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// sectionEdge returns the first position among the pinned or unpinned
+// favorites of f's owner other than f, or "" if there is none. Soft-deleted
+// favorites count too, as in FavoriteRepository.
+func (r *MemoryFavoriteRepository) sectionEdge(f favorite.Favorite, pinned bool) string {
+	edge := ""
+	for _, other := range r.favorites {
+		if sameOwner(other, f) && other.ID != f.ID && other.Pinned == pinned &&
+			(edge == "" || other.Position < edge) {
+			edge = other.Position
+		}
+	}
+	return edge
+}
+
+// neighbourPosition returns the position of the favorite directly following
+// anchor in its section, or directly preceding it when backward is set,
+// skipping the moved favorite f. It returns "" at the end of the section.
+func (r *MemoryFavoriteRepository) neighbourPosition(f favorite.Favorite, anchor favorite.Favorite, backward bool) string {
+	keys := []sortKey{positionKey(backward), idKey(backward)}
+	var neighbour *Cursor
+	for _, other := range r.favorites {
+		if !sameOwner(other, f) || other.ID == f.ID || other.Pinned != anchor.Pinned {
+			continue
+		}
+		cursor := CursorOf(other)
+		if compareInOrder(keys, cursor, CursorOf(anchor)) > 0 &&
+			(neighbour == nil || compareInOrder(keys, cursor, neighbour) < 0) {
+			neighbour = cursor
+		}
+	}
+	if neighbour == nil {
+		return ""
+	}
+	return neighbour.Position
+}
+
+func sameOwner(a, b favorite.Favorite) bool {
+	return a.ProjectID == b.ProjectID && a.OwnerType == b.OwnerType && a.OwnerID == b.OwnerID
+}
+
+func (r *MemoryFavoriteRepository) DeleteFavorite(
+	_ context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	actor audit.Actor,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.softDelete(projectID, id, false, actor); !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) DeleteFavorites(
+	_ context.Context,
+	projectID uuid.UUID,
+	ids []uuid.UUID,
+	actor audit.Actor,
+) ([]favorite.Favorite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted []favorite.Favorite
+	for _, id := range ids {
+		if f, ok := r.softDelete(projectID, id, false, actor); ok {
+			deleted = append(deleted, f)
+		}
+	}
+	return deleted, nil
+}
+
+func (r *MemoryFavoriteRepository) PushFavoriteChange(
+	_ context.Context,
+	change PushedChange,
+	actor audit.Actor,
+) (PushResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := PushResult{ChangedAt: change.ChangedAt}
+	f := change.Favorite
+	last := r.lastFavoriteEvent(f)
+	current := r.currentFavorite(f)
+	result.Seq = last.Seq
+	if change.lastChangedAt(last.Seq, last.CreatedAt).After(change.ChangedAt) {
+		result.Outcome, result.Favorite = PushConflict, current
+		return result, nil
+	}
+	result.Outcome = PushUnchanged
+	if !change.Deleted {
+		position, err := positionBetween("", r.sectionEdge(f, false))
+		if err != nil {
+			return PushResult{}, err
+		}
+		if r.insertFavorite(&f, position, actor) {
+			result.Outcome = PushApplied
+		}
+		result.Favorite = &f
+	} else if current != nil {
+		r.softDelete(f.ProjectID, current.ID, false, actor)
+		result.Outcome = PushApplied
+	}
+	if result.Outcome == PushApplied {
+		result.Seq = r.lastFavoriteEvent(f).Seq
+	}
+	return result, nil
+}
+
+// lastFavoriteEvent returns the last event of the owner's favorite of the
+// object, or a zero event if there is none. The caller must hold the lock.
+func (r *MemoryFavoriteRepository) lastFavoriteEvent(f favorite.Favorite) audit.Event {
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		if e.ProjectID == f.ProjectID &&
+			e.OwnerType == f.OwnerType &&
+			e.OwnerID == f.OwnerID &&
+			e.ObjectType == f.ObjectType &&
+			e.ObjectID == f.ObjectID {
+			return e
+		}
+	}
+	return audit.Event{}
+}
+
+// currentFavorite returns the owner's favorite of the object, or nil if the
+// owner has not favorited it. The caller must hold the lock.
+func (r *MemoryFavoriteRepository) currentFavorite(f favorite.Favorite) *favorite.Favorite {
+	for _, existing := range r.favorites {
+		if existing.DeletedAt == nil && favoriteKeyOf(existing) == favoriteKeyOf(f) {
+			return snapshotOf(&existing)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryFavoriteRepository) EraseFavorites(
+	_ context.Context,
+	projectID uuid.UUID,
+	erasure Erasure,
+	_ int,
+	actor audit.Actor,
+) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uuid.UUID
+	for id, f := range r.favorites {
+		if f.ProjectID != projectID || !erasure.Matches(f) {
+			continue
+		}
+		if f.DeletedAt == nil {
+			ids = append(ids, id)
+		} else if erasure.OwnerType != "" {
+			// Hidden favorites of an erased owner stay deleted when their
+			// objects are restored.
+			delete(r.hidden, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	hidden := erasure.Hide && erasure.OwnerType == ""
+	for _, id := range ids {
+		r.softDelete(projectID, id, hidden, actor)
+	}
+	if erasure.OwnerType != "" {
+		r.redactOwner(projectID, erasure)
+	}
+	total := int64(len(ids))
+	r.appendEvent(erasure.receipt(projectID, total, actor), time.Now().UTC().Truncate(time.Microsecond))
+	return total, nil
+}
+
+// redactOwner removes the notes and tags of the deleted favorites the erasure
+// selects from the favorites, their events and their messages. The caller
+// must hold the write lock.
+func (r *MemoryFavoriteRepository) redactOwner(projectID uuid.UUID, erasure Erasure) {
+	redacted := func(f favorite.Favorite) bool {
+		if f.ProjectID != projectID || !erasure.Matches(f) {
+			return false
+		}
+		current, ok := r.favorites[f.ID]
+		return !ok || current.DeletedAt != nil
+	}
+	for id, f := range r.favorites {
+		if redacted(f) {
+			r.favorites[id] = *redact(&f)
+		}
+	}
+	for i, e := range r.events {
+		if f := cmp.Or(e.After, e.Before); f != nil && redacted(*f) {
+			r.events[i].Before = redact(e.Before)
+			r.events[i].After = redact(e.After)
+		}
+	}
+	for i, m := range r.messages {
+		if redacted(m.Favorite) {
+			r.messages[i].Favorite = *redact(&m.Favorite)
+		}
+	}
+}
+
+// softDelete marks the favorite of the project with the given id deleted, or
+// hidden by the deletion of its object, and returns it, or reports false if
+// there is none. The caller must hold the write lock.
+func (r *MemoryFavoriteRepository) softDelete(
+	projectID uuid.UUID,
+	id uuid.UUID,
+	hidden bool,
+	actor audit.Actor,
+) (favorite.Favorite, bool) {
+	f, ok := r.favorites[id]
+	if !ok || f.ProjectID != projectID || f.DeletedAt != nil {
+		return favorite.Favorite{}, false
+	}
+	before := f
+	deletedAt := time.Now().UTC().Truncate(time.Microsecond)
+	f.DeletedAt = &deletedAt
+	r.favorites[id] = f
+	key := objectKeyOf(f)
+	if r.counts[key]--; r.counts[key] <= 0 {
+		delete(r.counts, key)
+	}
+	if hidden {
+		r.hidden[id] = true
+		r.recordEvent(audit.ActionHide, actor, &before, &f, deletedAt)
+		r.enqueueMessage(outbox.TypeFavoriteHidden, f)
+	} else {
+		r.recordEvent(audit.ActionDelete, actor, &before, &f, deletedAt)
+		r.enqueueMessage(outbox.TypeFavoriteDeleted, f)
+	}
+	return f, true
+}
+
+func (r *MemoryFavoriteRepository) GetDeletedFavorite(
+	_ context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	deletedAfter time.Time,
+) (favorite.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.favorites[id]
+	if !ok || f.ProjectID != projectID || f.DeletedAt == nil || f.DeletedAt.Before(deletedAfter) {
+		return favorite.Favorite{}, ErrNotFound
+	}
+	return f, nil
+}
+
+func (r *MemoryFavoriteRepository) RestoreFavorite(
+	_ context.Context,
+	projectID uuid.UUID,
+	id uuid.UUID,
+	deletedAfter time.Time,
+	actor audit.Actor,
+) (favorite.Favorite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.favorites[id]
+	if !ok || f.ProjectID != projectID || f.DeletedAt == nil || f.DeletedAt.Before(deletedAfter) {
+		return favorite.Favorite{}, ErrNotFound
+	}
+	if r.hidden[id] {
+		return favorite.Favorite{}, ErrHiddenByObject
+	}
+	if r.favoritedAgain(f) {
+		return favorite.Favorite{}, ErrAlreadyExists
+	}
+	return r.restore(f, actor), nil
+}
+
+func (r *MemoryFavoriteRepository) RestoreObjectFavorites(
+	_ context.Context,
+	projectID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectID uuid.UUID,
+	deletedAfter time.Time,
+	_ int,
+	actor audit.Actor,
+) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// The latest hidden favorite of every owner. The favorites of the object
+	// are no longer hidden afterwards, restored or not.
+	latest := make(map[favoriteKey]favorite.Favorite)
+	for _, f := range r.favorites {
+		if f.ProjectID != projectID || f.ObjectType != objectType || f.ObjectID != objectID || !r.hidden[f.ID] {
+			continue
+		}
+		delete(r.hidden, f.ID)
+		if f.DeletedAt.Before(deletedAfter) || r.favoritedAgain(f) {
+			continue
+		}
+		key := favoriteKeyOf(f)
+		if current, ok := latest[key]; !ok || f.DeletedAt.After(*current.DeletedAt) {
+			latest[key] = f
+		}
+	}
+	restored := slices.SortedFunc(maps.Values(latest), func(a, b favorite.Favorite) int {
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	for _, f := range restored {
+		r.restore(f, actor)
+	}
+	return int64(len(restored)), nil
+}
+
+// favoritedAgain reports whether the owner of the deleted favorite f has a
+// favorite of the same object. The caller must hold the lock.
+func (r *MemoryFavoriteRepository) favoritedAgain(f favorite.Favorite) bool {
+	for _, existing := range r.favorites {
+		if existing.DeletedAt == nil && favoriteKeyOf(existing) == favoriteKeyOf(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// restore undoes the soft delete of f and returns the restored favorite. The
+// caller must hold the write lock.
+func (r *MemoryFavoriteRepository) restore(f favorite.Favorite, actor audit.Actor) favorite.Favorite {
+	before := f
+	f.DeletedAt = nil
+	r.favorites[f.ID] = f
+	r.counts[objectKeyOf(f)]++
+	r.recordEvent(audit.ActionRestore, actor, &before, &f, time.Now().UTC().Truncate(time.Microsecond))
+	r.enqueueMessage(outbox.TypeFavoriteRestored, f)
+	return f
+}
+
+// recordEvent appends the change of a favorite to the audit log. Events get
+// increasing times so that changes within one microsecond keep their order.
+// The caller must hold the write lock.
+func (r *MemoryFavoriteRepository) recordEvent(
+	action audit.Action,
+	actor audit.Actor,
+	before, after *favorite.Favorite,
+	at time.Time,
+) {
+	r.appendEvent(audit.NewEvent(action, actor, snapshotOf(before), snapshotOf(after)), at)
+}
+
+// appendEvent appends e to the audit log at the given time. The caller must
+// hold the write lock.
+func (r *MemoryFavoriteRepository) appendEvent(e audit.Event, at time.Time) {
+	e.ID = uuid.New()
+	if n := len(r.events); n > 0 && !at.After(r.events[n-1].CreatedAt) {
+		at = r.events[n-1].CreatedAt.Add(time.Microsecond)
+	}
+	e.CreatedAt = at
+	e.Seq = int64(len(r.events)) + 1
+	r.events = append(r.events, e)
+	r.notices.send(NoticeOf(e))
+}
+
+// enqueueMessage appends the announcement of a change of f to the outbox.
+// The caller must hold the write lock.
+func (r *MemoryFavoriteRepository) enqueueMessage(messageType outbox.Type, f favorite.Favorite) {
+	r.lastMessageID++
+	m := outbox.NewMessage(messageType, *snapshotOf(&f))
+	m.ID = r.lastMessageID
+	m.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	r.messages = append(r.messages, m)
+}
+
+// snapshotOf copies f so that later changes to the favorite do not leak into
+// recorded events.
+func snapshotOf(f *favorite.Favorite) *favorite.Favorite {
+	if f == nil {
+		return nil
+	}
+	snapshot := *f
+	snapshot.Tags = slices.Clone(f.Tags)
+	return &snapshot
+}
+
+func (r *MemoryFavoriteRepository) PurgeDeletedFavorites(_ context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged int64
+	for id, f := range r.favorites {
+		if f.DeletedAt != nil && f.DeletedAt.Before(deletedBefore) {
+			delete(r.favorites, id)
+			delete(r.hidden, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemoryFavoriteRepository) LookupFavorites(
+	_ context.Context,
+	projectID uuid.UUID,
+	ownerType favorite.OwnerType,
+	ownerID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectIDs []uuid.UUID,
+) (map[uuid.UUID]uuid.UUID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	wanted := make(map[uuid.UUID]bool, len(objectIDs))
+	for _, objectID := range objectIDs {
+		wanted[objectID] = true
+	}
+	favoriteIDs := make(map[uuid.UUID]uuid.UUID)
+	for _, f := range r.favorites {
+		if f.ProjectID == projectID &&
+			f.OwnerType == ownerType &&
+			f.OwnerID == ownerID &&
+			f.ObjectType == objectType &&
+			f.DeletedAt == nil &&
+			wanted[f.ObjectID] {
+			favoriteIDs[f.ObjectID] = f.ID
+		}
+	}
+	return favoriteIDs, nil
+}
+
+func (r *MemoryFavoriteRepository) GetFavoriteCounts(
+	_ context.Context,
+	projectID uuid.UUID,
+	objectType favorite.ObjectType,
+	objectIDs []uuid.UUID,
+) (map[uuid.UUID]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts := make(map[uuid.UUID]int64)
+	for _, objectID := range objectIDs {
+		key := objectKey{projectID: projectID, objectType: objectType, objectID: objectID}
+		if count, ok := r.counts[key]; ok {
+			counts[objectID] = count
+		}
+	}
+	return counts, nil
+}
+
+func (r *MemoryFavoriteRepository) GetFavoritedObjects(_ context.Context, after object.Ref, limit int) ([]object.Ref, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	refs := []object.Ref{}
+	for key := range r.counts {
+		ref := object.Ref{ProjectID: key.projectID, ObjectType: key.objectType, ObjectID: key.objectID}
+		if compareRefs(ref, after) > 0 {
+			refs = append(refs, ref)
+		}
+	}
+	slices.SortFunc(refs, compareRefs)
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+func (r *MemoryFavoriteRepository) RepairFavoriteCounts(_ context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actual := make(map[objectKey]int64)
+	for _, f := range r.favorites {
+		if f.DeletedAt == nil {
+			actual[objectKeyOf(f)]++
+		}
+	}
+	var drifted int64
+	for key, count := range actual {
+		if r.counts[key] != count {
+			drifted++
+		}
+	}
+	for key := range r.counts {
+		if _, ok := actual[key]; !ok {
+			drifted++
+		}
+	}
+	r.counts = actual
+	return drifted, nil
+}
+
+// paginate cuts the page described by pageRequest out of favorites.
+func paginate(favorites []favorite.Favorite, pageRequest PageRequest) Page {
+	page := Page{}
+	page.Favorites, page.Next, page.Prev = paginateBy(favorites, CursorOf, pageRequest.Sort.keys(), pageRequest)
+	return page
+}
+
+// paginateBy sorts items by keys and cuts the page described by pageRequest
+// out of them, returning it with its Next and Prev cursors.
+func paginateBy[T any](
+	items []T,
+	cursorOf func(T) *Cursor,
+	keys []sortKey,
+	pageRequest PageRequest,
+) ([]T, *Cursor, *Cursor) {
+	sort.Slice(items, func(i, j int) bool {
+		return compareInOrder(keys, cursorOf(items[i]), cursorOf(items[j])) < 0
+	})
+	start, end := 0, len(items)
+	switch {
+	case pageRequest.Before != nil:
+		end = sort.Search(len(items), func(i int) bool {
+			return compareInOrder(keys, cursorOf(items[i]), pageRequest.Before) >= 0
+		})
+		if uint64(end) > pageRequest.Limit {
+			start = end - int(pageRequest.Limit)
+		}
+	case pageRequest.After != nil:
+		start = sort.Search(len(items), func(i int) bool {
+			return compareInOrder(keys, cursorOf(items[i]), pageRequest.After) > 0
+		})
+		fallthrough
+	default:
+		if uint64(end-start) > pageRequest.Limit {
+			end = start + int(pageRequest.Limit)
+		}
+	}
+	if start == end {
+		return nil, nil, nil
+	}
+	var next, prev *Cursor
+	if start > 0 {
+		prev = cursorOf(items[start])
+	}
+	if end < len(items) {
+		next = cursorOf(items[end-1])
+	}
+	return items[start:end], next, prev
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+)
+
+type groupMember struct {
+	projectID uuid.UUID
+	groupID   uuid.UUID
+	userID    uuid.UUID
+}
+
+type MemoryGroupMemberRepository struct {
+	mu      sync.RWMutex
+	members map[groupMember]bool
+}
+
+func NewMemoryGroupMemberRepository() *MemoryGroupMemberRepository {
+	return &MemoryGroupMemberRepository{members: make(map[groupMember]bool)}
+}
+
+func (r *MemoryGroupMemberRepository) GetGroupsOfUser(
+	_ context.Context,
+	projectID uuid.UUID,
+	userID uuid.UUID,
+) ([]uuid.UUID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var groupIDs []uuid.UUID
+	for member := range r.members {
+		if member.projectID == projectID && member.userID == userID {
+			groupIDs = append(groupIDs, member.groupID)
+		}
+	}
+	slices.SortFunc(groupIDs, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	return groupIDs, nil
+}
+
+func (r *MemoryGroupMemberRepository) AddGroupMember(
+	_ context.Context,
+	projectID uuid.UUID,
+	groupID uuid.UUID,
+	userID uuid.UUID,
+) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	member := groupMember{projectID: projectID, groupID: groupID, userID: userID}
+	if r.members[member] {
+		return false, nil
+	}
+	r.members[member] = true
+	return true, nil
+}
+
+func (r *MemoryGroupMemberRepository) RemoveGroupMember(
+	_ context.Context,
+	projectID uuid.UUID,
+	groupID uuid.UUID,
+	userID uuid.UUID,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	member := groupMember{projectID: projectID, groupID: groupID, userID: userID}
+	if !r.members[member] {
+		return ErrNotFound
+	}
+	delete(r.members, member)
+	return nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type idempotencyKey struct {
+	key   string
+	scope string
+}
+
+type MemoryIdempotencyRepository struct {
+	mu        sync.Mutex
+	responses map[idempotencyKey]IdempotentResponse
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{responses: make(map[idempotencyKey]IdempotentResponse)}
+}
+
+func (r *MemoryIdempotencyRepository) GetIdempotentResponse(
+	_ context.Context,
+	key, scope string,
+	notBefore time.Time,
+) (*IdempotentResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	response, ok := r.responses[idempotencyKey{key: key, scope: scope}]
+	if !ok || response.CreatedAt.Before(notBefore) {
+		return nil, nil
+	}
+	return &response, nil
+}
+
+func (r *MemoryIdempotencyRepository) SaveIdempotentResponse(
+	_ context.Context,
+	response IdempotentResponse,
+	notBefore time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, stored := range r.responses {
+		if stored.CreatedAt.Before(notBefore) {
+			delete(r.responses, k)
+		}
+	}
+	k := idempotencyKey{key: response.Key, scope: response.Scope}
+	if _, ok := r.responses[k]; ok {
+		return nil
+	}
+	response.CreatedAt = time.Now().UTC()
+	r.responses[k] = response
+	return nil
+}
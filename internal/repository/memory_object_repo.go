@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"favorites/internal/models/object"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryObjectRepository keeps the states of objects in process memory.
+type MemoryObjectRepository struct {
+	mu     sync.Mutex
+	states map[object.Ref]object.State
+}
+
+func NewMemoryObjectRepository() *MemoryObjectRepository {
+	return &MemoryObjectRepository{states: make(map[object.Ref]object.State)}
+}
+
+func (r *MemoryObjectRepository) RecordObjectEvent(_ context.Context, e object.Event) (object.State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous, ok := r.states[e.Ref]
+	if ok && e.OccurredAt.Before(previous.ChangedAt) {
+		return object.State{}, ErrStaleEvent
+	}
+	state := object.State{
+		Ref:       e.Ref,
+		Deleted:   e.Type == object.EventDeleted,
+		ChangedAt: e.OccurredAt,
+		HiddenAt:  previous.HiddenAt,
+	}
+	if state.Deleted && (!ok || !previous.Deleted) {
+		hiddenAt := time.Now().UTC().Truncate(time.Microsecond)
+		state.HiddenAt = &hiddenAt
+	}
+	r.states[e.Ref] = state
+	return state, nil
+}
+
+func (r *MemoryObjectRepository) GetDeletedObjects(_ context.Context, after object.Ref, limit int) ([]object.Ref, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refs := []object.Ref{}
+	for ref, state := range r.states {
+		if state.Deleted && compareRefs(ref, after) > 0 {
+			refs = append(refs, ref)
+		}
+	}
+	slices.SortFunc(refs, compareRefs)
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+// compareRefs orders objects by project, type and id like PostgreSQL does.
+func compareRefs(a, b object.Ref) int {
+	if c := strings.Compare(a.ProjectID.String(), b.ProjectID.String()); c != 0 {
+		return c
+	}
+	if c := strings.Compare(string(a.ObjectType), string(b.ObjectType)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ObjectID.String(), b.ObjectID.String())
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"favorites/internal/models/outbox"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryOutboxRepository is an OutboxStore relaying the messages written by a
+// MemoryFavoriteRepository.
+type MemoryOutboxRepository struct {
+	favorites *MemoryFavoriteRepository
+	// relay is held while publishing so that relays take turns.
+	relay sync.Mutex
+}
+
+func NewMemoryOutboxRepository(favorites *MemoryFavoriteRepository) *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{favorites: favorites}
+}
+
+func (r *MemoryOutboxRepository) PublishMessages(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, m outbox.Message) error,
+) (int, error) {
+	if !r.relay.TryLock() {
+		return 0, nil
+	}
+	defer r.relay.Unlock()
+	var pending []outbox.Message
+	r.favorites.mu.RLock()
+	for _, m := range r.favorites.messages {
+		if len(pending) == limit {
+			break
+		}
+		if m.PublishedAt == nil {
+			pending = append(pending, m)
+		}
+	}
+	r.favorites.mu.RUnlock()
+	published := 0
+	var publishErr error
+	for _, m := range pending {
+		if publishErr = publish(ctx, m); publishErr != nil {
+			break
+		}
+		published++
+	}
+	r.favorites.mu.Lock()
+	defer r.favorites.mu.Unlock()
+	now := time.Now().UTC()
+	for _, m := range pending[:published] {
+		// Messages are kept in ID order.
+		i, found := slices.BinarySearchFunc(r.favorites.messages, m.ID, func(m outbox.Message, id int64) int {
+			return cmp.Compare(m.ID, id)
+		})
+		if found {
+			r.favorites.messages[i].PublishedAt = &now
+		}
+	}
+	return published, publishErr
+}
+
+func (r *MemoryOutboxRepository) DeletePublishedMessages(_ context.Context, publishedBefore time.Time) (int64, error) {
+	r.favorites.mu.Lock()
+	defer r.favorites.mu.Unlock()
+	// Messages are published in order, so the published ones form a prefix.
+	n := 0
+	for n < len(r.favorites.messages) {
+		publishedAt := r.favorites.messages[n].PublishedAt
+		if publishedAt == nil || !publishedAt.Before(publishedBefore) {
+			break
+		}
+		n++
+	}
+	r.favorites.messages = r.favorites.messages[n:]
+	return int64(n), nil
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"favorites/internal/models/outbox"
+	"favorites/internal/models/webhook"
+	"github.com/google/uuid"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MemoryWebhookRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[uuid.UUID]webhook.Subscription
+	deliveries    map[uuid.UUID]webhook.Delivery
+}
+
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		subscriptions: make(map[uuid.UUID]webhook.Subscription),
+		deliveries:    make(map[uuid.UUID]webhook.Delivery),
+	}
+}
+
+func (r *MemoryWebhookRepository) CreateSubscription(_ context.Context, s *webhook.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s.ID = uuid.New()
+	s.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	s.Events = slices.Clone(s.Events)
+	s.ObjectTypes = slices.Clone(s.ObjectTypes)
+	r.subscriptions[s.ID] = *s
+	return nil
+}
+
+func (r *MemoryWebhookRepository) GetSubscriptions(_ context.Context, projectID uuid.UUID) ([]webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.subscriptionsOf(projectID), nil
+}
+
+// subscriptionsOf returns the subscriptions of the project, oldest first. The
+// caller must hold the lock.
+func (r *MemoryWebhookRepository) subscriptionsOf(projectID uuid.UUID) []webhook.Subscription {
+	subscriptions := []webhook.Subscription{}
+	for _, s := range r.subscriptions {
+		if s.ProjectID == projectID {
+			subscriptions = append(subscriptions, s)
+		}
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		a, b := subscriptions[i], subscriptions[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return subscriptions
+}
+
+func (r *MemoryWebhookRepository) GetSubscription(_ context.Context, projectID uuid.UUID, id uuid.UUID) (webhook.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.subscriptions[id]
+	if !ok || s.ProjectID != projectID {
+		return webhook.Subscription{}, ErrNotFound
+	}
+	return s, nil
+}
+
+func (r *MemoryWebhookRepository) DeleteSubscription(_ context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.subscriptions[id]
+	if !ok || s.ProjectID != projectID {
+		return ErrNotFound
+	}
+	delete(r.subscriptions, id)
+	for deliveryID, d := range r.deliveries {
+		if d.SubscriptionID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRepository) EnqueueDeliveries(_ context.Context, m outbox.Message) (int, error) {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	created := 0
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	for _, s := range r.subscriptionsOf(m.ProjectID) {
+		if !s.Matches(m) || r.hasDelivery(s.ID, m.ID) {
+			continue
+		}
+		d := webhook.Delivery{
+			ID:             uuid.New(),
+			ProjectID:      m.ProjectID,
+			SubscriptionID: s.ID,
+			MessageID:      m.ID,
+			Event:          m.Type,
+			Payload:        payload,
+			Status:         webhook.StatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+		r.deliveries[d.ID] = d
+		created++
+	}
+	return created, nil
+}
+
+func (r *MemoryWebhookRepository) hasDelivery(subscriptionID uuid.UUID, messageID int64) bool {
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID && d.MessageID == messageID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryWebhookRepository) ClaimDueDeliveries(
+	_ context.Context,
+	now time.Time,
+	limit int,
+	lease time.Duration,
+) ([]ClaimedDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []webhook.Delivery
+	for _, d := range r.deliveries {
+		if d.Status == webhook.StatusPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID.String() < due[j].ID.String()
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	claimed := make([]ClaimedDelivery, len(due))
+	for i, d := range due {
+		d.NextAttemptAt = now.Add(lease)
+		r.deliveries[d.ID] = d
+		s := r.subscriptions[d.SubscriptionID]
+		claimed[i] = ClaimedDelivery{Delivery: d, URL: s.URL, Secret: s.Secret}
+	}
+	return claimed, nil
+}
+
+func (r *MemoryWebhookRepository) SaveDeliveryAttempt(_ context.Context, d webhook.Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.deliveries[d.ID]
+	if !ok {
+		return nil
+	}
+	stored.Status = d.Status
+	stored.Attempts = d.Attempts
+	stored.NextAttemptAt = d.NextAttemptAt
+	stored.LastStatusCode = d.LastStatusCode
+	stored.LastError = d.LastError
+	stored.DeliveredAt = d.DeliveredAt
+	r.deliveries[d.ID] = stored
+	return nil
+}
+
+func (r *MemoryWebhookRepository) GetDeliveries(
+	_ context.Context,
+	projectID uuid.UUID,
+	subscriptionID uuid.UUID,
+	limit int,
+) ([]webhook.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	deliveries := []webhook.Delivery{}
+	for _, d := range r.deliveries {
+		if d.ProjectID == projectID && d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		a, b := deliveries[i], deliveries[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID.String() > b.ID.String()
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *MemoryWebhookRepository) RedeliverDelivery(
+	_ context.Context,
+	projectID uuid.UUID,
+	subscriptionID uuid.UUID,
+	id uuid.UUID,
+	now time.Time,
+) (webhook.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok || d.ProjectID != projectID || d.SubscriptionID != subscriptionID {
+		return webhook.Delivery{}, ErrNotFound
+	}
+	if d.Status == webhook.StatusDelivered {
+		return webhook.Delivery{}, ErrAlreadyDelivered
+	}
+	d.Status = webhook.StatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	r.deliveries[id] = d
+	return d, nil
+}
+
+func (r *MemoryWebhookRepository) DeleteFinishedDeliveries(_ context.Context, createdBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id, d := range r.deliveries {
+		if d.Status != webhook.StatusPending && d.CreatedAt.Before(createdBefore) {
+			delete(r.deliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"favorites/internal/models/object"
+	"github.com/jmoiron/sqlx"
+)
+
+type ObjectRepository struct {
+	db *sqlx.DB
+}
+
+func NewObjectRepository(db *sqlx.DB) *ObjectRepository {
+	return &ObjectRepository{db: db}
+}
+
+func (r *ObjectRepository) RecordObjectEvent(ctx context.Context, e object.Event) (object.State, error) {
+	var state object.State
+	// hidden_at only moves when a restored object is deleted again, so a
+	// repeated deletion keeps hiding the favorites from the first one on.
+	query := `INSERT INTO object_states (project_id, object_type, object_id, deleted, changed_at, hidden_at)
+	          VALUES ($1, $2, $3, $4, $5, CASE WHEN $4::BOOLEAN THEN NOW() END)
+	          ON CONFLICT (project_id, object_type, object_id) DO UPDATE
+	          SET deleted    = EXCLUDED.deleted,
+	              changed_at = EXCLUDED.changed_at,
+	              hidden_at  = CASE
+	                               WHEN EXCLUDED.deleted AND NOT object_states.deleted THEN NOW()
+	                               ELSE object_states.hidden_at
+	                           END
+	          WHERE object_states.changed_at <= EXCLUDED.changed_at
+	          RETURNING project_id, object_type, object_id, deleted, changed_at, hidden_at;`
+	err := r.db.QueryRowxContext(
+		ctx,
+		query,
+		e.ProjectID,
+		e.ObjectType,
+		e.ObjectID,
+		e.Type == object.EventDeleted,
+		e.OccurredAt,
+	).StructScan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, ErrStaleEvent
+	}
+	return state, err
+}
+
+func (r *ObjectRepository) GetDeletedObjects(ctx context.Context, after object.Ref, limit int) ([]object.Ref, error) {
+	refs := []object.Ref{}
+	query := `SELECT project_id, object_type, object_id
+	          FROM object_states
+	          WHERE deleted
+	            AND (project_id, object_type, object_id) > ($1, $2, $3)
+	          ORDER BY project_id, object_type, object_id
+	          LIMIT $4;`
+	err := r.db.SelectContext(ctx, &refs, query, after.ProjectID, after.ObjectType, after.ObjectID, limit)
+	return refs, err
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"favorites/internal/models/object"
+)
+
+// ErrStaleEvent is returned for an object event that occurred before the
+// latest one recorded for its object.
+var ErrStaleEvent = errors.New("a later event of the object was recorded")
+
+// ObjectStore keeps the state the latest deleted or restored event left each
+// object in, so that events arriving late are told apart from new ones.
+type ObjectStore interface {
+	// RecordObjectEvent stores the state e leaves its object in and returns
+	// it. An event occurring at the same time as the latest one replaces it,
+	// which makes repeating an event harmless. It returns ErrStaleEvent if a
+	// later event was recorded.
+	RecordObjectEvent(ctx context.Context, e object.Event) (object.State, error)
+	// GetDeletedObjects returns up to limit objects of any project whose
+	// latest event deleted them, following after in project, type and id
+	// order. The zero Ref starts from the first object.
+	GetDeletedObjects(ctx context.Context, after object.Ref, limit int) ([]object.Ref, error)
+}
+
+var (
+	_ ObjectStore = (*ObjectRepository)(nil)
+	_ ObjectStore = (*MemoryObjectRepository)(nil)
+)
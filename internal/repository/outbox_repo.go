@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"favorites/internal/models/outbox"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"slices"
+	"time"
+)
+
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Advisory lock keys of the outbox. Writers hold the write lock of a project,
+// keyed by outboxWriteLock and the project id, from taking a message id or an
+// audit event seq of the project until they commit. Both are then handed out
+// in commit order within a project, so neither the relay nor the change stream
+// skips past a change committed late, while writers to different projects do
+// not wait for each other. The relay holds outboxRelayLock while publishing.
+const (
+	outboxWriteLock = "favorite_outbox:write:"
+	outboxRelayLock = "favorite_outbox:relay"
+)
+
+// lockProjectWrites takes the write locks of the projects within tx, in a
+// fixed order so that writers to several projects cannot deadlock.
+func lockProjectWrites(ctx context.Context, tx *sqlx.Tx, projectIDs []uuid.UUID) error {
+	keys := make([]string, 0, len(projectIDs))
+	for _, projectID := range projectIDs {
+		keys = append(keys, outboxWriteLock+projectID.String())
+	}
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0));`, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messageRow carries the favorite in a form the driver can scan.
+type messageRow struct {
+	outbox.Message
+	Payload []byte `db:"payload"`
+}
+
+// enqueueMessage writes m to the outbox within tx. It should be the last
+// statement of tx since it serializes the commits of all writers to the
+// project.
+func enqueueMessage(ctx context.Context, tx *sqlx.Tx, m outbox.Message) error {
+	return enqueueMessages(ctx, tx, []outbox.Message{m})
+}
+
+// enqueueMessages writes messages to the outbox with a single insert, giving
+// them ids in the order they are passed. Like enqueueMessage it should be the
+// last statement of tx.
+func enqueueMessages(ctx context.Context, tx *sqlx.Tx, messages []outbox.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	var types, payloads pq.StringArray
+	var projectIDs, favoriteIDs []uuid.UUID
+	for _, m := range messages {
+		payload, err := json.Marshal(m.Favorite)
+		if err != nil {
+			return err
+		}
+		types = append(types, string(m.Type))
+		projectIDs = append(projectIDs, m.ProjectID)
+		favoriteIDs = append(favoriteIDs, m.FavoriteID)
+		payloads = append(payloads, string(payload))
+	}
+	if err := lockProjectWrites(ctx, tx, projectIDs); err != nil {
+		return err
+	}
+	query := `INSERT INTO favorite_outbox (type, project_id, favorite_id, payload)
+	          SELECT type, project_id, favorite_id, payload
+	          FROM UNNEST($1::TEXT[], $2::uuid[], $3::uuid[], $4::JSONB[])
+	               WITH ORDINALITY AS m (type, project_id, favorite_id, payload, n)
+	          ORDER BY n;`
+	_, err := tx.ExecContext(ctx, query, types, uuidArray(projectIDs), uuidArray(favoriteIDs), payloads)
+	return err
+}
+
+// PublishMessages holds the relay lock on a connection of its own rather than
+// in a transaction, so that no transaction stays open while publish waits on
+// the network. Messages are marked published once publish returns.
+func (r *OutboxRepository) PublishMessages(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, m outbox.Message) error,
+) (int, error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	var locked bool
+	err = conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock(hashtextextended($1, 0));`, outboxRelayLock)
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtextextended($1, 0));`, outboxRelayLock)
+
+	var rows []messageRow
+	query := `SELECT id, type, project_id, favorite_id, payload, created_at, published_at
+	          FROM favorite_outbox
+	          WHERE published_at IS NULL
+	          ORDER BY id
+	          LIMIT $1;`
+	if err = conn.SelectContext(ctx, &rows, query, limit); err != nil {
+		return 0, err
+	}
+	var ids []int64
+	var publishErr error
+	for _, row := range rows {
+		m := row.Message
+		if err = json.Unmarshal(row.Payload, &m.Favorite); err != nil {
+			publishErr = err
+			break
+		}
+		if publishErr = publish(ctx, m); publishErr != nil {
+			break
+		}
+		ids = append(ids, m.ID)
+	}
+	if len(ids) == 0 {
+		return 0, publishErr
+	}
+	// The published messages are marked even if the context was cancelled
+	// meanwhile, so that they are not published again needlessly.
+	query = `UPDATE favorite_outbox SET published_at = NOW() WHERE id = ANY($1);`
+	if _, err = conn.ExecContext(context.WithoutCancel(ctx), query, pq.Int64Array(ids)); err != nil {
+		return 0, err
+	}
+	return len(ids), publishErr
+}
+
+func (r *OutboxRepository) DeletePublishedMessages(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM favorite_outbox WHERE published_at < $1;`, publishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"favorites/internal/models/outbox"
+	"time"
+)
+
+// OutboxStore hands the messages FavoriteStore writes along with every change
+// to a relay. Only one relay publishes at a time, so the messages of a project
+// leave in order.
+type OutboxStore interface {
+	// PublishMessages passes up to limit unpublished messages to publish in
+	// order, stops at the first one publish fails on and marks the ones
+	// before it as published. It returns how many were published, which is
+	// zero while another relay is publishing.
+	PublishMessages(
+		ctx context.Context,
+		limit int,
+		publish func(ctx context.Context, m outbox.Message) error,
+	) (int, error)
+	// DeletePublishedMessages removes messages published before
+	// publishedBefore and returns how many it removed.
+	DeletePublishedMessages(ctx context.Context, publishedBefore time.Time) (int64, error)
+}
+
+var (
+	_ OutboxStore = (*OutboxRepository)(nil)
+	_ OutboxStore = (*MemoryOutboxRepository)(nil)
+)
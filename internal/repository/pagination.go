@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"favorites/internal/models/favorite"
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// Cursor is the full keyset sort key of a row bounding a page. Carrying the
+// id as a tiebreaker keeps pagination stable for rows sharing the other sort
+// columns and lets a page be resumed even after the row it points to was
+// deleted. Only the fields used by the listing's Sort are significant.
+type Cursor struct {
+	CreatedAt  time.Time           `json:"created_at"`
+	ID         uuid.UUID           `json:"id"`
+	ObjectType favorite.ObjectType `json:"object_type,omitempty"`
+	Pinned     bool                `json:"pinned,omitempty"`
+	Position   string              `json:"position,omitempty"`
+	// Seq is the position in the audit log a change token stands for.
+	Seq int64 `json:"seq,omitempty"`
+}
+
+func CursorOf(f favorite.Favorite) *Cursor {
+	return &Cursor{
+		CreatedAt:  f.CreatedAt,
+		ID:         f.ID,
+		ObjectType: f.ObjectType,
+		Pinned:     f.Pinned,
+		Position:   f.Position,
+	}
+}
+
+// Sort is the order of a listing.
+type Sort string
+
+const (
+	SortNewest     Sort = "newest"
+	SortOldest     Sort = "oldest"
+	SortObjectType Sort = "object_type"
+	// SortManual is the owner's own order: pinned favorites first, then by
+	// position. It only makes sense for listings of a single owner.
+	SortManual Sort = "manual"
+)
+
+func IsValidSort(sort string) bool {
+	switch Sort(sort) {
+	case SortNewest, SortOldest, SortObjectType, SortManual:
+		return true
+	default:
+		return false
+	}
+}
+
+// PageRequest selects a page of at most Limit rows in Sort order, newest
+// first by default. After walks forward from a cursor and Before backwards;
+// at most one of them may be set.
+type PageRequest struct {
+	Limit  uint64
+	Sort   Sort
+	After  *Cursor
+	Before *Cursor
+}
+
+// Page is a slice of favorites in the requested order. Next is the After
+// cursor of the following page and Prev is the Before cursor of the
+// preceding page; each is nil when there is nothing in that direction.
+type Page struct {
+	Favorites []favorite.Favorite
+	Next      *Cursor
+	Prev      *Cursor
+}
+
+func (p Page) HasNext() bool {
+	return p.Next != nil
+}
+
+func (p Page) HasPrev() bool {
+	return p.Prev != nil
+}
+
+// FavoriteFilter narrows an owner's favorites. Zero values match everything.
+type FavoriteFilter struct {
+	ObjectTypes   []favorite.ObjectType
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Tags keeps only favorites carrying every one of the tags.
+	Tags []string
+}
+
+func (f FavoriteFilter) Matches(fav favorite.Favorite) bool {
+	if len(f.ObjectTypes) > 0 && !slices.Contains(f.ObjectTypes, fav.ObjectType) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !fav.CreatedAt.After(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !fav.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(fav.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
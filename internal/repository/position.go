@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"favorites/internal/models/favorite"
+	"favorites/internal/rank"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidMove is returned when the anchors of a Move contradict each other
+// or the requested pinned state.
+var ErrInvalidMove = errors.New("invalid move")
+
+// Move places a favorite in its owner's manual order. After and Before name
+// the favorites it should directly follow and precede; either one is enough.
+// Without anchors the favorite goes to the top of its section, the pinned
+// favorites or the others, and Pinned moves it to the top of the other one.
+type Move struct {
+	After  *uuid.UUID
+	Before *uuid.UUID
+	Pinned *bool
+}
+
+// movedPinned returns the section f ends up in. A favorite placed next to
+// anchors joins their section.
+func movedPinned(f favorite.Favorite, after, before *favorite.Favorite, move Move) (bool, error) {
+	pinned := f.Pinned
+	if move.Pinned != nil {
+		pinned = *move.Pinned
+	}
+	for _, anchor := range []*favorite.Favorite{after, before} {
+		if anchor == nil {
+			continue
+		}
+		if anchor.OwnerType != f.OwnerType || anchor.OwnerID != f.OwnerID {
+			return false, ErrOwnerMismatch
+		}
+		if anchor.ID == f.ID || (move.Pinned != nil && anchor.Pinned != *move.Pinned) {
+			return false, ErrInvalidMove
+		}
+		pinned = anchor.Pinned
+	}
+	if after != nil && before != nil && after.Pinned != before.Pinned {
+		return false, ErrInvalidMove
+	}
+	return pinned, nil
+}
+
+// positionBetween ranks a favorite between the positions of its new
+// neighbours, either of which may be empty at an end of the section.
+func positionBetween(lower, upper string) (string, error) {
+	position, err := rank.Between(lower, upper)
+	if errors.Is(err, rank.ErrUnordered) {
+		return "", ErrInvalidMove
+	}
+	return position, err
+}
+
+// topPositions ranks new favorites at the top of their owners' unpinned
+// favorites, each above the ones before it as if they were created one by
+// one. top returns the first unpinned position of the owner of a favorite.
+func topPositions(fs []favorite.Favorite, top func(f favorite.Favorite) (string, error)) ([]string, error) {
+	type owner struct {
+		projectID uuid.UUID
+		ownerType favorite.OwnerType
+		ownerID   uuid.UUID
+	}
+	var owners []owner
+	indexes := make(map[owner][]int)
+	for i, f := range fs {
+		o := owner{f.ProjectID, f.OwnerType, f.OwnerID}
+		if _, ok := indexes[o]; !ok {
+			owners = append(owners, o)
+		}
+		indexes[o] = append(indexes[o], i)
+	}
+	positions := make([]string, len(fs))
+	for _, o := range owners {
+		edge, err := top(fs[indexes[o][0]])
+		if err != nil {
+			return nil, err
+		}
+		ranks, err := rank.Spread("", edge, len(indexes[o]))
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range indexes[o] {
+			positions[i] = ranks[len(ranks)-1-j]
+		}
+	}
+	return positions, nil
+}
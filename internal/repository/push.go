@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"favorites/internal/models/favorite"
+	"time"
+)
+
+// PushOutcome tells what became of a pushed change.
+type PushOutcome string
+
+const (
+	PushApplied   PushOutcome = "applied"
+	PushUnchanged PushOutcome = "unchanged"
+	PushConflict  PushOutcome = "conflict"
+)
+
+// PushedChange is a change a client made offline at ChangedAt to the owner's
+// favorite of an object: Favorite is created, or deleted if Deleted is set.
+// The last writer wins, so the change is a conflict if the favorite changed
+// after ChangedAt.
+type PushedChange struct {
+	Favorite  favorite.Favorite
+	Deleted   bool
+	ChangedAt time.Time
+	// Previous is the result of a change to the same favorite pushed earlier
+	// in the same batch. The audit log dates that change by when it was
+	// applied, so while it is the last change it is judged by its ChangedAt.
+	Previous *PushResult
+}
+
+// PushResult is the outcome of a PushedChange.
+type PushResult struct {
+	Outcome PushOutcome
+	// Favorite is the owner's favorite of the object after the push, if the
+	// owner has one.
+	Favorite *favorite.Favorite
+	// Seq is the seq of the last event of the favorite after the push and
+	// ChangedAt the time of the change as given.
+	Seq       int64
+	ChangedAt time.Time
+}
+
+// lastChangedAt returns when the favorite was last changed given the seq and
+// creation time of its last event.
+func (c PushedChange) lastChangedAt(seq int64, createdAt time.Time) time.Time {
+	if c.Previous != nil && c.Previous.Seq == seq {
+		return c.Previous.ChangedAt
+	}
+	return createdAt
+}
@@ -0,0 +1,56 @@
+package repository
+
+import "github.com/jmoiron/sqlx"
+
+// Storage bundles the stores backing the API so the HTTP layer can run on
+// either PostgreSQL or process memory.
+type Storage struct {
+	Favorites   FavoriteStore
+	Idempotency IdempotencyStore
+	APIKeys     APIKeyStore
+	// GroupMembers is the local membership source. The HTTP layer resolves
+	// groups through the MembershipSource interface only.
+	GroupMembers GroupMemberStore
+	Collections  CollectionStore
+	Audit        AuditStore
+	// Feed announces new audit events, which the change stream follows.
+	Feed     EventFeed
+	Outbox   OutboxStore
+	Webhooks WebhookStore
+	// Objects remembers the deleted and restored events of objects.
+	Objects ObjectStore
+}
+
+// NewPostgresStorage returns the stores over db. dbURL is dialed by the
+// listeners of the event feed, which need connections of their own.
+func NewPostgresStorage(db *sqlx.DB, dbURL string) Storage {
+	return Storage{
+		Favorites:    NewFavoriteRepository(db),
+		Idempotency:  NewIdempotencyRepository(db),
+		APIKeys:      NewAPIKeyRepository(db),
+		GroupMembers: NewGroupMemberRepository(db),
+		Collections:  NewCollectionRepository(db),
+		Audit:        NewAuditRepository(db),
+		Feed:         NewEventListener(dbURL),
+		Outbox:       NewOutboxRepository(db),
+		Webhooks:     NewWebhookRepository(db),
+		Objects:      NewObjectRepository(db),
+	}
+}
+
+func NewMemoryStorage() Storage {
+	favorites := NewMemoryFavoriteRepository()
+	audit := NewMemoryAuditRepository(favorites)
+	return Storage{
+		Favorites:    favorites,
+		Idempotency:  NewMemoryIdempotencyRepository(),
+		APIKeys:      NewMemoryAPIKeyRepository(),
+		GroupMembers: NewMemoryGroupMemberRepository(),
+		Collections:  NewMemoryCollectionRepository(favorites),
+		Audit:        audit,
+		Feed:         audit,
+		Outbox:       NewMemoryOutboxRepository(favorites),
+		Webhooks:     NewMemoryWebhookRepository(),
+		Objects:      NewMemoryObjectRepository(),
+	}
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"slices"
+)
+
+// FavoriteUpdate edits the annotations of a favorite. Nil fields are left as
+// they are, and Tags replaces the whole tag set.
+type FavoriteUpdate struct {
+	Note *string
+	Tags *[]string
+}
+
+// sortedTags returns tags sorted and without duplicates, and an empty rather
+// than a nil slice so that favorites without tags encode as [].
+func sortedTags(tags []string) []string {
+	sorted := slices.Clone(tags)
+	if sorted == nil {
+		sorted = []string{}
+	}
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+)
+
+// withTx runs fn in a transaction that is committed if fn succeeds and rolled
+// back otherwise.
+func withTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"favorites/internal/models/webhook"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const deliveryColumns = `id, project_id, subscription_id, message_id, event, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, delivered_at, created_at`
+
+// subscriptionRow carries the filters in a form the driver can scan.
+type subscriptionRow struct {
+	webhook.Subscription
+	Events      pq.StringArray `db:"events"`
+	ObjectTypes pq.StringArray `db:"object_types"`
+}
+
+func (row subscriptionRow) subscription() webhook.Subscription {
+	s := row.Subscription
+	s.Events = make([]outbox.Type, len(row.Events))
+	for i, event := range row.Events {
+		s.Events[i] = outbox.Type(event)
+	}
+	s.ObjectTypes = make([]favorite.ObjectType, len(row.ObjectTypes))
+	for i, objectType := range row.ObjectTypes {
+		s.ObjectTypes[i] = favorite.ObjectType(objectType)
+	}
+	return s
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, s *webhook.Subscription) error {
+	events := make(pq.StringArray, len(s.Events))
+	for i, event := range s.Events {
+		events[i] = string(event)
+	}
+	objectTypes := make(pq.StringArray, len(s.ObjectTypes))
+	for i, objectType := range s.ObjectTypes {
+		objectTypes[i] = string(objectType)
+	}
+	query := `INSERT INTO webhook_subscriptions (project_id, url, secret, events, object_types)
+	          VALUES ($1, $2, $3, $4, $5)
+	          RETURNING id, created_at;`
+	return r.db.QueryRowxContext(ctx, query, s.ProjectID, s.URL, s.Secret, events, objectTypes).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (r *WebhookRepository) GetSubscriptions(ctx context.Context, projectID uuid.UUID) ([]webhook.Subscription, error) {
+	var rows []subscriptionRow
+	query := `SELECT id, project_id, url, secret, events, object_types, created_at
+	          FROM webhook_subscriptions
+	          WHERE project_id = $1
+	          ORDER BY created_at, id;`
+	if err := r.db.SelectContext(ctx, &rows, query, projectID); err != nil {
+		return nil, err
+	}
+	subscriptions := make([]webhook.Subscription, len(rows))
+	for i, row := range rows {
+		subscriptions[i] = row.subscription()
+	}
+	return subscriptions, nil
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (webhook.Subscription, error) {
+	var row subscriptionRow
+	query := `SELECT id, project_id, url, secret, events, object_types, created_at
+	          FROM webhook_subscriptions
+	          WHERE id = $1
+	            AND project_id = $2;`
+	err := r.db.GetContext(ctx, &row, query, id, projectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhook.Subscription{}, ErrNotFound
+	}
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+	return row.subscription(), nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1 AND project_id = $2;`, id, projectID)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, m outbox.Message) (int, error) {
+	subscriptions, err := r.GetSubscriptions(ctx, m.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	query := `INSERT INTO webhook_deliveries (project_id, subscription_id, message_id, event, payload)
+	          VALUES ($1, $2, $3, $4, $5)
+	          ON CONFLICT (subscription_id, message_id) DO NOTHING;`
+	for _, s := range subscriptions {
+		if !s.Matches(m) {
+			continue
+		}
+		result, err := r.db.ExecContext(ctx, query, m.ProjectID, s.ID, m.ID, m.Type, string(payload))
+		if err != nil {
+			return created, err
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return created, err
+		}
+		created += int(inserted)
+	}
+	return created, nil
+}
+
+func (r *WebhookRepository) ClaimDueDeliveries(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+	lease time.Duration,
+) ([]ClaimedDelivery, error) {
+	var claimed []ClaimedDelivery
+	query := `UPDATE webhook_deliveries d
+	          SET next_attempt_at = $2
+	          FROM webhook_subscriptions s
+	          WHERE s.id = d.subscription_id
+	            AND d.id IN (SELECT id
+	                         FROM webhook_deliveries
+	                         WHERE status = 'PENDING'
+	                           AND next_attempt_at <= $1
+	                         ORDER BY next_attempt_at, id
+	                         LIMIT $3 FOR UPDATE SKIP LOCKED)
+	          RETURNING d.id, d.project_id, d.subscription_id, d.message_id, d.event, d.payload, d.status, d.attempts,
+	                    d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at,
+	                    s.url, s.secret;`
+	err := r.db.SelectContext(ctx, &claimed, query, now, now.Add(lease), limit)
+	return claimed, err
+}
+
+func (r *WebhookRepository) SaveDeliveryAttempt(ctx context.Context, d webhook.Delivery) error {
+	query := `UPDATE webhook_deliveries
+	          SET status           = $2,
+	              attempts         = $3,
+	              next_attempt_at  = $4,
+	              last_status_code = $5,
+	              last_error       = $6,
+	              delivered_at     = $7
+	          WHERE id = $1;`
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		d.ID,
+		d.Status,
+		d.Attempts,
+		d.NextAttemptAt,
+		d.LastStatusCode,
+		d.LastError,
+		d.DeliveredAt,
+	)
+	return err
+}
+
+func (r *WebhookRepository) GetDeliveries(
+	ctx context.Context,
+	projectID uuid.UUID,
+	subscriptionID uuid.UUID,
+	limit int,
+) ([]webhook.Delivery, error) {
+	deliveries := []webhook.Delivery{}
+	query := `SELECT ` + deliveryColumns + `
+	          FROM webhook_deliveries
+	          WHERE subscription_id = $1
+	            AND project_id = $2
+	          ORDER BY created_at DESC, id DESC
+	          LIMIT $3;`
+	err := r.db.SelectContext(ctx, &deliveries, query, subscriptionID, projectID, limit)
+	return deliveries, err
+}
+
+func (r *WebhookRepository) RedeliverDelivery(
+	ctx context.Context,
+	projectID uuid.UUID,
+	subscriptionID uuid.UUID,
+	id uuid.UUID,
+	now time.Time,
+) (webhook.Delivery, error) {
+	var d webhook.Delivery
+	err := withTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		query := `SELECT ` + deliveryColumns + `
+		          FROM webhook_deliveries
+		          WHERE id = $1
+		            AND subscription_id = $2
+		            AND project_id = $3
+		          FOR UPDATE;`
+		err := tx.GetContext(ctx, &d, query, id, subscriptionID, projectID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if d.Status == webhook.StatusDelivered {
+			return ErrAlreadyDelivered
+		}
+		query = `UPDATE webhook_deliveries
+		         SET status          = $2,
+		             attempts        = 0,
+		             next_attempt_at = $3
+		         WHERE id = $1
+		         RETURNING ` + deliveryColumns + `;`
+		return tx.GetContext(ctx, &d, query, id, webhook.StatusPending, now)
+	})
+	return d, err
+}
+
+func (r *WebhookRepository) DeleteFinishedDeliveries(ctx context.Context, createdBefore time.Time) (int64, error) {
+	query := `DELETE FROM webhook_deliveries
+	          WHERE status IN ($1, $2)
+	            AND created_at < $3;`
+	result, err := r.db.ExecContext(ctx, query, webhook.StatusDelivered, webhook.StatusDead, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
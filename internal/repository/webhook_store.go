@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"favorites/internal/models/outbox"
+	"favorites/internal/models/webhook"
+	"github.com/google/uuid"
+	"time"
+)
+
+// ErrAlreadyDelivered is returned when a delivery that succeeded is asked to
+// be redelivered.
+var ErrAlreadyDelivered = errors.New("already delivered")
+
+// ClaimedDelivery is a delivery due for an attempt together with where it
+// goes.
+type ClaimedDelivery struct {
+	webhook.Delivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// WebhookStore keeps webhook subscriptions and their deliveries. Like the
+// other stores it always takes the project it acts on, except for the
+// methods used by the dispatcher, which works through all projects.
+type WebhookStore interface {
+	// CreateSubscription stores s, filling in its id and creation time.
+	CreateSubscription(ctx context.Context, s *webhook.Subscription) error
+	// GetSubscriptions returns all subscriptions of the project, oldest first.
+	GetSubscriptions(ctx context.Context, projectID uuid.UUID) ([]webhook.Subscription, error)
+	// GetSubscription returns the subscription of the project with the given
+	// id and ErrNotFound if there is none.
+	GetSubscription(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (webhook.Subscription, error)
+	// DeleteSubscription removes the subscription with its deliveries and
+	// returns ErrNotFound if there is none.
+	DeleteSubscription(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error
+	// EnqueueDeliveries creates a pending delivery of m for every subscription
+	// of its project that matches it, and returns how many it created. A
+	// message is only delivered once to a subscription however often it is
+	// enqueued.
+	EnqueueDeliveries(ctx context.Context, m outbox.Message) (int, error)
+	// ClaimDueDeliveries returns up to limit pending deliveries due at now and
+	// postpones them by lease, so that no other dispatcher attempts them
+	// while this one does.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ClaimedDelivery, error)
+	// SaveDeliveryAttempt stores the outcome of an attempt of d: its status,
+	// attempts, next attempt, last failure and delivery time.
+	SaveDeliveryAttempt(ctx context.Context, d webhook.Delivery) error
+	// GetDeliveries returns up to limit deliveries to the subscription,
+	// newest first.
+	GetDeliveries(ctx context.Context, projectID uuid.UUID, subscriptionID uuid.UUID, limit int) ([]webhook.Delivery, error)
+	// RedeliverDelivery makes a delivery that has not succeeded pending again
+	// with no attempts made, due at now. It returns ErrNotFound if there is
+	// no such delivery and ErrAlreadyDelivered if it succeeded.
+	RedeliverDelivery(
+		ctx context.Context,
+		projectID uuid.UUID,
+		subscriptionID uuid.UUID,
+		id uuid.UUID,
+		now time.Time,
+	) (webhook.Delivery, error)
+	// DeleteFinishedDeliveries removes delivered and dead deliveries created
+	// before createdBefore and returns how many it removed.
+	DeleteFinishedDeliveries(ctx context.Context, createdBefore time.Time) (int64, error)
+}
+
+var (
+	_ WebhookStore = (*WebhookRepository)(nil)
+	_ WebhookStore = (*MemoryWebhookRepository)(nil)
+)
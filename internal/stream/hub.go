@@ -0,0 +1,112 @@
+// Package stream wakes up the clients following the changes to favorites of
+// an owner. A single Hub per process listens to the EventFeed and passes its
+// notices on to the subscribers of the owner they are about.
+package stream
+
+import (
+	"context"
+	"favorites/internal/models/favorite"
+	"favorites/internal/repository"
+	"github.com/google/uuid"
+	"log"
+	"sync"
+	"time"
+)
+
+// relistenDelay is the wait before listening again after the feed failed.
+const relistenDelay = time.Second
+
+type ownerKey struct {
+	projectID uuid.UUID
+	ownerType favorite.OwnerType
+	ownerID   uuid.UUID
+}
+
+// Subscription is woken up whenever the owner it follows may have new events.
+type Subscription struct {
+	owner ownerKey
+	wake  chan struct{}
+}
+
+// Wake receives when new events may be waiting. Wake-ups do not queue up:
+// one wake-up stands for any number of events.
+func (s *Subscription) Wake() <-chan struct{} {
+	return s.wake
+}
+
+func (s *Subscription) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+type Hub struct {
+	feed          repository.EventFeed
+	start         sync.Once
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+}
+
+// NewHub returns a hub that starts listening to feed with its first
+// subscription and keeps listening for the life of the process.
+func NewHub(feed repository.EventFeed) *Hub {
+	return &Hub{feed: feed, subscriptions: make(map[*Subscription]struct{})}
+}
+
+// Subscribe follows the owner until Unsubscribe. Events committed before it
+// returns may or may not wake the subscription up.
+func (h *Hub) Subscribe(projectID uuid.UUID, ownerType favorite.OwnerType, ownerID uuid.UUID) *Subscription {
+	h.start.Do(func() {
+		go h.run(context.Background())
+	})
+	s := &Subscription{
+		owner: ownerKey{projectID: projectID, ownerType: ownerType, ownerID: ownerID},
+		wake:  make(chan struct{}, 1),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscriptions[s] = struct{}{}
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscriptions, s)
+}
+
+// run passes the notices of the feed on until ctx is done, listening again
+// whenever the feed fails.
+func (h *Hub) run(ctx context.Context) {
+	for {
+		notices, err := h.feed.Listen(ctx)
+		if err != nil {
+			log.Printf("Failed to listen to favorite events: %v", err)
+		} else {
+			// Notices sent before listening again are lost.
+			h.broadcast(repository.EventNotice{})
+			for notice := range notices {
+				h.broadcast(notice)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relistenDelay):
+		}
+	}
+}
+
+// broadcast wakes up the subscriptions of the owner of the notice, or all of
+// them for the zero notice.
+func (h *Hub) broadcast(notice repository.EventNotice) {
+	owner := ownerKey{projectID: notice.ProjectID, ownerType: notice.OwnerType, ownerID: notice.OwnerID}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subscriptions {
+		if notice == (repository.EventNotice{}) || s.owner == owner {
+			s.notify()
+		}
+	}
+}
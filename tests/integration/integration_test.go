@@ -3,11 +3,25 @@ package integration
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"favorites/config"
+	"favorites/internal/auth"
 	"favorites/internal/db"
+	"favorites/internal/dispatch"
 	"favorites/internal/handlers"
+	"favorites/internal/models/apikey"
+	"favorites/internal/models/audit"
 	"favorites/internal/models/favorite"
+	"favorites/internal/models/object"
+	"favorites/internal/models/outbox"
+	"favorites/internal/models/webhook"
+	"favorites/internal/objects"
+	"favorites/internal/purge"
+	"favorites/internal/repository"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -18,14 +32,37 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 var testDB *sqlx.DB
+var testDBURL string
 var router *gin.Engine
 
+// testProjectID is the project the tests insert into and query.
+var testProjectID = uuid.New()
+
+func projectURL(path string) string {
+	return "/projects/" + testProjectID.String() + path
+}
+
+const testJWTSecret = "jwt-secret"
+
+// bearer returns an Authorization header for the user ownerID.
+func bearer(ownerID uuid.UUID) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]any{"sub": ownerID, "project_id": testProjectID, "exp": time.Now().Add(time.Hour).Unix()})
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(signed))
+	return "Bearer " + signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
 func TestMain(m *testing.M) {
 	ctx := context.Background()
 	envFile, err := godotenv.Read("../../deploy/.env")
@@ -48,14 +85,14 @@ func TestMain(m *testing.M) {
 	}
 	host, _ := postgresContainer.Host(ctx)
 	mappedPort, _ := postgresContainer.MappedPort(ctx, "5432/tcp")
-	dbURL := fmt.Sprintf(
+	testDBURL = fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		envFile["DATABASE_USER"],
 		envFile["DATABASE_PASSWORD"],
 		host, mappedPort.Port(),
 		envFile["DATABASE_NAME"],
 	)
-	testDB, err = sqlx.Connect("postgres", dbURL)
+	testDB, err = sqlx.Connect("postgres", testDBURL)
 	if err != nil {
 		panic(err)
 	}
@@ -64,7 +101,9 @@ func TestMain(m *testing.M) {
 		panic(err)
 	}
 	router = gin.Default()
-	handlers.RegisterRoutes(testDB, router)
+	cfg := config.LoadConfig()
+	cfg.JWTSecret = testJWTSecret
+	handlers.RegisterRoutes(repository.NewPostgresStorage(testDB, testDBURL), cfg, router)
 	code := m.Run()
 	_ = testDB.Close()
 	_ = postgresContainer.Terminate(ctx)
@@ -72,7 +111,7 @@ func TestMain(m *testing.M) {
 }
 
 func clearDB() {
-	_, err := testDB.Exec("TRUNCATE TABLE favorites RESTART IDENTITY CASCADE")
+	_, err := testDB.Exec("TRUNCATE TABLE favorites, favorite_counts, api_keys, group_members, collections, collection_favorites, favorite_tags, favorite_events, favorite_outbox, webhook_subscriptions, webhook_deliveries, object_states RESTART IDENTITY CASCADE")
 	if err != nil {
 		panic(err)
 	}
@@ -83,21 +122,22 @@ func TestGetFavorites(t *testing.T) {
 	var id uuid.UUID
 	var ownerID uuid.UUID
 	err := testDB.QueryRowx(`
-		INSERT INTO favorites (id, project_id, owner_type, owner_id, object_id, object_type, created_at)
-		VALUES (gen_random_uuid(), gen_random_uuid(), 'USER', gen_random_uuid(), gen_random_uuid(), 'IMAGE', NOW())
+		INSERT INTO favorites (id, project_id, owner_type, owner_id, object_id, object_type, created_at, position)
+		VALUES (gen_random_uuid(), $1, 'USER', gen_random_uuid(), gen_random_uuid(), 'IMAGE', NOW(), 'i')
 		RETURNING id, owner_id;
-	`).Scan(&id, &ownerID)
+	`, testProjectID).Scan(&id, &ownerID)
 	if err != nil {
 		t.Fatalf("Failed to insert test data: %v", err)
 		return
 	}
-	req := httptest.NewRequest(http.MethodGet, "/favorites", nil)
+	req := httptest.NewRequest(http.MethodGet, projectURL("/favorites"), nil)
 	urlQuery := req.URL.Query()
 	urlQuery.Add("owner_type", "USER")
 	urlQuery.Add("owner_id", ownerID.String())
 	urlQuery.Add("limit", "25")
 	urlQuery.Add("cursor", "")
 	req.URL.RawQuery = urlQuery.Encode()
+	req.Header.Set("Authorization", bearer(ownerID))
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	if w.Code != http.StatusOK {
@@ -105,22 +145,8 @@ func TestGetFavorites(t *testing.T) {
 		t.Errorf("Error message: %s", w.Body)
 		return
 	}
-	cursorBase64 := w.Header()["X-Next-Cursor"][0]
-	if cursorBase64 == "" {
-		t.Errorf("Error message: %s", "cursor is nil")
-		return
-	}
-	decodedCursor, err := base64.URLEncoding.DecodeString(cursorBase64)
-	if err != nil {
-		t.Errorf("Error message: %s", w.Body)
-		return
-	}
-	cursorID, err := uuid.Parse(string(decodedCursor))
-	if err != nil {
-		t.Errorf("Error message: %s", w.Body)
-		return
-	} else if id != cursorID {
-		t.Errorf("Error message: %s", "ids are not equal")
+	if w.Header().Get("X-Next-Cursor") != "" || w.Header().Get("X-Has-More") != "false" {
+		t.Errorf("Error message: %s", "expected the only page to be the last one")
 		return
 	}
 	var favorites []favorite.Favorite
@@ -131,21 +157,120 @@ func TestGetFavorites(t *testing.T) {
 	}
 	if len(favorites) != 1 {
 		t.Errorf("Expected 1 favorite, got %d", len(favorites))
+	} else if favorites[0].ID != id {
+		t.Errorf("Error message: %s", "ids are not equal")
+	}
+}
+
+func TestGetFavoritesKeysetPagination(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	_, err := testDB.Exec(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, created_at, position)
+		SELECT $1, 'USER', $2, gen_random_uuid(), 'IMAGE', '2024-01-01 00:00:00', 'i'
+		FROM generate_series(1, 5);
+	`, testProjectID, ownerID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	seen := make(map[uuid.UUID]bool)
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		req := httptest.NewRequest(http.MethodGet, projectURL("/favorites"), nil)
+		urlQuery := req.URL.Query()
+		urlQuery.Add("owner_type", "USER")
+		urlQuery.Add("owner_id", ownerID.String())
+		urlQuery.Add("limit", "2")
+		urlQuery.Add("after", cursor)
+		req.URL.RawQuery = urlQuery.Encode()
+		req.Header.Set("Authorization", bearer(ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err = json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, f := range favorites {
+			if seen[f.ID] {
+				t.Errorf("Favorite %s returned twice", f.ID)
+			}
+			seen[f.ID] = true
+		}
+		// Deleting the row the cursor points to must not break the next page.
+		_, err = testDB.Exec("DELETE FROM favorites WHERE id = $1", favorites[len(favorites)-1].ID)
+		if err != nil {
+			t.Fatalf("Failed to delete cursor row: %v", err)
+		}
+		cursor = w.Header().Get("X-Next-Cursor")
+		if cursor == "" {
+			break
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected 5 favorites across pages, got %d", len(seen))
+	}
+}
+
+func TestGetFavoritesSortedByObjectType(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	_, err := testDB.Exec(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, created_at, position)
+		SELECT $1, 'USER', $2, gen_random_uuid(), (ARRAY ['VIDEO', 'IMAGE', 'DOCUMENT'])[i % 3 + 1], NOW(), 'i'
+		FROM generate_series(1, 5) AS i;
+	`, testProjectID, ownerID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	var objectTypes []favorite.ObjectType
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		req := httptest.NewRequest(http.MethodGet, projectURL("/favorites"), nil)
+		urlQuery := req.URL.Query()
+		urlQuery.Add("owner_type", "USER")
+		urlQuery.Add("owner_id", ownerID.String())
+		urlQuery.Add("limit", "2")
+		urlQuery.Add("sort", "object_type")
+		urlQuery.Add("after", cursor)
+		req.URL.RawQuery = urlQuery.Encode()
+		req.Header.Set("Authorization", bearer(ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err = json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, f := range favorites {
+			objectTypes = append(objectTypes, f.ObjectType)
+		}
+		cursor = w.Header().Get("X-Next-Cursor")
+		if cursor == "" {
+			break
+		}
+	}
+	if len(objectTypes) != 5 || !slices.IsSorted(objectTypes) {
+		t.Errorf("Expected 5 favorites sorted by object type, got %v", objectTypes)
 	}
 }
 
 func TestCreateFavorite(t *testing.T) {
 	clearDB()
 	requestBody := map[string]any{
-		"project_id":  uuid.New(),
 		"owner_type":  "USER",
 		"owner_id":    uuid.New(),
 		"object_id":   uuid.New(),
 		"object_type": "IMAGE",
 	}
 	body, _ := json.Marshal(requestBody)
-	req := httptest.NewRequest(http.MethodPost, "/favorites", bytes.NewReader(body))
+	req := httptest.NewRequest(http.MethodPost, projectURL("/favorites"), bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(requestBody["owner_id"].(uuid.UUID)))
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	if w.Code != http.StatusCreated {
@@ -163,30 +288,923 @@ func TestCreateFavorite(t *testing.T) {
 	}
 }
 
+func TestCreateFavoriteDuplicate(t *testing.T) {
+	clearDB()
+	requestBody := map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    uuid.New(),
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+	}
+	body, _ := json.Marshal(requestBody)
+	expected := []int{http.StatusCreated, http.StatusOK}
+	for _, code := range expected {
+		req := httptest.NewRequest(http.MethodPost, projectURL("/favorites"), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", bearer(requestBody["owner_id"].(uuid.UUID)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != code {
+			t.Errorf("Expected status %d, got %d", code, w.Code)
+			t.Errorf("Error message: %s", w.Body)
+			return
+		}
+	}
+	var count int
+	err := testDB.Get(&count, "SELECT COUNT(*) FROM favorites")
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 favorite in database, got %d", count)
+	}
+}
+
+func TestRepairFavoriteCounts(t *testing.T) {
+	clearDB()
+	_, err := testDB.Exec(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, position)
+		SELECT '00000000-0000-0000-0000-000000000001', 'USER', gen_random_uuid(),
+		       '00000000-0000-0000-0000-000000000002', 'IMAGE', 'i'
+		FROM generate_series(1, 3);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	drifted, err := repository.NewFavoriteRepository(testDB).RepairFavoriteCounts(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to repair counts: %v", err)
+	}
+	if drifted != 1 {
+		t.Errorf("Expected 1 drifted counter, got %d", drifted)
+	}
+	var count int
+	err = testDB.Get(&count, "SELECT count FROM favorite_counts WHERE object_id = '00000000-0000-0000-0000-000000000002'")
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected counter 3, got %d", count)
+	}
+}
+
 func TestDeleteFavorite(t *testing.T) {
 	clearDB()
-	var favoriteID uuid.UUID
+	var favoriteID, ownerID uuid.UUID
 	err := testDB.QueryRowx(`
-		INSERT INTO favorites (id, project_id, owner_type, owner_id, object_id, object_type, created_at)
+		INSERT INTO favorites (id, project_id, owner_type, owner_id, object_id, object_type, created_at, position)
 		VALUES
-			(gen_random_uuid(), gen_random_uuid(), 'USER', gen_random_uuid(), gen_random_uuid(), 'IMAGE', NOW())
-		RETURNING id
-	`).Scan(&favoriteID)
+			(gen_random_uuid(), $1, 'USER', gen_random_uuid(), gen_random_uuid(), 'IMAGE', NOW(), 'i')
+		RETURNING id, owner_id
+	`, testProjectID).Scan(&favoriteID, &ownerID)
 	if err != nil {
 		t.Fatalf("Failed to insert test data: %v", err)
 	}
-	req := httptest.NewRequest(http.MethodDelete, "/favorites/"+favoriteID.String(), nil)
+	req := httptest.NewRequest(http.MethodDelete, projectURL("/favorites/")+favoriteID.String(), nil)
+	req.Header.Set("Authorization", bearer(ownerID))
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	if w.Code != http.StatusNoContent {
 		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
 	}
 	var count int
-	err = testDB.Get(&count, "SELECT COUNT(*) FROM favorites")
+	err = testDB.Get(&count, "SELECT COUNT(*) FROM favorites WHERE deleted_at IS NULL")
 	if err != nil {
 		t.Fatalf("Failed to query database: %v", err)
 	}
 	if count != 0 {
-		t.Errorf("Expected 0 favorites in database, got %d", count)
+		t.Errorf("Expected 0 live favorites in database, got %d", count)
+	}
+	err = testDB.Get(&count, "SELECT COUNT(*) FROM favorites WHERE deleted_at IS NOT NULL")
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected the favorite to be kept as soft-deleted, got %d", count)
+	}
+}
+
+func TestDeleteFavoriteOfAnotherProject(t *testing.T) {
+	clearDB()
+	var favoriteID, ownerID uuid.UUID
+	err := testDB.QueryRowx(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, position)
+		VALUES (gen_random_uuid(), 'USER', gen_random_uuid(), gen_random_uuid(), 'IMAGE', 'i')
+		RETURNING id, owner_id
+	`).Scan(&favoriteID, &ownerID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodDelete, projectURL("/favorites/")+favoriteID.String(), nil)
+	req.Header.Set("Authorization", bearer(ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	var count int
+	err = testDB.Get(&count, "SELECT COUNT(*) FROM favorites WHERE deleted_at IS NULL")
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected the favorite to survive, got %d favorites", count)
+	}
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	clearDB()
+	ctx := context.Background()
+	apiKeys := repository.NewAPIKeyRepository(testDB)
+	k, secret := auth.NewAPIKey(testProjectID, "search", []apikey.Scope{apikey.ScopeRead, apikey.ScopeWrite}, nil)
+	if err := apiKeys.CreateAPIKey(ctx, &k); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	usedAt := time.Now().UTC().Truncate(time.Microsecond)
+	if err := apiKeys.TouchAPIKey(ctx, k.ID, usedAt); err != nil {
+		t.Fatalf("Failed to touch API key: %v", err)
+	}
+	stored, err := apiKeys.GetAPIKey(ctx, k.ID)
+	if err != nil {
+		t.Fatalf("Failed to get API key: %v", err)
+	}
+	if !slices.Equal(stored.Scopes, k.Scopes) || stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(usedAt) {
+		t.Errorf("Expected scopes %v used at %v, got %+v", k.Scopes, usedAt, stored)
+	}
+	if err = auth.CheckAPIKey(stored, secret, time.Now()); err != nil {
+		t.Errorf("Expected the secret to match, got %v", err)
+	}
+	if err = apiKeys.RevokeAPIKey(ctx, uuid.New(), k.ID); err != repository.ErrNotFound {
+		t.Errorf("Expected a key of another project not to be revoked, got %v", err)
+	}
+	if err = apiKeys.RevokeAPIKey(ctx, testProjectID, k.ID); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+	stored, _ = apiKeys.GetAPIKey(ctx, k.ID)
+	if err = auth.CheckAPIKey(stored, secret, time.Now()); err != auth.ErrRevokedAPIKey {
+		t.Errorf("Expected the key to be revoked, got %v", err)
+	}
+}
+
+func TestGetEffectiveFavorites(t *testing.T) {
+	clearDB()
+	userID, groupID := uuid.New(), uuid.New()
+	sharedID := uuid.New()
+	_, err := testDB.Exec(`
+		INSERT INTO group_members (project_id, group_id, user_id) VALUES ($1, $2, $3);
+	`, testProjectID, groupID, userID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	_, err = testDB.Exec(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, created_at, position)
+		VALUES ($1, 'USER', $2, $4, 'IMAGE', NOW() - INTERVAL '1 hour', 'i'),
+		       ($1, 'GROUP', $3, $4, 'IMAGE', NOW(), 'i'),
+		       ($1, 'GROUP', $3, gen_random_uuid(), 'IMAGE', NOW(), 'i'),
+		       ($1, 'GROUP', gen_random_uuid(), gen_random_uuid(), 'IMAGE', NOW(), 'i');
+	`, testProjectID, userID, groupID, sharedID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, projectURL("/users/"+userID.String()+"/effective-favorites?limit=25"), nil)
+	req.Header.Set("Authorization", bearer(userID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var favorites []favorite.Favorite
+	if err = json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(favorites) != 2 {
+		t.Fatalf("Expected 2 effective favorites, got %d", len(favorites))
+	}
+	for _, f := range favorites {
+		if f.ObjectID == sharedID && f.OwnerType != favorite.OwnerTypeUser {
+			t.Errorf("Expected the user's own favorite for the shared object, got %+v", f)
+		}
+	}
+}
+
+func TestCollectionFavorites(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	var collectionID, inside, outside uuid.UUID
+	err := testDB.QueryRow(`
+		INSERT INTO collections (project_id, owner_type, owner_id, name) VALUES ($1, 'USER', $2, 'Travel') RETURNING id;
+	`, testProjectID, ownerID).Scan(&collectionID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	for _, id := range []*uuid.UUID{&inside, &outside} {
+		err = testDB.QueryRow(`
+			INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, position)
+			VALUES ($1, 'USER', $2, gen_random_uuid(), 'IMAGE', 'i') RETURNING id;
+		`, testProjectID, ownerID).Scan(id)
+		if err != nil {
+			t.Fatalf("Failed to insert test data: %v", err)
+		}
+	}
+	collectionPath := "/collections/" + collectionID.String() + "/favorites"
+	req := httptest.NewRequest(http.MethodPut, projectURL(collectionPath+"/"+inside.String()), nil)
+	req.Header.Set("Authorization", bearer(ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	req = httptest.NewRequest(http.MethodGet, projectURL(collectionPath+"?limit=25"), nil)
+	req.Header.Set("Authorization", bearer(ownerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var favorites []favorite.Favorite
+	if err = json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(favorites) != 1 || favorites[0].ID != inside {
+		t.Errorf("Expected only favorite %s in the collection, got %v", inside, favorites)
+	}
+	if _, err = testDB.Exec(`DELETE FROM favorites WHERE id = $1`, inside); err != nil {
+		t.Fatalf("Failed to delete favorite: %v", err)
+	}
+	var links int
+	if err = testDB.Get(&links, `SELECT COUNT(*) FROM collection_favorites WHERE collection_id = $1`, collectionID); err != nil {
+		t.Fatalf("Failed to count links: %v", err)
+	}
+	if links != 0 {
+		t.Errorf("Expected deleting the favorite to remove it from the collection, got %d links", links)
+	}
+}
+
+func TestMoveFavoriteKeepsManualOrder(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	_, err := testDB.Exec(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, position)
+		SELECT $1, 'USER', $2, gen_random_uuid(), 'IMAGE', position
+		FROM UNNEST(ARRAY ['a', 'b', 'c']) AS position;
+	`, testProjectID, ownerID)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	var ids []uuid.UUID
+	if err = testDB.Select(&ids, `SELECT id FROM favorites ORDER BY position`); err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	body, _ := json.Marshal(map[string]any{"after": ids[0], "before": ids[1]})
+	req := httptest.NewRequest(http.MethodPatch, projectURL("/favorites/"+ids[2].String()+"/position"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var order []uuid.UUID
+	cursor := ""
+	for {
+		req = httptest.NewRequest(http.MethodGet, projectURL("/favorites?sort=manual&limit=1&owner_type=USER&owner_id="+ownerID.String()+"&after="+cursor), nil)
+		req.Header.Set("Authorization", bearer(ownerID))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err = json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		order = append(order, favorites[0].ID)
+		if cursor = w.Header().Get("X-Next-Cursor"); cursor == "" {
+			break
+		}
+	}
+	expected := []uuid.UUID{ids[0], ids[2], ids[1]}
+	if !slices.Equal(order, expected) {
+		t.Errorf("Expected order %v, got %v", expected, order)
+	}
+}
+
+func TestGetFavoritesByTags(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	for _, tags := range [][]string{{"travel", "summer"}, {"travel"}, {}} {
+		body, _ := json.Marshal(map[string]any{
+			"owner_type":  "USER",
+			"owner_id":    ownerID,
+			"object_id":   uuid.New(),
+			"object_type": "IMAGE",
+			"tags":        tags,
+		})
+		req := httptest.NewRequest(http.MethodPost, projectURL("/favorites"), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", bearer(ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+		}
+	}
+	for tags, expected := range map[string]int{"tag=travel": 2, "tag=travel&tag=summer": 1} {
+		req := httptest.NewRequest(http.MethodGet, projectURL("/favorites?limit=25&owner_type=USER&owner_id="+ownerID.String()+"&"+tags), nil)
+		req.Header.Set("Authorization", bearer(ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err := json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if len(favorites) != expected {
+			t.Errorf("Expected %d favorites for %s, got %d", expected, tags, len(favorites))
+		}
+		for _, f := range favorites {
+			if !slices.Contains(f.Tags, "travel") {
+				t.Errorf("Expected favorite %s to carry its tags, got %v", f.ID, f.Tags)
+			}
+		}
+	}
+}
+
+func TestRestoreAndPurgeFavorites(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	var restorable, expired uuid.UUID
+	err := testDB.QueryRow(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, position, deleted_at)
+		VALUES ($1, 'USER', $2, gen_random_uuid(), 'IMAGE', 'i', NOW() - INTERVAL '1 minute')
+		RETURNING id;
+	`, testProjectID, ownerID).Scan(&restorable)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	err = testDB.QueryRow(`
+		INSERT INTO favorites (project_id, owner_type, owner_id, object_id, object_type, position, deleted_at)
+		VALUES ($1, 'USER', $2, gen_random_uuid(), 'IMAGE', 'r', NOW() - INTERVAL '90 days')
+		RETURNING id;
+	`, testProjectID, ownerID).Scan(&expired)
+	if err != nil {
+		t.Fatalf("Failed to insert test data: %v", err)
+	}
+	for id, expected := range map[uuid.UUID]int{restorable: http.StatusOK, expired: http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodPost, projectURL("/favorites/"+id.String()+"/restore"), nil)
+		req.Header.Set("Authorization", bearer(ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("Expected status %d restoring %s, got %d: %s", expected, id, w.Code, w.Body)
+		}
+	}
+	purged, err := purge.NewPurger(repository.NewFavoriteRepository(testDB), 30*24*time.Hour, time.Hour).
+		PurgeOnce(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged favorite, got %d", purged)
+	}
+	var ids []uuid.UUID
+	if err = testDB.Select(&ids, `SELECT id FROM favorites`); err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != restorable {
+		t.Errorf("Expected only the restored favorite %s to remain, got %v", restorable, ids)
+	}
+}
+
+func TestAuditLogRecordsChanges(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	body, _ := json.Marshal(map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+		"tags":        []string{"work"},
+	})
+	req := httptest.NewRequest(http.MethodPost, projectURL("/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(ownerID))
+	req.Header.Set("X-Request-ID", "audit-create")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var created favorite.Favorite
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	req = httptest.NewRequest(http.MethodDelete, projectURL("/favorites/")+created.ID.String(), nil)
+	req.Header.Set("Authorization", bearer(ownerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body)
+	}
+	page, err := repository.NewAuditRepository(testDB).GetPageOfEvents(
+		context.Background(),
+		testProjectID,
+		repository.AuditFilter{ObjectType: created.ObjectType, ObjectID: created.ObjectID},
+		repository.PageRequest{Limit: 10},
+	)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(page.Events) != 2 || page.Events[0].Action != audit.ActionDelete || page.Events[1].Action != audit.ActionCreate {
+		t.Fatalf("Expected a deletion after a creation, got %+v", page.Events)
+	}
+	create, deletion := page.Events[1], page.Events[0]
+	if create.ActorID != ownerID || create.RequestID != "audit-create" || create.Before != nil {
+		t.Errorf("Expected the creation by the owner in its request, got %+v", create)
+	}
+	if create.After == nil || !slices.Equal(create.After.Tags, []string{"work"}) {
+		t.Errorf("Expected the creation snapshot to carry the tags, got %+v", create.After)
+	}
+	if deletion.Before == nil || deletion.Before.DeletedAt != nil || deletion.After == nil || deletion.After.DeletedAt == nil {
+		t.Errorf("Expected the deletion to snapshot the favorite before and after, got %+v", deletion)
+	}
+	if _, err = testDB.Exec(`UPDATE favorite_events SET request_id = 'forged'`); err == nil {
+		t.Errorf("Expected audit events to be append-only")
+	}
+	if _, err = testDB.Exec(`DELETE FROM favorite_events`); err == nil {
+		t.Errorf("Expected audit events to be append-only")
+	}
+	if _, err = testDB.Exec(`UPDATE favorite_events SET after = after || '{"note": "forged"}'`); err == nil {
+		t.Errorf("Expected snapshots to change only by redaction")
+	}
+	redact := `UPDATE favorite_events SET before = redact_favorite_snapshot(before), after = redact_favorite_snapshot(after)`
+	if _, err = testDB.Exec(redact); err != nil {
+		t.Errorf("Expected snapshots to be redactable, got %v", err)
+	}
+}
+
+func TestOutboxRelaysCommittedChanges(t *testing.T) {
+	clearDB()
+	ownerID := uuid.New()
+	body, _ := json.Marshal(map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   uuid.New(),
+		"object_type": "VIDEO",
+	})
+	req := httptest.NewRequest(http.MethodPost, projectURL("/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var created favorite.Favorite
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	req = httptest.NewRequest(http.MethodDelete, projectURL("/favorites/")+created.ID.String(), nil)
+	req.Header.Set("Authorization", bearer(ownerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body)
+	}
+	messages := repository.NewOutboxRepository(testDB)
+	var types []outbox.Type
+	publish := func(_ context.Context, m outbox.Message) error {
+		if m.FavoriteID != created.ID || m.Favorite.ObjectID != created.ObjectID {
+			t.Errorf("Expected messages about favorite %s, got %+v", created.ID, m)
+		}
+		types = append(types, m.Type)
+		var open int
+		query := `SELECT COUNT(*) FROM pg_stat_activity
+		          WHERE datname = current_database() AND xact_start IS NOT NULL AND pid <> pg_backend_pid()`
+		if err := testDB.Get(&open, query); err != nil || open != 0 {
+			t.Errorf("Expected no transaction open while publishing, got %d, %v", open, err)
+		}
+		return nil
+	}
+	concurrent := func(ctx context.Context, m outbox.Message) error {
+		if published, err := messages.PublishMessages(ctx, 10, publish); err != nil || published != 0 {
+			t.Errorf("Expected another relay to wait its turn, got %d, %v", published, err)
+		}
+		return publish(ctx, m)
+	}
+	published, err := messages.PublishMessages(context.Background(), 10, concurrent)
+	if err != nil || published != 2 {
+		t.Fatalf("Expected 2 published messages, got %d, %v", published, err)
+	}
+	if !slices.Equal(types, []outbox.Type{outbox.TypeFavoriteCreated, outbox.TypeFavoriteDeleted}) {
+		t.Errorf("Expected a creation and then a deletion, got %v", types)
+	}
+	if published, err = messages.PublishMessages(context.Background(), 10, publish); err != nil || published != 0 {
+		t.Errorf("Expected nothing left to publish, got %d, %v", published, err)
+	}
+}
+
+func TestWebhookDeliveriesOfCommittedChanges(t *testing.T) {
+	clearDB()
+	webhooks := repository.NewWebhookRepository(testDB)
+	images := webhook.Subscription{
+		ProjectID:   testProjectID,
+		URL:         "http://localhost/hooks",
+		Secret:      "0123456789abcdef",
+		Events:      []outbox.Type{outbox.TypeFavoriteCreated},
+		ObjectTypes: []favorite.ObjectType{favorite.ObjectTypeImage},
+	}
+	videos := images
+	videos.ObjectTypes = []favorite.ObjectType{favorite.ObjectTypeVideo}
+	for _, s := range []*webhook.Subscription{&images, &videos} {
+		if err := webhooks.CreateSubscription(context.Background(), s); err != nil {
+			t.Fatalf("Failed to create subscription: %v", err)
+		}
+	}
+	ownerID := uuid.New()
+	body, _ := json.Marshal(map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+	})
+	req := httptest.NewRequest(http.MethodPost, projectURL("/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var message outbox.Message
+	_, err := repository.NewOutboxRepository(testDB).PublishMessages(context.Background(), 10,
+		func(_ context.Context, m outbox.Message) error {
+			message = m
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	fanout := dispatch.NewFanout(webhooks)
+	for i := 0; i < 2; i++ {
+		if err = fanout.Publish(context.Background(), message); err != nil {
+			t.Fatalf("Failed to enqueue deliveries: %v", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	claimed, err := webhooks.ClaimDueDeliveries(context.Background(), now, 10, time.Minute)
+	if err != nil || len(claimed) != 1 || claimed[0].SubscriptionID != images.ID || claimed[0].Secret != images.Secret {
+		t.Fatalf("Expected the delivery to the image subscription only, got %+v, %v", claimed, err)
+	}
+	if again, _ := webhooks.ClaimDueDeliveries(context.Background(), now, 10, time.Minute); len(again) != 0 {
+		t.Errorf("Expected a claimed delivery not to be claimed again during its lease, got %d", len(again))
+	}
+	d := claimed[0].Delivery
+	d.Status, d.Attempts = webhook.StatusDead, 3
+	if err = webhooks.SaveDeliveryAttempt(context.Background(), d); err != nil {
+		t.Fatalf("Failed to save attempt: %v", err)
+	}
+	redelivered, err := webhooks.RedeliverDelivery(context.Background(), testProjectID, images.ID, d.ID, now)
+	if err != nil || redelivered.Status != webhook.StatusPending || redelivered.Attempts != 0 {
+		t.Fatalf("Expected the delivery to be pending again, got %+v, %v", redelivered, err)
+	}
+	deliveries, err := webhooks.GetDeliveries(context.Background(), testProjectID, images.ID, 10)
+	if err != nil || len(deliveries) != 1 || deliveries[0].MessageID != message.ID {
+		t.Errorf("Expected 1 delivery of message %d, got %+v, %v", message.ID, deliveries, err)
+	}
+	if err = webhooks.DeleteSubscription(context.Background(), testProjectID, images.ID); err != nil {
+		t.Fatalf("Failed to delete subscription: %v", err)
+	}
+	if deliveries, _ = webhooks.GetDeliveries(context.Background(), testProjectID, images.ID, 10); len(deliveries) != 0 {
+		t.Errorf("Expected the deliveries to go with the subscription, got %d", len(deliveries))
+	}
+}
+
+func TestEventListenerNotifiesCommittedEvents(t *testing.T) {
+	clearDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notices, err := repository.NewEventListener(testDBURL).Listen(ctx)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	ownerID := uuid.New()
+	body, _ := json.Marshal(map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   uuid.New(),
+		"object_type": "DOCUMENT",
+	})
+	req := httptest.NewRequest(http.MethodPost, projectURL("/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var notice repository.EventNotice
+	select {
+	case notice = <-notices:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a notice of the creation")
+	}
+	if notice.ProjectID != testProjectID || notice.OwnerID != ownerID || notice.Seq == 0 {
+		t.Fatalf("Expected a notice about owner %s, got %+v", ownerID, notice)
+	}
+	events := repository.NewAuditRepository(testDB)
+	after, err := events.GetEventsAfter(context.Background(), testProjectID, favorite.OwnerTypeUser, ownerID, 0, 10)
+	if err != nil || len(after) != 1 || after[0].Seq != notice.Seq || after[0].Action != audit.ActionCreate {
+		t.Errorf("Expected the noticed creation in the log, got %+v, %v", after, err)
+	}
+	last, err := events.GetLastEventSeq(context.Background(), testProjectID, favorite.OwnerTypeUser, ownerID)
+	if err != nil || last != notice.Seq {
+		t.Errorf("Expected the last seq to be %d, got %d, %v", notice.Seq, last, err)
+	}
+}
+
+func TestBatchCreateAndDeleteFavorites(t *testing.T) {
+	clearDB()
+	ctx := context.Background()
+	repo := repository.NewFavoriteRepository(testDB)
+	actor := audit.Actor{Type: audit.ActorTypeUser, ID: uuid.New(), RequestID: "batch"}
+	ownerID := uuid.New()
+	newFavorite := func(objectID uuid.UUID, tags ...string) favorite.Favorite {
+		return favorite.Favorite{
+			ProjectID:  testProjectID,
+			OwnerType:  favorite.OwnerTypeUser,
+			OwnerID:    ownerID,
+			ObjectID:   objectID,
+			ObjectType: favorite.ObjectTypeImage,
+			Tags:       tags,
+		}
+	}
+	existing := newFavorite(uuid.New())
+	if _, err := repo.CreateFavorite(ctx, &existing, actor); err != nil {
+		t.Fatalf("Failed to create favorite: %v", err)
+	}
+	repeated := uuid.New()
+	fs := []favorite.Favorite{
+		newFavorite(repeated, "work"),
+		newFavorite(existing.ObjectID),
+		newFavorite(uuid.New(), "home", "work"),
+		newFavorite(repeated),
+	}
+	created, err := repo.CreateFavorites(ctx, fs, actor)
+	if err != nil {
+		t.Fatalf("Failed to create favorites: %v", err)
+	}
+	if !slices.Equal(created, []bool{true, false, true, false}) {
+		t.Errorf("Expected the first and third favorites to be new, got %v", created)
+	}
+	if fs[1].ID != existing.ID || fs[3].ID != fs[0].ID || !slices.Equal(fs[3].Tags, []string{"work"}) {
+		t.Errorf("Expected repeated favorites to load the stored ones, got %+v", fs)
+	}
+	if !(fs[2].Position < fs[0].Position && fs[0].Position < existing.Position) {
+		t.Errorf("Expected later favorites above earlier ones, got %q, %q, %q", fs[2].Position, fs[0].Position, existing.Position)
+	}
+	var seqs []int64
+	if err = testDB.Select(&seqs, `SELECT seq FROM favorite_events WHERE favorite_id IN ($1, $2) ORDER BY seq`,
+		fs[0].ID, fs[2].ID); err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	var messages int
+	if err = testDB.Get(&messages, `SELECT COUNT(*) FROM favorite_outbox`); err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if len(seqs) != 2 || messages != 3 {
+		t.Errorf("Expected 2 new events and 3 messages in all, got %v and %d", seqs, messages)
+	}
+
+	deleted, err := repo.DeleteFavorites(ctx, testProjectID, []uuid.UUID{fs[2].ID, uuid.New(), existing.ID}, actor)
+	if err != nil {
+		t.Fatalf("Failed to delete favorites: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0].ID != fs[2].ID || deleted[1].ID != existing.ID || deleted[0].DeletedAt == nil {
+		t.Fatalf("Expected 2 tombstones in the order of ids, got %+v", deleted)
+	}
+	if !slices.Equal(deleted[0].Tags, []string{"home", "work"}) {
+		t.Errorf("Expected the tombstone to carry the tags, got %v", deleted[0].Tags)
+	}
+	counts, err := repo.GetFavoriteCounts(ctx, testProjectID, favorite.ObjectTypeImage,
+		[]uuid.UUID{existing.ObjectID, repeated, fs[2].ObjectID})
+	if err != nil {
+		t.Fatalf("Failed to count favorites: %v", err)
+	}
+	if len(counts) != 1 || counts[repeated] != 1 {
+		t.Errorf("Expected only the remaining favorite to be counted, got %v", counts)
+	}
+}
+
+func TestEraseFavoritesInChunks(t *testing.T) {
+	clearDB()
+	ctx := context.Background()
+	repo := repository.NewFavoriteRepository(testDB)
+	actor := audit.Actor{Type: audit.ActorTypeService, ID: uuid.New(), RequestID: "erase"}
+	ownerID, objectID := uuid.New(), uuid.New()
+	var fs []favorite.Favorite
+	for i := 0; i < 5; i++ {
+		fs = append(fs, favorite.Favorite{
+			ProjectID:  testProjectID,
+			OwnerType:  favorite.OwnerTypeUser,
+			OwnerID:    ownerID,
+			ObjectID:   uuid.New(),
+			ObjectType: favorite.ObjectTypeImage,
+		})
+	}
+	fs[0].ObjectID = objectID
+	fs = append(fs, favorite.Favorite{
+		ProjectID:  testProjectID,
+		OwnerType:  favorite.OwnerTypeUser,
+		OwnerID:    uuid.New(),
+		ObjectID:   objectID,
+		ObjectType: favorite.ObjectTypeImage,
+	})
+	if _, err := repo.CreateFavorites(ctx, fs, actor); err != nil {
+		t.Fatalf("Failed to create favorites: %v", err)
+	}
+
+	erased, err := repo.EraseFavorites(ctx, testProjectID, repository.Erasure{
+		ObjectType: favorite.ObjectTypeImage,
+		ObjectID:   objectID,
+	}, 2, actor)
+	if err != nil || erased != 2 {
+		t.Fatalf("Expected the 2 favorites of the object to be erased, got %d, %v", erased, err)
+	}
+	erased, err = repo.EraseFavorites(ctx, testProjectID, repository.Erasure{
+		OwnerType: favorite.OwnerTypeUser,
+		OwnerID:   ownerID,
+	}, 2, actor)
+	if err != nil || erased != 4 {
+		t.Fatalf("Expected the 4 remaining favorites of the owner to be erased in chunks, got %d, %v", erased, err)
+	}
+	var live, deletions, messages int
+	if err = testDB.Get(&live, `SELECT COUNT(*) FROM favorites WHERE deleted_at IS NULL`); err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if err = testDB.Get(&deletions, `SELECT COUNT(*) FROM favorite_events WHERE action = 'DELETE'`); err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if err = testDB.Get(&messages, `SELECT COUNT(*) FROM favorite_outbox WHERE type = 'favorite.deleted'`); err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if live != 0 || deletions != 6 || messages != 6 {
+		t.Errorf("Expected every favorite deleted with 6 events and messages, got %d live, %d, %d", live, deletions, messages)
+	}
+	page, err := repository.NewAuditRepository(testDB).GetPageOfEvents(
+		ctx,
+		testProjectID,
+		repository.AuditFilter{OwnerType: favorite.OwnerTypeUser, OwnerID: ownerID},
+		repository.PageRequest{Limit: 1},
+	)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(page.Events) != 1 || page.Events[0].Action != audit.ActionErase || page.Events[0].Erased != 4 {
+		t.Errorf("Expected an erasure receipt of 4 favorites, got %+v", page.Events)
+	}
+	counts, err := repo.GetFavoriteCounts(ctx, testProjectID, favorite.ObjectTypeImage, []uuid.UUID{objectID})
+	if err != nil || len(counts) != 0 {
+		t.Errorf("Expected no counters left, got %v, %v", counts, err)
+	}
+}
+
+func TestObjectEvents(t *testing.T) {
+	clearDB()
+	ctx := context.Background()
+	repo := repository.NewFavoriteRepository(testDB)
+	objectStates := repository.NewObjectRepository(testDB)
+	ingester := objects.NewIngester(repo, objectStates, 1)
+	actor := audit.Actor{Type: audit.ActorTypeService, ID: uuid.New(), RequestID: "objects"}
+	ref := object.Ref{ProjectID: testProjectID, ObjectType: favorite.ObjectTypeImage, ObjectID: uuid.New()}
+	owner := func(ownerID uuid.UUID) favorite.Favorite {
+		return favorite.Favorite{
+			ProjectID:  testProjectID,
+			OwnerType:  favorite.OwnerTypeUser,
+			OwnerID:    ownerID,
+			ObjectID:   ref.ObjectID,
+			ObjectType: ref.ObjectType,
+		}
+	}
+	first, second := owner(uuid.New()), owner(uuid.New())
+	created := []favorite.Favorite{first, second}
+	if _, err := repo.CreateFavorites(ctx, created, actor); err != nil {
+		t.Fatalf("Failed to create favorites: %v", err)
+	}
+
+	deletedAt := time.Now().UTC().Add(-time.Hour)
+	result, err := ingester.Ingest(ctx, object.Event{Type: object.EventDeleted, Ref: ref, OccurredAt: deletedAt}, actor)
+	if err != nil || result.Favorites != 2 {
+		t.Fatalf("Expected 2 favorites to be hidden, got %+v, %v", result, err)
+	}
+	deleted, err := objectStates.GetDeletedObjects(ctx, object.Ref{}, 10)
+	if err != nil || len(deleted) != 1 || deleted[0] != ref {
+		t.Errorf("Expected the object to be recorded deleted, got %v, %v", deleted, err)
+	}
+	var hidden int
+	err = testDB.Get(&hidden, `SELECT COUNT(*) FROM favorite_outbox WHERE type = 'favorite.hidden'`)
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if hidden != 2 {
+		t.Errorf("Expected 2 favorites to be announced as hidden, got %d", hidden)
+	}
+	_, err = repo.RestoreFavorite(ctx, testProjectID, created[0].ID, deletedAt, actor)
+	if !errors.Is(err, repository.ErrHiddenByObject) {
+		t.Errorf("Expected a hidden favorite not to be restored by itself, got %v", err)
+	}
+	again := owner(second.OwnerID)
+	if _, err = repo.CreateFavorite(ctx, &again, actor); err != nil {
+		t.Fatalf("Failed to favorite the object again: %v", err)
+	}
+	stale := object.Event{Type: object.EventRestored, Ref: ref, OccurredAt: deletedAt.Add(-time.Minute)}
+	if result, err = ingester.Ingest(ctx, stale, actor); err != nil || !result.Stale {
+		t.Errorf("Expected an event older than the deletion to be stale, got %+v, %v", result, err)
+	}
+	result, err = ingester.Ingest(ctx, object.Event{Type: object.EventRestored, Ref: ref}, actor)
+	if err != nil || result.Favorites != 1 {
+		t.Fatalf("Expected the favorite of the first owner to be restored, got %+v, %v", result, err)
+	}
+
+	var live, restorations int
+	err = testDB.Get(&live, `SELECT COUNT(*) FROM favorites WHERE object_id = $1 AND deleted_at IS NULL`, ref.ObjectID)
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	err = testDB.Get(&restorations, `SELECT COUNT(*) FROM favorite_outbox WHERE type = 'favorite.restored'`)
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if live != 2 || restorations != 1 {
+		t.Errorf("Expected 2 live favorites after 1 restoration, got %d and %d", live, restorations)
+	}
+	counts, err := repo.GetFavoriteCounts(ctx, testProjectID, ref.ObjectType, []uuid.UUID{ref.ObjectID})
+	if err != nil || counts[ref.ObjectID] != 2 {
+		t.Errorf("Expected a counter of 2, got %v, %v", counts, err)
+	}
+	favorited, err := repo.GetFavoritedObjects(ctx, object.Ref{}, 10)
+	if err != nil || len(favorited) != 1 || favorited[0] != ref {
+		t.Errorf("Expected the object to be favorited, got %v, %v", favorited, err)
+	}
+	if deleted, err = objectStates.GetDeletedObjects(ctx, object.Ref{}, 10); err != nil || len(deleted) != 0 {
+		t.Errorf("Expected no deleted objects after the restore, got %v, %v", deleted, err)
+	}
+	if err = testDB.Get(&hidden, `SELECT COUNT(*) FROM favorites WHERE hidden_by_object`); err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if hidden != 0 {
+		t.Errorf("Expected no favorites to stay hidden after the restore, got %d", hidden)
+	}
+}
+
+func TestRestoreObjectFavoritesWhileOwnersFavoriteAgain(t *testing.T) {
+	clearDB()
+	ctx := context.Background()
+	repo := repository.NewFavoriteRepository(testDB)
+	actor := audit.Actor{Type: audit.ActorTypeService, ID: uuid.New(), RequestID: "objects"}
+	objectID := uuid.New()
+	owners := make([]favorite.Favorite, 20)
+	for i := range owners {
+		owners[i] = favorite.Favorite{
+			ProjectID:  testProjectID,
+			OwnerType:  favorite.OwnerTypeUser,
+			OwnerID:    uuid.New(),
+			ObjectID:   objectID,
+			ObjectType: favorite.ObjectTypeImage,
+		}
+	}
+	if _, err := repo.CreateFavorites(ctx, owners, actor); err != nil {
+		t.Fatalf("Failed to create favorites: %v", err)
+	}
+	erasure := repository.Erasure{ObjectType: favorite.ObjectTypeImage, ObjectID: objectID, Hide: true}
+	hiddenAt := time.Now().UTC().Add(-time.Minute)
+	if _, err := repo.EraseFavorites(ctx, testProjectID, erasure, 5, actor); err != nil {
+		t.Fatalf("Failed to hide favorites: %v", err)
+	}
+
+	// Every owner favorites the object again while it is restored, which
+	// either wins or is skipped, but never fails the restore.
+	var wg sync.WaitGroup
+	for _, f := range owners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			again := f
+			if _, err := repo.CreateFavorite(ctx, &again, actor); err != nil {
+				t.Errorf("Failed to favorite the object again: %v", err)
+			}
+		}()
+	}
+	restored, err := repo.RestoreObjectFavorites(ctx, testProjectID, favorite.ObjectTypeImage, objectID, hiddenAt, 5, actor)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("Failed to restore favorites: %v", err)
+	}
+	var live int
+	err = testDB.Get(&live, `SELECT COUNT(*) FROM favorites WHERE object_id = $1 AND deleted_at IS NULL`, objectID)
+	if err != nil {
+		t.Fatalf("Failed to query database: %v", err)
+	}
+	if live != len(owners) || restored > int64(len(owners)) {
+		t.Errorf("Expected every owner to favorite the object once, got %d live after %d restored", live, restored)
 	}
 }
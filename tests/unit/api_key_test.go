@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"favorites/internal/auth"
+	"favorites/internal/models/apikey"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withAPIKey(router *gin.Engine, req *http.Request, token string) *httptest.ResponseRecorder {
+	req.Header.Set("X-API-Key", token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeys(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	admin, secret := auth.NewAPIKey(testProjectID, "bootstrap", []apikey.Scope{apikey.ScopeAdmin}, nil)
+	if err := storage.APIKeys.CreateAPIKey(context.Background(), &admin); err != nil {
+		t.Fatalf("Failed to create admin key: %v", err)
+	}
+	adminToken := auth.APIKeyToken(admin, secret)
+	body, _ := json.Marshal(map[string]any{"name": "search", "scopes": []string{"read"}})
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/api-keys"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := withAPIKey(router, req, adminToken)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var created struct {
+		APIKey apikey.APIKey `json:"api_key"`
+		Token  string        `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	fav := createFavorite(t, router, uuid.New())
+	list := projectURL(testProjectID, "/favorites?owner_type=USER&limit=1&owner_id="+fav.OwnerID.String())
+	if w = withAPIKey(router, httptest.NewRequest(http.MethodGet, list, nil), created.Token); w.Code != http.StatusOK {
+		t.Errorf("Expected a read key to list any owner's favorites, got %d: %s", w.Code, w.Body)
+	}
+	deleteURL := projectURL(testProjectID, "/favorites/") + fav.ID.String()
+	if w = withAPIKey(router, httptest.NewRequest(http.MethodDelete, deleteURL, nil), created.Token); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a write with a read key, got %d", http.StatusForbidden, w.Code)
+	}
+	otherProject := projectURL(uuid.New(), "/favorites?owner_type=USER&limit=1&owner_id="+fav.OwnerID.String())
+	if w = withAPIKey(router, httptest.NewRequest(http.MethodGet, otherProject, nil), created.Token); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for another project, got %d", http.StatusForbidden, w.Code)
+	}
+	revokeURL := projectURL(testProjectID, "/api-keys/") + created.APIKey.ID.String()
+	if w = withAPIKey(router, httptest.NewRequest(http.MethodDelete, revokeURL, nil), adminToken); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body)
+	}
+	if w = withAPIKey(router, httptest.NewRequest(http.MethodGet, list, nil), created.Token); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a revoked key, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w = withAPIKey(router, httptest.NewRequest(http.MethodGet, list, nil), created.Token+"x"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a wrong secret, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
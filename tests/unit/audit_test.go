@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"favorites/internal/auth"
+	"favorites/internal/models/apikey"
+	"favorites/internal/models/audit"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func getAuditEvents(router *gin.Engine, token string, query url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/audit")+"?"+query.Encode(), nil)
+	return withAPIKey(router, req, token)
+}
+
+func TestAuditLog(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	admin, secret := auth.NewAPIKey(testProjectID, "compliance", []apikey.Scope{apikey.ScopeAdmin}, nil)
+	if err := storage.APIKeys.CreateAPIKey(context.Background(), &admin); err != nil {
+		t.Fatalf("Failed to create admin key: %v", err)
+	}
+	adminToken := auth.APIKeyToken(admin, secret)
+	ownerID := uuid.New()
+	body, _ := json.Marshal(map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+	})
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	req.Header.Set("X-Request-ID", "create-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Header().Get("X-Request-ID") != "create-1" {
+		t.Fatalf("Expected status %d echoing the request id, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var created struct {
+		ID uuid.UUID `json:"id"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	fav, err := storage.Favorites.GetFavorite(context.Background(), testProjectID, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to load favorite: %v", err)
+	}
+	deleteFavorite(router, testProjectID, fav)
+	restoreFavorite(router, fav)
+	createFavorite(t, router, uuid.New())
+
+	w = getAuditEvents(router, adminToken, url.Values{
+		"limit":      {"10"},
+		"owner_type": {"USER"},
+		"owner_id":   {ownerID.String()},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var events []audit.Event
+	if err = json.Unmarshal(w.Body.Bytes(), &events); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events of the owner, got %d", len(events))
+	}
+	for i, action := range []audit.Action{audit.ActionRestore, audit.ActionDelete, audit.ActionCreate} {
+		if events[i].Action != action || events[i].FavoriteID != fav.ID {
+			t.Errorf("Expected event %d to be %s of %s, got %s of %s", i, action, fav.ID, events[i].Action, events[i].FavoriteID)
+		}
+	}
+	create := events[2]
+	if create.ActorType != audit.ActorTypeUser || create.ActorID != ownerID || create.RequestID != "create-1" {
+		t.Errorf("Expected the creation to be attributed to the owner and its request, got %+v", create)
+	}
+	if create.Before != nil || create.After == nil || create.After.ID != fav.ID {
+		t.Errorf("Expected the creation to have only an after snapshot, got %+v", create)
+	}
+	deletion := events[1]
+	if deletion.RequestID == "" || deletion.Before == nil || deletion.Before.DeletedAt != nil ||
+		deletion.After == nil || deletion.After.DeletedAt == nil {
+		t.Errorf("Expected the deletion to snapshot the favorite before and after, got %+v", deletion)
+	}
+
+	w = getAuditEvents(router, adminToken, url.Values{"limit": {"2"}})
+	if w.Code != http.StatusOK || w.Header().Get("X-Has-More") != "true" {
+		t.Fatalf("Expected a first page of 4 events, got %d: %s", w.Code, w.Body)
+	}
+	w = getAuditEvents(router, adminToken, url.Values{"limit": {"2"}, "after": {w.Header().Get("X-Next-Cursor")}})
+	_ = json.Unmarshal(w.Body.Bytes(), &events)
+	if w.Code != http.StatusOK || len(events) != 2 || events[1].Action != audit.ActionCreate {
+		t.Errorf("Expected the second page to end with the first creation, got %d: %s", w.Code, w.Body)
+	}
+	if w.Header().Get("X-Has-More") != "false" {
+		t.Errorf("Expected the second page to be the last one")
+	}
+
+	w = getAuditEvents(router, adminToken, url.Values{"limit": {"10"}, "object_type": {"IMAGE"}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an object_type without object_id, got %d", http.StatusBadRequest, w.Code)
+	}
+	req = httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/audit?limit=10"), nil)
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a user, got %d", http.StatusForbidden, w.Code)
+	}
+}
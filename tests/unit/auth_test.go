@@ -0,0 +1,242 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"favorites/internal/auth"
+	"favorites/internal/repository"
+	"github.com/google/uuid"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "jwt-secret"
+
+func encodeToken(header map[string]any, claims map[string]any) string {
+	encodedHeader, _ := json.Marshal(header)
+	encodedClaims, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(encodedHeader) + "." +
+		base64.RawURLEncoding.EncodeToString(encodedClaims)
+}
+
+func signToken(claims map[string]any) string {
+	signed := encodeToken(map[string]any{"alg": "HS256", "typ": "JWT"}, claims)
+	mac := hmac.New(sha256.New, []byte(testJWTSecret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// bearer returns an Authorization header for a caller of the test project
+// representing the owner: the user itself or the member of the group that
+// joinGroup adds.
+func bearer(ownerType string, ownerID uuid.UUID) string {
+	return projectBearer(testProjectID, ownerType, ownerID)
+}
+
+// projectBearer is bearer for a token valid in the given project.
+func projectBearer(projectID uuid.UUID, ownerType string, ownerID uuid.UUID) string {
+	claims := map[string]any{"sub": ownerID, "project_id": projectID, "exp": time.Now().Add(time.Hour).Unix()}
+	if ownerType == "GROUP" {
+		claims["sub"] = groupMember(ownerID)
+	}
+	return "Bearer " + signToken(claims)
+}
+
+// groupMember is the user bearer acts as for the group.
+func groupMember(groupID uuid.UUID) uuid.UUID {
+	return uuid.NewSHA1(groupID, []byte("member"))
+}
+
+// joinGroup makes groupMember a member of the group in the test project.
+func joinGroup(t *testing.T, storage repository.Storage, groupID uuid.UUID) {
+	t.Helper()
+	if _, err := storage.GroupMembers.AddGroupMember(context.Background(), testProjectID, groupID, groupMember(groupID)); err != nil {
+		t.Fatalf("Failed to add group member: %v", err)
+	}
+}
+
+func TestFavoritesRequireToken(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	createFavorite(t, router, ownerID)
+	expired := signToken(map[string]any{"sub": ownerID, "project_id": testProjectID, "exp": time.Now().Add(-time.Hour).Unix()})
+	withoutProject := signToken(map[string]any{"sub": ownerID, "exp": time.Now().Add(time.Hour).Unix()})
+	for _, authorization := range []string{
+		"",
+		"Bearer " + expired,
+		"Bearer " + signToken(map[string]any{"sub": ownerID, "project_id": testProjectID}),
+		"Bearer " + withoutProject,
+	} {
+		req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites?owner_type=USER&limit=1&owner_id="+ownerID.String()), nil)
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusUnauthorized, authorization, w.Code)
+		}
+	}
+}
+
+func TestTokensAreBoundToTheirProject(t *testing.T) {
+	router := newRouter()
+	created := createFavorite(t, router, uuid.New())
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites?owner_type=USER&limit=1&owner_id="+created.OwnerID.String()), nil)
+	req.Header.Set("Authorization", projectBearer(uuid.New(), "USER", created.OwnerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a token of another project, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestFavoritesOfOtherOwnersAreForbidden(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	created := createFavorite(t, router, uuid.New())
+	stranger := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites?owner_type=USER&limit=1&owner_id="+created.OwnerID.String()), nil)
+	req.Header.Set("Authorization", bearer("USER", stranger))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for listing, got %d", http.StatusForbidden, w.Code)
+	}
+	req = httptest.NewRequest(http.MethodDelete, projectURL(testProjectID, "/favorites/")+created.ID.String(), nil)
+	req.Header.Set("Authorization", bearer("USER", stranger))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for deleting, got %d", http.StatusForbidden, w.Code)
+	}
+	groupID := uuid.New()
+	requestBody := map[string]any{
+		"owner_type":  "GROUP",
+		"owner_id":    groupID,
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+	}
+	if w = postFavorite(router, requestBody, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a non-member, got %d", http.StatusForbidden, w.Code)
+	}
+	joinGroup(t, storage, groupID)
+	if w = postFavorite(router, requestBody, ""); w.Code != http.StatusCreated {
+		t.Errorf("Expected a group member to create the group's favorite, got %d: %s", w.Code, w.Body)
+	}
+	if err := storage.GroupMembers.RemoveGroupMember(context.Background(), testProjectID, groupID, groupMember(groupID)); err != nil {
+		t.Fatalf("Failed to remove group member: %v", err)
+	}
+	requestBody["object_id"] = uuid.New()
+	if w = postFavorite(router, requestBody, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a removed member to lose the group at once, got %d", w.Code)
+	}
+	requestBody["owner_type"] = "USER"
+	body, _ := json.Marshal(requestBody)
+	req = httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", stranger))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for creating, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestGroupsOfTheTokenAddToMemberships(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	userID, claimed, joined := uuid.New(), uuid.New(), uuid.New()
+	if _, err := storage.GroupMembers.AddGroupMember(context.Background(), testProjectID, joined, userID); err != nil {
+		t.Fatalf("Failed to add group member: %v", err)
+	}
+	token := func(groups any) string {
+		return "Bearer " + signToken(map[string]any{
+			"sub":        userID,
+			"groups":     groups,
+			"project_id": testProjectID,
+			"exp":        time.Now().Add(time.Hour).Unix(),
+		})
+	}
+	create := func(authorization string, groupID uuid.UUID) int {
+		body, _ := json.Marshal(map[string]any{
+			"owner_type":  "GROUP",
+			"owner_id":    groupID,
+			"object_id":   uuid.New(),
+			"object_type": "IMAGE",
+		})
+		req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites"), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	for _, groupID := range []uuid.UUID{claimed, joined} {
+		if code := create(token([]uuid.UUID{claimed}), groupID); code != http.StatusCreated {
+			t.Errorf("Expected the user to represent group %s, got %d", groupID, code)
+		}
+	}
+	if code := create(token([]uuid.UUID{}), claimed); code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a group neither claimed nor joined, got %d", http.StatusForbidden, code)
+	}
+	if code := create(token([]string{"not-a-uuid"}), joined); code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a malformed groups claim, got %d", http.StatusUnauthorized, code)
+	}
+}
+
+func TestVerifierAcceptsRS256FromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "k1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+	keys, err := auth.LoadJWKS(path)
+	if err != nil {
+		t.Fatalf("Failed to load JWKS: %v", err)
+	}
+	verifier, err := auth.NewVerifier(auth.Options{RSAKeys: keys, Audience: "favorites"})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	subject, group := uuid.New(), uuid.New()
+	signed := encodeToken(map[string]any{"alg": "RS256", "kid": "k1"}, map[string]any{
+		"sub":        subject,
+		"aud":        []string{"favorites"},
+		"groups":     []uuid.UUID{group},
+		"project_id": uuid.New(),
+		"exp":        time.Now().Add(time.Hour).Unix(),
+	})
+	digest := sha256.Sum256([]byte(signed))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	principal, err := verifier.Verify(signed + "." + base64.RawURLEncoding.EncodeToString(signature))
+	if err != nil {
+		t.Fatalf("Expected the token to verify, got %v", err)
+	}
+	if principal.Subject != subject || !principal.Represents("GROUP", group) {
+		t.Errorf("Expected subject %s in group %s, got %+v", subject, group, principal)
+	}
+	if _, err = verifier.Verify(signToken(map[string]any{"sub": subject, "exp": time.Now().Add(time.Hour).Unix()})); err == nil {
+		t.Errorf("Expected HS256 to be rejected without a secret")
+	}
+}
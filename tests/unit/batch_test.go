@@ -0,0 +1,169 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/favorite"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func postBatch(router *gin.Engine, method string, query string, requestBody map[string]any, callerID uuid.UUID) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(requestBody)
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites:"+method+query), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", callerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func batchResults(t *testing.T, w *httptest.ResponseRecorder, expected ...string) []dto.BatchFavoriteResult {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response dto.BatchFavoritesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode results: %v", err)
+	}
+	if len(response.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), response.Results)
+	}
+	for i, result := range response.Results {
+		if result.Status != expected[i] {
+			t.Errorf("Expected item %d to be %s, got %+v", i, expected[i], result)
+		}
+	}
+	return response.Results
+}
+
+func batchItem(ownerID uuid.UUID, objectID uuid.UUID, objectType string) map[string]any {
+	return map[string]any{"owner_type": "USER", "owner_id": ownerID, "object_id": objectID, "object_type": objectType}
+}
+
+func TestBatchCreateFavorites(t *testing.T) {
+	t.Setenv("BATCH_MAX_ITEMS", "4")
+	router := newRouter()
+	ownerID := uuid.New()
+	existing := createFavorite(t, router, ownerID)
+	image, video := uuid.New(), uuid.New()
+	tagged := batchItem(ownerID, video, "VIDEO")
+	tagged["tags"] = []string{" Cats ", "cats", "dogs"}
+	results := batchResults(t, postBatch(router, "batchCreate", "", map[string]any{"items": []map[string]any{
+		batchItem(ownerID, image, "IMAGE"),
+		batchItem(ownerID, existing.ObjectID, "IMAGE"),
+		tagged,
+		batchItem(ownerID, image, "IMAGE"),
+	}}, ownerID), "created", "exists", "created", "exists")
+	if results[1].Favorite == nil || results[1].Favorite.ID != existing.ID {
+		t.Errorf("Expected the existing favorite %s, got %+v", existing.ID, results[1].Favorite)
+	}
+	if results[3].Favorite == nil || results[3].Favorite.ID != results[0].Favorite.ID {
+		t.Errorf("Expected the repeated item to get favorite %s, got %+v", results[0].Favorite.ID, results[3].Favorite)
+	}
+	if tags := results[2].Favorite.Tags; !slices.Equal(tags, []string{"cats", "dogs"}) {
+		t.Errorf("Expected normalized tags, got %v", tags)
+	}
+	expected := []uuid.UUID{results[2].Favorite.ID, results[0].Favorite.ID, existing.ID}
+	if manual := getManualOrder(t, router, ownerID); !slices.Equal(manual, expected) {
+		t.Errorf("Expected later items above earlier ones %v, got %v", expected, manual)
+	}
+
+	audio := uuid.New()
+	for _, test := range []struct {
+		items  []map[string]any
+		status int
+	}{
+		{[]map[string]any{batchItem(ownerID, audio, "IMAGE"), batchItem(ownerID, uuid.New(), "AUDIO")}, http.StatusBadRequest},
+		{[]map[string]any{batchItem(ownerID, audio, "IMAGE"), {"owner_type": "USER", "owner_id": ownerID}}, http.StatusBadRequest},
+		{[]map[string]any{batchItem(ownerID, audio, "IMAGE"), batchItem(uuid.New(), uuid.New(), "IMAGE")}, http.StatusForbidden},
+		{[]map[string]any{}, http.StatusBadRequest},
+		{slices.Repeat([]map[string]any{batchItem(ownerID, audio, "IMAGE")}, 5), http.StatusBadRequest},
+	} {
+		if w := postBatch(router, "batchCreate", "", map[string]any{"items": test.items}, ownerID); w.Code != test.status {
+			t.Errorf("Expected status %d for %v, got %d: %s", test.status, test.items, w.Code, w.Body)
+		}
+	}
+	if manual := getManualOrder(t, router, ownerID); len(manual) != 3 {
+		t.Errorf("Expected rejected batches to create nothing, got %v", manual)
+	}
+
+	invalidTag := batchItem(ownerID, uuid.New(), "IMAGE")
+	invalidTag["tags"] = []string{"a,b"}
+	results = batchResults(t, postBatch(router, "batchCreate", "?partial=true", map[string]any{"items": []map[string]any{
+		batchItem(ownerID, audio, "IMAGE"),
+		batchItem(ownerID, uuid.New(), "AUDIO"),
+		invalidTag,
+		batchItem(uuid.New(), uuid.New(), "IMAGE"),
+	}}, ownerID), "created", "invalid", "invalid", "forbidden")
+	if results[1].Error == "" || results[1].Favorite != nil {
+		t.Errorf("Expected the invalid item to carry an error only, got %+v", results[1])
+	}
+	if w := postBatch(router, "batchCreate", "?partial=maybe", map[string]any{"items": []map[string]any{tagged}}, ownerID); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a malformed partial, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := postBatch(router, "batchUpdate", "", map[string]any{"items": []map[string]any{tagged}}, ownerID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown method, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestBatchDeleteFavorites(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	first, second := createFavorite(t, router, ownerID), createFavorite(t, router, ownerID)
+	foreign := createFavorite(t, router, uuid.New())
+	missing := uuid.New()
+
+	if w := postBatch(router, "batchDelete", "", map[string]any{"ids": []uuid.UUID{first.ID, missing}}, ownerID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusNotFound, w.Code, w.Body)
+	}
+	if w := postBatch(router, "batchDelete", "", map[string]any{"ids": []uuid.UUID{first.ID, foreign.ID}}, ownerID); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, w.Code, w.Body)
+	}
+	if w := postBatch(router, "batchDelete", "", map[string]any{"ids": []uuid.UUID{first.ID, first.ID}}, ownerID); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for repeated ids, got %d", http.StatusBadRequest, w.Code)
+	}
+	if manual := getManualOrder(t, router, ownerID); len(manual) != 2 {
+		t.Fatalf("Expected rejected batches to delete nothing, got %v", manual)
+	}
+
+	results := batchResults(t, postBatch(router, "batchDelete", "?partial=true", map[string]any{
+		"ids": []uuid.UUID{first.ID, missing, foreign.ID},
+	}, ownerID), "deleted", "not_found", "forbidden")
+	if f := results[0].Favorite; f == nil || f.ID != first.ID || f.DeletedAt == nil {
+		t.Errorf("Expected the tombstone of favorite %s, got %+v", first.ID, f)
+	}
+	batchResults(t, postBatch(router, "batchDelete", "", map[string]any{"ids": []uuid.UUID{second.ID}}, ownerID), "deleted")
+	for _, f := range []favorite.Favorite{first, second} {
+		if w := restoreFavorite(router, f); w.Code != http.StatusOK {
+			t.Errorf("Expected batch deleted favorite %s to be restorable, got %d: %s", f.ID, w.Code, w.Body)
+		}
+	}
+	if manual := getManualOrder(t, router, ownerID); len(manual) != 2 {
+		t.Errorf("Expected both favorites back, got %v", manual)
+	}
+}
+
+func TestBatchRoutesMatchOnlyTheirMethods(t *testing.T) {
+	router := newRouter()
+	for _, path := range []string{"/favorites:batchUpdate", "/favorites:", "/batchCreate", "/favorites-batchCreate"} {
+		req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, path), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound || w.Body.String() != "404 page not found" {
+			t.Errorf("Expected the router's 404 for %s without a token, got %d: %s", path, w.Code, w.Body)
+		}
+	}
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites:batchCreate"), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a batch method without a token, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
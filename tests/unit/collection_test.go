@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"favorites/internal/models/collection"
+	"favorites/internal/models/favorite"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func collectionRequest(router *gin.Engine, method string, path string, requestBody any, ownerID uuid.UUID) *httptest.ResponseRecorder {
+	var body []byte
+	if requestBody != nil {
+		body, _ = json.Marshal(requestBody)
+	}
+	req := httptest.NewRequest(method, projectURL(testProjectID, path), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCollections(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	w := collectionRequest(router, http.MethodPost, "/collections", map[string]any{
+		"owner_type": "USER",
+		"owner_id":   ownerID,
+		"name":       "Travel",
+	}, ownerID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var col collection.Collection
+	if err := json.Unmarshal(w.Body.Bytes(), &col); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	w = collectionRequest(router, http.MethodPost, "/collections", map[string]any{
+		"owner_type": "USER",
+		"owner_id":   ownerID,
+		"name":       "Travel",
+	}, ownerID)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a duplicate name, got %d", http.StatusConflict, w.Code)
+	}
+	collectionPath := "/collections/" + col.ID.String()
+	if w = collectionRequest(router, http.MethodGet, collectionPath, nil, uuid.New()); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a stranger, got %d", http.StatusForbidden, w.Code)
+	}
+
+	var added []favorite.Favorite
+	for i := 0; i < 3; i++ {
+		f := createFavorite(t, router, ownerID)
+		if w = collectionRequest(router, http.MethodPut, collectionPath+"/favorites/"+f.ID.String(), nil, ownerID); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+		}
+		added = append(added, f)
+	}
+	createFavorite(t, router, ownerID)
+	if w = collectionRequest(router, http.MethodPut, collectionPath+"/favorites/"+added[0].ID.String(), nil, ownerID); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d for adding twice, got %d", http.StatusOK, w.Code)
+	}
+	foreign := createFavorite(t, router, uuid.New())
+	if w = collectionRequest(router, http.MethodPut, collectionPath+"/favorites/"+foreign.ID.String(), nil, ownerID); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a favorite of another owner, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	seen := 0
+	cursor := ""
+	for {
+		w = collectionRequest(router, http.MethodGet, collectionPath+"/favorites?limit=2&after="+cursor, nil, ownerID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err := json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		seen += len(favorites)
+		if cursor = w.Header().Get("X-Next-Cursor"); cursor == "" {
+			break
+		}
+	}
+	if seen != len(added) {
+		t.Errorf("Expected %d favorites in the collection, got %d", len(added), seen)
+	}
+
+	if w = collectionRequest(router, http.MethodDelete, collectionPath+"/favorites/"+added[0].ID.String(), nil, ownerID); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	// Deleting a favorite drops it from its collections.
+	deleteFavorite(router, testProjectID, added[1])
+	w = collectionRequest(router, http.MethodGet, collectionPath+"/favorites?limit=25", nil, ownerID)
+	var favorites []favorite.Favorite
+	_ = json.Unmarshal(w.Body.Bytes(), &favorites)
+	if len(favorites) != 1 || favorites[0].ID != added[2].ID {
+		t.Errorf("Expected only favorite %s in the collection, got %v", added[2].ID, favorites)
+	}
+
+	w = collectionRequest(router, http.MethodPatch, collectionPath, map[string]any{"name": "Trips"}, ownerID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	w = collectionRequest(router, http.MethodGet, "/collections?owner_type=USER&owner_id="+ownerID.String(), nil, ownerID)
+	var collections []collection.Collection
+	_ = json.Unmarshal(w.Body.Bytes(), &collections)
+	if len(collections) != 1 || collections[0].Name != "Trips" {
+		t.Errorf("Expected the renamed collection, got %v", collections)
+	}
+
+	if w = collectionRequest(router, http.MethodDelete, collectionPath, nil, ownerID); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w = collectionRequest(router, http.MethodGet, collectionPath, nil, ownerID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after deleting, got %d", http.StatusNotFound, w.Code)
+	}
+	if w = getFavorites(router, ownerID, "25", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the favorites to outlive the collection, got %d", w.Code)
+	}
+}
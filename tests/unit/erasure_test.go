@@ -0,0 +1,237 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeDeleted(t *testing.T, w *httptest.ResponseRecorder) int64 {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response dto.DeleteFavoritesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response.Deleted
+}
+
+// latestAuditEvent returns the newest event of the project matching query.
+func latestAuditEvent(t *testing.T, router *gin.Engine, token string, query url.Values) audit.Event {
+	t.Helper()
+	query.Set("limit", "1")
+	w := getAuditEvents(router, token, query)
+	var events []audit.Event
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil || len(events) != 1 {
+		t.Fatalf("Expected an audit event, got %d: %s", w.Code, w.Body)
+	}
+	return events[0]
+}
+
+func TestDeleteOwnerFavorites(t *testing.T) {
+	t.Setenv("ERASE_CHUNK_SIZE", "2")
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+	ownerID := uuid.New()
+	for range 3 {
+		createFavorite(t, router, ownerID)
+	}
+	deleteFavorite(router, testProjectID, createFavorite(t, router, ownerID))
+	other := createFavorite(t, router, uuid.New())
+	since := getFavoriteChanges(t, router, ownerID, "", "").NextToken
+
+	erase := func(callerID uuid.UUID) *httptest.ResponseRecorder {
+		query := url.Values{"owner_type": {"USER"}, "owner_id": {ownerID.String()}}
+		req := httptest.NewRequest(http.MethodDelete, projectURL(testProjectID, "/favorites?"+query.Encode()), nil)
+		req.Header.Set("Authorization", bearer("USER", callerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := erase(uuid.New()); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if deleted := decodeDeleted(t, erase(ownerID)); deleted != 3 {
+		t.Errorf("Expected the 3 remaining favorites to be deleted, got %d", deleted)
+	}
+	if w := getFavorites(router, ownerID, "10", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the owner to have no favorites left, got %d: %s", w.Code, w.Body)
+	}
+	if _, err := storage.Favorites.GetFavorite(context.Background(), testProjectID, other.ID); err != nil {
+		t.Errorf("Expected the favorite of another owner to be kept, got %v", err)
+	}
+
+	receipt := latestAuditEvent(t, router, token, url.Values{"owner_type": {"USER"}, "owner_id": {ownerID.String()}})
+	if receipt.Action != audit.ActionErase || receipt.Erased != 3 || receipt.FavoriteID != uuid.Nil || receipt.ActorID != ownerID {
+		t.Errorf("Expected an erasure receipt of 3 favorites by the owner, got %+v", receipt)
+	}
+	changes := getFavoriteChanges(t, router, ownerID, since, "")
+	if len(changes.Changes) != 3 {
+		t.Fatalf("Expected 3 deletions without the receipt, got %+v", changes.Changes)
+	}
+	for _, change := range changes.Changes {
+		if change.Type != outbox.TypeFavoriteDeleted {
+			t.Errorf("Expected a deletion, got %+v", change)
+		}
+	}
+	if deleted := decodeDeleted(t, erase(ownerID)); deleted != 0 {
+		t.Errorf("Expected a repeated erasure to find nothing, got %d", deleted)
+	}
+}
+
+func TestDeleteOwnerFavoritesKeepsThoseHiddenByObjectsDeleted(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+	hidden := createFavorite(t, router, uuid.New())
+	kept := createFavorite(t, router, uuid.New())
+	if w := postFavorite(router, map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    kept.OwnerID,
+		"object_id":   hidden.ObjectID,
+		"object_type": "IMAGE",
+	}, ""); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	event := func(eventType string) map[string]any {
+		return map[string]any{"type": eventType, "object_type": "IMAGE", "object_id": hidden.ObjectID}
+	}
+	if response := ingestObjectEvent(t, router, token, event("object.deleted")); response.Favorites != 2 {
+		t.Fatalf("Expected the favorites of both owners to be hidden, got %+v", response)
+	}
+
+	query := url.Values{"owner_type": {"USER"}, "owner_id": {hidden.OwnerID.String()}}
+	req := httptest.NewRequest(http.MethodDelete, projectURL(testProjectID, "/favorites?"+query.Encode()), nil)
+	req.Header.Set("Authorization", bearer("USER", hidden.OwnerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if deleted := decodeDeleted(t, w); deleted != 0 {
+		t.Errorf("Expected no live favorites to be deleted, got %d", deleted)
+	}
+
+	// Only the favorite of the owner who was not erased comes back.
+	if response := ingestObjectEvent(t, router, token, event("object.restored")); response.Favorites != 1 {
+		t.Errorf("Expected 1 favorite to be restored, got %+v", response)
+	}
+	if w := getFavorites(router, hidden.OwnerID, "10", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the erased owner to have no favorites, got %d: %s", w.Code, w.Body)
+	}
+	if counts := countFavorites(t, storage, hidden.ObjectID); counts[hidden.ObjectID] != 1 {
+		t.Errorf("Expected the object to be favorited once, got %v", counts)
+	}
+}
+
+func TestDeleteOwnerFavoritesRedactsNotesAndTags(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+	ownerID, otherID := uuid.New(), uuid.New()
+	var erased, kept favorite.Favorite
+	for _, f := range []struct {
+		ownerID uuid.UUID
+		into    *favorite.Favorite
+	}{{ownerID, &erased}, {otherID, &kept}} {
+		w := postFavorite(router, map[string]any{
+			"owner_type":  "USER",
+			"owner_id":    f.ownerID,
+			"object_id":   uuid.New(),
+			"object_type": "IMAGE",
+			"note":        "private diary",
+			"tags":        []string{"secret"},
+		}, "")
+		if err := json.Unmarshal(w.Body.Bytes(), f.into); err != nil {
+			t.Fatalf("Failed to create favorite: %d %s", w.Code, w.Body)
+		}
+	}
+
+	query := url.Values{"owner_type": {"USER"}, "owner_id": {ownerID.String()}}
+	req := httptest.NewRequest(http.MethodDelete, projectURL(testProjectID, "/favorites?"+query.Encode()), nil)
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if deleted := decodeDeleted(t, w); deleted != 1 {
+		t.Fatalf("Expected 1 favorite to be deleted, got %d", deleted)
+	}
+
+	if w = getAuditEvents(router, token, query); strings.Contains(w.Body.String(), "private diary") || strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("Expected the audit log of the owner to be redacted, got %s", w.Body)
+	}
+	deleted, err := storage.Favorites.GetDeletedFavorite(context.Background(), testProjectID, erased.ID, time.Time{})
+	if err != nil || deleted.Note != "" || len(deleted.Tags) != 0 {
+		t.Errorf("Expected the deleted favorite to be redacted, got %+v, %v", deleted, err)
+	}
+	messages := map[uuid.UUID][]favorite.Favorite{}
+	_, err = storage.Outbox.PublishMessages(context.Background(), 100, func(_ context.Context, m outbox.Message) error {
+		messages[m.FavoriteID] = append(messages[m.FavoriteID], m.Favorite)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to publish messages: %v", err)
+	}
+	for _, f := range messages[erased.ID] {
+		if f.Note != "" || len(f.Tags) != 0 {
+			t.Errorf("Expected the messages of the owner to be redacted, got %+v", f)
+		}
+	}
+	for _, f := range messages[kept.ID] {
+		if f.Note != "private diary" {
+			t.Errorf("Expected the messages of another owner to be kept, got %+v", f)
+		}
+	}
+}
+
+func TestDeleteObjectFavorites(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+	removed := createFavorite(t, router, uuid.New())
+	postFavorite(router, map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    uuid.New(),
+		"object_id":   removed.ObjectID,
+		"object_type": "IMAGE",
+	}, "")
+	kept := createFavorite(t, router, removed.OwnerID)
+
+	path := projectURL(testProjectID, "/objects/IMAGE/"+removed.ObjectID.String()+"/favorites")
+	req := httptest.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set("Authorization", bearer("USER", removed.OwnerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d without the admin scope, got %d", http.StatusForbidden, w.Code)
+	}
+	if deleted := decodeDeleted(t, withAPIKey(router, httptest.NewRequest(http.MethodDelete, path, nil), token)); deleted != 2 {
+		t.Errorf("Expected the favorites of both owners to be deleted, got %d", deleted)
+	}
+	counts, err := storage.Favorites.GetFavoriteCounts(context.Background(), testProjectID, "IMAGE", []uuid.UUID{removed.ObjectID, kept.ObjectID})
+	if err != nil {
+		t.Fatalf("Failed to count favorites: %v", err)
+	}
+	if len(counts) != 1 || counts[kept.ObjectID] != 1 {
+		t.Errorf("Expected only the other object to stay favorited, got %v", counts)
+	}
+	receipt := latestAuditEvent(t, router, token, url.Values{"object_type": {"IMAGE"}, "object_id": {removed.ObjectID.String()}})
+	if receipt.Action != audit.ActionErase || receipt.Erased != 2 || receipt.OwnerType != "" {
+		t.Errorf("Expected an erasure receipt of 2 favorites of the object, got %+v", receipt)
+	}
+	w = withAPIKey(router, httptest.NewRequest(http.MethodDelete, projectURL(testProjectID, "/objects/AUDIO/"+uuid.NewString()+"/favorites"), nil), token)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown object type, got %d", http.StatusBadRequest, w.Code)
+	}
+}
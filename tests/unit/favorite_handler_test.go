@@ -0,0 +1,751 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"favorites/config"
+	"favorites/internal/handlers"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/favorite"
+	"favorites/internal/purge"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProjectID is the project the helpers below act on.
+var testProjectID = uuid.New()
+
+func projectURL(projectID uuid.UUID, path string) string {
+	return "/projects/" + projectID.String() + path
+}
+
+func newRouter() *gin.Engine {
+	return newRouterWithStorage(repository.NewMemoryStorage())
+}
+
+func newRouterWithStorage(storage repository.Storage) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	cfg := config.LoadConfig()
+	cfg.CursorSigningKeys = []config.SigningKey{{ID: "test", Secret: "secret"}}
+	cfg.JWTSecret = testJWTSecret
+	handlers.RegisterRoutes(storage, cfg, router)
+	return router
+}
+
+func createFavorite(t *testing.T, router *gin.Engine, ownerID uuid.UUID) favorite.Favorite {
+	t.Helper()
+	requestBody := map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+	}
+	body, _ := json.Marshal(requestBody)
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var fav favorite.Favorite
+	if err := json.Unmarshal(w.Body.Bytes(), &fav); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return fav
+}
+
+func getFavorites(router *gin.Engine, ownerID uuid.UUID, limit string, cursor string) *httptest.ResponseRecorder {
+	return getFavoritesPage(router, ownerID, limit, "after", cursor)
+}
+
+func getFavoritesPage(router *gin.Engine, ownerID uuid.UUID, limit string, cursorKey string, cursor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites"), nil)
+	urlQuery := req.URL.Query()
+	urlQuery.Add("owner_type", "USER")
+	urlQuery.Add("owner_id", ownerID.String())
+	urlQuery.Add("limit", limit)
+	urlQuery.Add(cursorKey, cursor)
+	req.URL.RawQuery = urlQuery.Encode()
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateAndGetFavorites(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	created := createFavorite(t, router, ownerID)
+	createFavorite(t, router, uuid.New())
+	w := getFavorites(router, ownerID, "25", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var favorites []favorite.Favorite
+	if err := json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(favorites) != 1 || favorites[0].ID != created.ID {
+		t.Errorf("Expected only favorite %s, got %v", created.ID, favorites)
+	}
+	if w.Header().Get("X-Next-Cursor") != "" || w.Header().Get("X-Has-More") != "false" {
+		t.Errorf("Expected the only page to be the last one")
+	}
+}
+
+func TestGetFavoritesPagination(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	for i := 0; i < 5; i++ {
+		createFavorite(t, router, ownerID)
+	}
+	seen := make(map[uuid.UUID]bool)
+	cursor := ""
+	for {
+		w := getFavorites(router, ownerID, "2", cursor)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err := json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, f := range favorites {
+			if seen[f.ID] {
+				t.Errorf("Favorite %s returned twice", f.ID)
+			}
+			seen[f.ID] = true
+		}
+		// Deleting the row the cursor points to must not break the next page.
+		deleteFavorite(router, testProjectID, favorites[len(favorites)-1])
+		cursor = w.Header().Get("X-Next-Cursor")
+		if cursor == "" {
+			if w.Header().Get("X-Has-More") != "false" {
+				t.Errorf("Expected X-Has-More to be false on the last page")
+			}
+			break
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected 5 favorites across pages, got %d", len(seen))
+	}
+}
+
+func TestGetFavoritesBackwards(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	for i := 0; i < 5; i++ {
+		createFavorite(t, router, ownerID)
+	}
+	first := getFavorites(router, ownerID, "2", "")
+	if first.Header().Get("X-Prev-Cursor") != "" {
+		t.Errorf("Expected no previous page for the first page")
+	}
+	second := getFavorites(router, ownerID, "2", first.Header().Get("X-Next-Cursor"))
+	prevCursor := second.Header().Get("X-Prev-Cursor")
+	if second.Code != http.StatusOK || prevCursor == "" {
+		t.Fatalf("Expected a previous page cursor, got %d: %s", second.Code, second.Body)
+	}
+	back := getFavoritesPage(router, ownerID, "2", "before", prevCursor)
+	if back.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, back.Code, back.Body)
+	}
+	if back.Body.String() != first.Body.String() {
+		t.Errorf("Expected the first page again, got %s", back.Body)
+	}
+	if back.Header().Get("X-Prev-Cursor") != "" || back.Header().Get("X-Next-Cursor") == "" {
+		t.Errorf("Expected only a next cursor on the first page")
+	}
+}
+
+func TestGetFavoritesRejectsForeignCursors(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	otherOwnerID := uuid.New()
+	for i := 0; i < 3; i++ {
+		createFavorite(t, router, ownerID)
+		createFavorite(t, router, otherOwnerID)
+	}
+	nextCursor := getFavorites(router, ownerID, "1", "").Header().Get("X-Next-Cursor")
+	if w := getFavorites(router, ownerID, "1", nextCursor); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if w := getFavorites(router, otherOwnerID, "1", nextCursor); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for another owner's cursor, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := getFavoritesPage(router, ownerID, "1", "before", nextCursor); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a cursor used in the wrong direction, got %d", http.StatusBadRequest, w.Code)
+	}
+	tampered := "x" + nextCursor[1:]
+	if w := getFavorites(router, ownerID, "1", tampered); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a tampered cursor, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func postFavorite(router *gin.Engine, requestBody map[string]any, idempotencyKey string) *httptest.ResponseRecorder {
+	return postProjectFavorite(router, testProjectID, requestBody, idempotencyKey)
+}
+
+func postProjectFavorite(router *gin.Engine, projectID uuid.UUID, requestBody map[string]any, idempotencyKey string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(requestBody)
+	req := httptest.NewRequest(http.MethodPost, projectURL(projectID, "/favorites"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", projectBearer(projectID, requestBody["owner_type"].(string), requestBody["owner_id"].(uuid.UUID)))
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// deleteFavorite deletes f from the project on behalf of its owner.
+func deleteFavorite(router *gin.Engine, projectID uuid.UUID, f favorite.Favorite) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, projectURL(projectID, "/favorites/")+f.ID.String(), nil)
+	req.Header.Set("Authorization", projectBearer(projectID, string(f.OwnerType), f.OwnerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateFavoriteTwiceReturnsExisting(t *testing.T) {
+	router := newRouter()
+	requestBody := map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    uuid.New(),
+		"object_id":   uuid.New(),
+		"object_type": "VIDEO",
+	}
+	first := postFavorite(router, requestBody, "")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, first.Code, first.Body)
+	}
+	second := postFavorite(router, requestBody, "")
+	if second.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, second.Code, second.Body)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("Expected the existing favorite %s, got %s", first.Body, second.Body)
+	}
+}
+
+func TestCreateFavoriteIdempotencyKey(t *testing.T) {
+	router := newRouter()
+	requestBody := map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    uuid.New(),
+		"object_id":   uuid.New(),
+		"object_type": "DOCUMENT",
+	}
+	first := postFavorite(router, requestBody, "retry-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, first.Code, first.Body)
+	}
+	replayed := postFavorite(router, requestBody, "retry-1")
+	if replayed.Code != http.StatusCreated || replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Expected the replayed %d response, got %d: %s", http.StatusCreated, replayed.Code, replayed.Body)
+	}
+	if replayed.Body.String() != first.Body.String() {
+		t.Errorf("Expected body %s, got %s", first.Body, replayed.Body)
+	}
+	requestBody["object_id"] = uuid.New()
+	if w := postFavorite(router, requestBody, "retry-1"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d for a reused key, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+func TestLookupFavorites(t *testing.T) {
+	router := newRouter()
+	requestBody := map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    uuid.New(),
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+	}
+	created := postFavorite(router, requestBody, "")
+	var fav favorite.Favorite
+	if err := json.Unmarshal(created.Body.Bytes(), &fav); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	missingID := uuid.New()
+	lookupBody, _ := json.Marshal(map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    requestBody["owner_id"],
+		"object_type": "IMAGE",
+		"object_ids":  []uuid.UUID{fav.ObjectID, missingID},
+	})
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites/lookup"), bytes.NewReader(lookupBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", fav.OwnerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response struct {
+		Favorites map[uuid.UUID]*uuid.UUID `json:"favorites"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if got := response.Favorites[fav.ObjectID]; got == nil || *got != fav.ID {
+		t.Errorf("Expected favorite %s for object %s, got %v", fav.ID, fav.ObjectID, got)
+	}
+	if got, ok := response.Favorites[missingID]; !ok || got != nil {
+		t.Errorf("Expected null for object %s, got %v", missingID, got)
+	}
+}
+
+func TestGetObjectFavorites(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+	objectID := uuid.New()
+	for _, ownerType := range []string{"USER", "GROUP"} {
+		ownerID := uuid.New()
+		joinGroup(t, storage, ownerID)
+		postFavorite(router, map[string]any{
+			"owner_type":  ownerType,
+			"owner_id":    ownerID,
+			"object_id":   objectID,
+			"object_type": "DOCUMENT",
+			"note":        "private",
+		}, "")
+	}
+	postProjectFavorite(router, uuid.New(), map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    uuid.New(),
+		"object_id":   objectID,
+		"object_type": "DOCUMENT",
+	}, "")
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/objects/DOCUMENT/"+objectID.String()+"/favorites"), nil)
+	urlQuery := req.URL.Query()
+	urlQuery.Add("limit", "25")
+	req.URL.RawQuery = urlQuery.Encode()
+	req.Header.Set("Authorization", bearer("USER", uuid.New()))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d for a user, got %d", http.StatusForbidden, w.Code)
+	}
+	req.Header.Del("Authorization")
+	w = withAPIKey(router, req, token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "private") {
+		t.Errorf("Expected the notes of the owners to stay private, got %s", w.Body)
+	}
+	var owners []dto.ObjectOwner
+	if err := json.Unmarshal(w.Body.Bytes(), &owners); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(owners) != 2 || owners[0].OwnerID == uuid.Nil {
+		t.Errorf("Expected 2 owners within the project, got %+v", owners)
+	}
+}
+
+func TestGetObjectCounts(t *testing.T) {
+	router := newRouter()
+	objectID := uuid.New()
+	otherObjectID := uuid.New()
+	var favorites []favorite.Favorite
+	for i := 0; i < 2; i++ {
+		w := postFavorite(router, map[string]any{
+			"owner_type":  "USER",
+			"owner_id":    uuid.New(),
+			"object_id":   objectID,
+			"object_type": "VIDEO",
+		}, "")
+		var fav favorite.Favorite
+		if err := json.Unmarshal(w.Body.Bytes(), &fav); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		favorites = append(favorites, fav)
+	}
+	deleteFavorite(router, testProjectID, favorites[0])
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/objects/counts"), nil)
+	urlQuery := req.URL.Query()
+	urlQuery.Add("object_type", "VIDEO")
+	urlQuery.Add("object_id", objectID.String())
+	urlQuery.Add("object_id", otherObjectID.String())
+	req.URL.RawQuery = urlQuery.Encode()
+	req.Header.Set("Authorization", bearer("USER", uuid.New()))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response struct {
+		Counts map[uuid.UUID]int64 `json:"counts"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Counts[objectID] != 1 || response.Counts[otherObjectID] != 0 || len(response.Counts) != 2 {
+		t.Errorf("Expected counts 1 and 0, got %v", response.Counts)
+	}
+}
+
+func TestGetFavoritesFilterAndSort(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	for _, objectType := range []string{"VIDEO", "IMAGE", "DOCUMENT", "IMAGE"} {
+		postFavorite(router, map[string]any{
+			"owner_type":  "USER",
+			"owner_id":    ownerID,
+			"object_id":   uuid.New(),
+			"object_type": objectType,
+		}, "")
+	}
+	list := func(query map[string][]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites"), nil)
+		urlQuery := req.URL.Query()
+		urlQuery.Add("owner_type", "USER")
+		urlQuery.Add("owner_id", ownerID.String())
+		for key, values := range query {
+			for _, value := range values {
+				urlQuery.Add(key, value)
+			}
+		}
+		req.URL.RawQuery = urlQuery.Encode()
+		req.Header.Set("Authorization", bearer("USER", ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	w := list(map[string][]string{"limit": {"10"}, "object_type": {"IMAGE", "VIDEO"}, "sort": {"object_type"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var favorites []favorite.Favorite
+	if err := json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	var objectTypes []favorite.ObjectType
+	for _, f := range favorites {
+		objectTypes = append(objectTypes, f.ObjectType)
+	}
+	expected := []favorite.ObjectType{favorite.ObjectTypeImage, favorite.ObjectTypeImage, favorite.ObjectTypeVideo}
+	if !slices.Equal(objectTypes, expected) {
+		t.Errorf("Expected object types %v, got %v", expected, objectTypes)
+	}
+	w = list(map[string][]string{"limit": {"2"}, "sort": {"object_type"}})
+	cursor := w.Header().Get("X-Next-Cursor")
+	if w = list(map[string][]string{"limit": {"2"}, "sort": {"object_type"}, "after": {cursor}}); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if w = list(map[string][]string{"limit": {"2"}, "sort": {"oldest"}, "after": {cursor}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a cursor of another sort, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w = list(map[string][]string{"limit": {"2"}, "created_after": {"yesterday"}}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid created_after, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDeleteFavorite(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	created := createFavorite(t, router, ownerID)
+	w := deleteFavorite(router, testProjectID, created)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	w = getFavorites(router, ownerID, "25", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDeleteFavoriteOfAnotherProject(t *testing.T) {
+	router := newRouter()
+	created := createFavorite(t, router, uuid.New())
+	w := deleteFavorite(router, uuid.New(), created)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w = getFavorites(router, created.OwnerID, "25", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the favorite to survive, got %d", w.Code)
+	}
+}
+
+func TestGetEffectiveFavorites(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	userID, groupID, otherGroupID := uuid.New(), uuid.New(), uuid.New()
+	path := projectURL(testProjectID, "/groups/"+groupID.String()+"/members/"+userID.String())
+	req := httptest.NewRequest(http.MethodPut, path, nil)
+	req.Header.Set("Authorization", bearer("USER", userID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d for a user joining a group, got %d", http.StatusForbidden, w.Code)
+	}
+	if w = withAPIKey(router, httptest.NewRequest(http.MethodPut, path, nil), newAdminToken(t, storage)); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	joinGroup(t, storage, groupID)
+	joinGroup(t, storage, otherGroupID)
+	shared := uuid.New()
+	owners := []struct {
+		ownerType string
+		ownerID   uuid.UUID
+		objectID  uuid.UUID
+	}{
+		{"USER", userID, uuid.New()},
+		{"USER", userID, shared},
+		{"GROUP", groupID, shared},
+		{"GROUP", groupID, uuid.New()},
+		{"GROUP", otherGroupID, uuid.New()},
+	}
+	for _, owner := range owners {
+		postFavorite(router, map[string]any{
+			"owner_type":  owner.ownerType,
+			"owner_id":    owner.ownerID,
+			"object_id":   owner.objectID,
+			"object_type": "IMAGE",
+		}, "")
+	}
+	seen := make(map[uuid.UUID]favorite.Favorite)
+	cursor := ""
+	for {
+		req = httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/users/"+userID.String()+"/effective-favorites?limit=2&after="+cursor), nil)
+		req.Header.Set("Authorization", bearer("USER", userID))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err := json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, f := range favorites {
+			if _, ok := seen[f.ObjectID]; ok {
+				t.Errorf("Object %s returned twice", f.ObjectID)
+			}
+			seen[f.ObjectID] = f
+		}
+		if cursor = w.Header().Get("X-Next-Cursor"); cursor == "" {
+			break
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected 3 effective favorites, got %d", len(seen))
+	}
+	if f := seen[shared]; f.OwnerType != favorite.OwnerTypeUser {
+		t.Errorf("Expected the user's own favorite for the shared object, got %+v", f)
+	}
+}
+
+func moveFavorite(router *gin.Engine, f favorite.Favorite, requestBody map[string]any) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(requestBody)
+	req := httptest.NewRequest(http.MethodPatch, projectURL(testProjectID, "/favorites/"+f.ID.String()+"/position"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(string(f.OwnerType), f.OwnerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// getManualOrder walks all pages of the owner's favorites in manual order.
+func getManualOrder(t *testing.T, router *gin.Engine, ownerID uuid.UUID) []uuid.UUID {
+	t.Helper()
+	var ids []uuid.UUID
+	cursor := ""
+	for {
+		req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites?sort=manual&limit=2&owner_type=USER&owner_id="+ownerID.String()+"&after="+cursor), nil)
+		req.Header.Set("Authorization", bearer("USER", ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+		}
+		var favorites []favorite.Favorite
+		if err := json.Unmarshal(w.Body.Bytes(), &favorites); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		for _, f := range favorites {
+			ids = append(ids, f.ID)
+		}
+		if cursor = w.Header().Get("X-Next-Cursor"); cursor == "" {
+			return ids
+		}
+	}
+}
+
+func TestMoveFavorite(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	var a, b, c, d favorite.Favorite
+	for _, f := range []*favorite.Favorite{&a, &b, &c, &d} {
+		*f = createFavorite(t, router, ownerID)
+	}
+	expected := []uuid.UUID{d.ID, c.ID, b.ID, a.ID}
+	if ids := getManualOrder(t, router, ownerID); !slices.Equal(ids, expected) {
+		t.Fatalf("Expected new favorites on top %v, got %v", expected, ids)
+	}
+	if w := moveFavorite(router, a, map[string]any{"after": d.ID}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if w := moveFavorite(router, b, map[string]any{"pinned": true}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if w := moveFavorite(router, c, map[string]any{"before": d.ID}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	expected = []uuid.UUID{b.ID, c.ID, d.ID, a.ID}
+	if ids := getManualOrder(t, router, ownerID); !slices.Equal(ids, expected) {
+		t.Errorf("Expected order %v, got %v", expected, ids)
+	}
+	if w := moveFavorite(router, a, map[string]any{"after": d.ID, "before": c.ID}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for anchors out of order, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := moveFavorite(router, a, map[string]any{"after": b.ID, "pinned": false}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a pinned anchor of an unpinned favorite, got %d", http.StatusBadRequest, w.Code)
+	}
+	foreign := createFavorite(t, router, uuid.New())
+	if w := moveFavorite(router, a, map[string]any{"after": foreign.ID}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an anchor of another owner, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestFavoriteTagsAndNotes(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	requestBody := map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   uuid.New(),
+		"object_type": "IMAGE",
+		"note":        "for the trip",
+		"tags":        []string{" Travel ", "summer", "travel"},
+	}
+	w := postFavorite(router, requestBody, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var tagged favorite.Favorite
+	if err := json.Unmarshal(w.Body.Bytes(), &tagged); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if tagged.Note != "for the trip" || !slices.Equal(tagged.Tags, []string{"summer", "travel"}) {
+		t.Errorf("Expected the note and normalized tags, got %q %v", tagged.Note, tagged.Tags)
+	}
+	untagged := createFavorite(t, router, ownerID)
+
+	body, _ := json.Marshal(map[string]any{"tags": []string{"travel", "work"}})
+	req := httptest.NewRequest(http.MethodPatch, projectURL(testProjectID, "/favorites/"+untagged.ID.String()), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites?limit=25&tag=TRAVEL&tag=summer&owner_type=USER&owner_id="+ownerID.String()), nil)
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var favorites []favorite.Favorite
+	_ = json.Unmarshal(w.Body.Bytes(), &favorites)
+	if len(favorites) != 1 || favorites[0].ID != tagged.ID {
+		t.Errorf("Expected only favorite %s to carry both tags, got %v", tagged.ID, favorites)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/tags?owner_type=USER&owner_id="+ownerID.String()), nil)
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var tags []struct {
+		Tag   string `json:"tag"`
+		Count int64  `json:"count"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &tags)
+	if len(tags) != 3 || tags[0].Tag != "travel" || tags[0].Count != 2 {
+		t.Errorf("Expected travel to be the most used of 3 tags, got %v", tags)
+	}
+
+	requestBody["object_id"] = uuid.New()
+	requestBody["tags"] = []string{"a,b"}
+	if w = postFavorite(router, requestBody, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a tag with a comma, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func restoreFavorite(router *gin.Engine, f favorite.Favorite) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites/"+f.ID.String()+"/restore"), nil)
+	req.Header.Set("Authorization", bearer(string(f.OwnerType), f.OwnerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRestoreFavorite(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	created := createFavorite(t, router, ownerID)
+	if w := restoreFavorite(router, created); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a favorite that is not deleted, got %d", http.StatusNotFound, w.Code)
+	}
+	deleteFavorite(router, testProjectID, created)
+	if w := deleteFavorite(router, testProjectID, created); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for deleting twice, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := restoreFavorite(router, created); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	w := getFavorites(router, ownerID, "25", "")
+	var favorites []favorite.Favorite
+	_ = json.Unmarshal(w.Body.Bytes(), &favorites)
+	if len(favorites) != 1 || favorites[0].ID != created.ID {
+		t.Errorf("Expected the restored favorite %s, got %v", created.ID, favorites)
+	}
+
+	deleteFavorite(router, testProjectID, created)
+	requestBody := map[string]any{
+		"owner_type":  "USER",
+		"owner_id":    ownerID,
+		"object_id":   created.ObjectID,
+		"object_type": created.ObjectType,
+	}
+	if w = postFavorite(router, requestBody, ""); w.Code != http.StatusCreated {
+		t.Fatalf("Expected favoriting the object again to create a new favorite, got %d", w.Code)
+	}
+	if w = restoreFavorite(router, created); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for restoring a favorite favorited again, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestPurgeDeletedFavorites(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	created := createFavorite(t, router, uuid.New())
+	kept := createFavorite(t, router, uuid.New())
+	deleteFavorite(router, testProjectID, created)
+	purged, err := purge.NewPurger(storage.Favorites, 0, time.Hour).PurgeOnce(context.Background())
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected 1 purged favorite, got %d, %v", purged, err)
+	}
+	if w := restoreFavorite(router, created); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a purged favorite, got %d", http.StatusNotFound, w.Code)
+	}
+	if _, err = storage.Favorites.GetFavorite(context.Background(), testProjectID, kept.ID); err != nil {
+		t.Errorf("Expected favorites that were not deleted to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/audit"
+	"favorites/internal/models/favorite"
+	"favorites/internal/models/outbox"
+	"favorites/internal/objects"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func postObjectEvent(router *gin.Engine, token string, requestBody map[string]any) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(requestBody)
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/objects/events"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return withAPIKey(router, req, token)
+}
+
+func ingestObjectEvent(t *testing.T, router *gin.Engine, token string, requestBody map[string]any) dto.ObjectEventResponse {
+	t.Helper()
+	w := postObjectEvent(router, token, requestBody)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response dto.ObjectEventResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response
+}
+
+func countFavorites(t *testing.T, storage repository.Storage, objectIDs ...uuid.UUID) map[uuid.UUID]int64 {
+	t.Helper()
+	counts, err := storage.Favorites.GetFavoriteCounts(context.Background(), testProjectID, favorite.ObjectTypeImage, objectIDs)
+	if err != nil {
+		t.Fatalf("Failed to count favorites: %v", err)
+	}
+	return counts
+}
+
+func TestIngestObjectEvents(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+	first := createFavorite(t, router, uuid.New())
+	secondOwner := uuid.New()
+	item := map[string]any{"owner_type": "USER", "owner_id": secondOwner, "object_id": first.ObjectID, "object_type": "IMAGE"}
+	w := postFavorite(router, item, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var second favorite.Favorite
+	if err := json.Unmarshal(w.Body.Bytes(), &second); err != nil {
+		t.Fatalf("Failed to decode favorite: %v", err)
+	}
+	other := createFavorite(t, router, first.OwnerID)
+	initial := getFavoriteChanges(t, router, first.OwnerID, "", "")
+
+	deletedAt := time.Now().Add(-time.Hour)
+	event := func(eventType string, occurredAt time.Time) map[string]any {
+		e := map[string]any{"type": eventType, "object_type": "IMAGE", "object_id": first.ObjectID}
+		if !occurredAt.IsZero() {
+			e["occurred_at"] = occurredAt
+		}
+		return e
+	}
+	if response := ingestObjectEvent(t, router, token, event("object.deleted", deletedAt)); response.Stale || response.Favorites != 2 {
+		t.Fatalf("Expected the favorites of both owners to be hidden, got %+v", response)
+	}
+	if counts := countFavorites(t, storage, first.ObjectID, other.ObjectID); len(counts) != 1 || counts[other.ObjectID] != 1 {
+		t.Errorf("Expected only the other object to stay favorited, got %v", counts)
+	}
+	receipt := latestAuditEvent(t, router, token, url.Values{"object_type": {"IMAGE"}, "object_id": {first.ObjectID.String()}})
+	if receipt.Action != audit.ActionErase || receipt.Erased != 2 || receipt.ActorType != audit.ActorTypeService {
+		t.Errorf("Expected an erasure receipt of 2 favorites by the service, got %+v", receipt)
+	}
+	if response := ingestObjectEvent(t, router, token, event("object.deleted", deletedAt)); response.Stale || response.Favorites != 0 {
+		t.Errorf("Expected a repeated event to hide nothing more, got %+v", response)
+	}
+	changes := getFavoriteChanges(t, router, first.OwnerID, initial.NextToken, "")
+	if len(changes.Changes) != 1 || changes.Changes[0].Type != outbox.TypeFavoriteHidden || changes.Changes[0].Favorite.ID != first.ID {
+		t.Errorf("Expected favorite %s to be announced as hidden, got %+v", first.ID, changes.Changes)
+	}
+	// Hidden favorites come back with their object only.
+	if w := restoreFavorite(router, first); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d restoring a hidden favorite, got %d: %s", http.StatusConflict, w.Code, w.Body)
+	}
+
+	// The second owner favorites the deleted object again meanwhile.
+	if w := postFavorite(router, item, ""); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	if response := ingestObjectEvent(t, router, token, event("object.restored", deletedAt.Add(-time.Minute))); !response.Stale {
+		t.Errorf("Expected an event older than the deletion to be stale, got %+v", response)
+	}
+	if response := ingestObjectEvent(t, router, token, event("object.restored", time.Time{})); response.Stale || response.Favorites != 1 {
+		t.Fatalf("Expected the favorite of the first owner to be restored, got %+v", response)
+	}
+	if counts := countFavorites(t, storage, first.ObjectID); counts[first.ObjectID] != 2 {
+		t.Errorf("Expected both owners to favorite the object again, got %v", counts)
+	}
+	if manual := getManualOrder(t, router, first.OwnerID); !slices.Contains(manual, first.ID) {
+		t.Errorf("Expected favorite %s to be back, got %v", first.ID, manual)
+	}
+	// The favorite skipped is an ordinary deleted favorite from now on.
+	if w := restoreFavorite(router, second); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "favorited again") {
+		t.Errorf("Expected status %d restoring a favorite favorited again, got %d: %s", http.StatusConflict, w.Code, w.Body)
+	}
+	if response := ingestObjectEvent(t, router, token, event("object.deleted", deletedAt)); !response.Stale {
+		t.Errorf("Expected the deletion to be stale after the restore, got %+v", response)
+	}
+
+	for _, requestBody := range []map[string]any{
+		{"type": "object.moved", "object_type": "IMAGE", "object_id": uuid.New()},
+		{"type": "object.deleted", "object_type": "AUDIO", "object_id": uuid.New()},
+		{"type": "object.deleted", "object_type": "IMAGE"},
+	} {
+		if w := postObjectEvent(router, token, requestBody); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %v, got %d", http.StatusBadRequest, requestBody, w.Code)
+		}
+	}
+	body, _ := json.Marshal(event("object.deleted", time.Time{}))
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/objects/events"), bytes.NewReader(body))
+	req.Header.Set("Authorization", bearer("USER", first.OwnerID))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d without the admin scope, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestReconcileObjects(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	ownerID := uuid.New()
+	kept, gone, back := createFavorite(t, router, ownerID), createFavorite(t, router, ownerID), createFavorite(t, router, ownerID)
+
+	var mu sync.Mutex
+	existing := map[uuid.UUID]bool{kept.ObjectID: true}
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var request struct {
+			ObjectIDs []uuid.UUID `json:"object_ids"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		response := map[string][]uuid.UUID{"existing": {}}
+		for _, objectID := range request.ObjectIDs {
+			if existing[objectID] {
+				response["existing"] = append(response["existing"], objectID)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+	ingester := objects.NewIngester(storage.Favorites, storage.Objects, 2)
+	resolver := objects.NewHTTPResolver(server.URL, time.Second)
+	// A batch of one walks the objects page by page.
+	reconciler := objects.NewReconciler(storage.Favorites, storage.Objects, resolver, ingester, 1, time.Hour)
+
+	report, err := reconciler.ReconcileOnce(context.Background())
+	if err != nil || report.Deleted != 2 || report.Restored != 0 {
+		t.Fatalf("Expected 2 objects to be found deleted, got %+v, %v", report, err)
+	}
+	if counts := countFavorites(t, storage, kept.ObjectID, gone.ObjectID, back.ObjectID); len(counts) != 1 || counts[kept.ObjectID] != 1 {
+		t.Errorf("Expected only the existing object to stay favorited, got %v", counts)
+	}
+
+	mu.Lock()
+	existing[back.ObjectID] = true
+	mu.Unlock()
+	report, err = reconciler.ReconcileOnce(context.Background())
+	if err != nil || report.Deleted != 0 || report.Restored != 1 {
+		t.Fatalf("Expected 1 object to be found restored, got %+v, %v", report, err)
+	}
+	restored, err := storage.Favorites.GetFavorite(context.Background(), testProjectID, back.ID)
+	if err != nil || restored.DeletedAt != nil {
+		t.Errorf("Expected favorite %s to be restored, got %+v, %v", back.ID, restored, err)
+	}
+
+	mu.Lock()
+	failing = true
+	delete(existing, kept.ObjectID)
+	mu.Unlock()
+	if report, err = reconciler.ReconcileOnce(context.Background()); err == nil || report.Deleted != 0 {
+		t.Errorf("Expected a failing resolver to change nothing, got %+v, %v", report, err)
+	}
+	if counts := countFavorites(t, storage, kept.ObjectID); counts[kept.ObjectID] != 1 {
+		t.Errorf("Expected the object to stay favorited, got %v", counts)
+	}
+}
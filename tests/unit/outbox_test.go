@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"favorites/internal/models/outbox"
+	"favorites/internal/relay"
+	"favorites/internal/repository"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// recordingPublisher keeps the messages it is given and fails once it has
+// accepted failAfter of them, if set.
+type recordingPublisher struct {
+	messages  []outbox.Message
+	failAfter int
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, m outbox.Message) error {
+	if p.failAfter > 0 && len(p.messages) == p.failAfter {
+		return errors.New("downstream is unavailable")
+	}
+	p.messages = append(p.messages, m)
+	return nil
+}
+
+func TestRelayPublishesOutboxInOrder(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	ownerID := uuid.New()
+	first := createFavorite(t, router, ownerID)
+	second := createFavorite(t, router, ownerID)
+	moveFavorite(router, first, map[string]any{"pinned": true})
+	deleteFavorite(router, testProjectID, second)
+	restoreFavorite(router, second)
+
+	publisher := &recordingPublisher{failAfter: 2}
+	r := relay.NewRelay(storage.Outbox, publisher, 2, time.Hour, time.Hour)
+	published, err := r.RelayOnce(context.Background())
+	if err == nil || published != 2 {
+		t.Fatalf("Expected the relay to stop at the failing message after 2, got %d, %v", published, err)
+	}
+	publisher.failAfter = 0
+	if published, err = r.RelayOnce(context.Background()); err != nil || published != 3 {
+		t.Fatalf("Expected the remaining 3 messages to be published, got %d, %v", published, err)
+	}
+	var types []outbox.Type
+	for i, m := range publisher.messages {
+		types = append(types, m.Type)
+		if i > 0 && m.ID <= publisher.messages[i-1].ID {
+			t.Errorf("Expected message ids to grow, got %d after %d", m.ID, publisher.messages[i-1].ID)
+		}
+	}
+	expected := []outbox.Type{
+		outbox.TypeFavoriteCreated,
+		outbox.TypeFavoriteCreated,
+		outbox.TypeFavoriteMoved,
+		outbox.TypeFavoriteDeleted,
+		outbox.TypeFavoriteRestored,
+	}
+	if !slices.Equal(types, expected) {
+		t.Errorf("Expected messages %v, got %v", expected, types)
+	}
+	if moved := publisher.messages[2]; moved.FavoriteID != first.ID || !moved.Favorite.Pinned {
+		t.Errorf("Expected the move message to carry the pinned favorite %s, got %+v", first.ID, moved)
+	}
+	if deleted := publisher.messages[3]; deleted.Favorite.DeletedAt == nil {
+		t.Errorf("Expected the delete message to carry the deletion time")
+	}
+	if published, _ = r.RelayOnce(context.Background()); published != 0 {
+		t.Errorf("Expected published messages not to be published again, got %d", published)
+	}
+	deleted, err := storage.Outbox.DeletePublishedMessages(context.Background(), time.Now().Add(time.Minute))
+	if err != nil || deleted != 5 {
+		t.Errorf("Expected 5 published messages to be deleted, got %d, %v", deleted, err)
+	}
+}
+
+func TestOutboxPublishers(t *testing.T) {
+	m := outbox.NewMessage(outbox.TypeFavoriteCreated, createFavorite(t, newRouter(), uuid.New()))
+	m.ID = 7
+
+	var received outbox.Message
+	var messageID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		messageID = r.Header.Get("X-Message-ID")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	if err := relay.NewHTTPPublisher(server.URL, time.Second).Publish(context.Background(), m); err != nil {
+		t.Fatalf("Failed to publish to the webhook: %v", err)
+	}
+	if messageID != "7" || received.FavoriteID != m.FavoriteID || received.Type != m.Type {
+		t.Errorf("Expected the webhook to receive message 7, got %q: %+v", messageID, received)
+	}
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	if err := relay.NewHTTPPublisher(failing.URL, time.Second).Publish(context.Background(), m); err == nil {
+		t.Errorf("Expected a non-2xx response to fail the delivery")
+	}
+
+	path := filepath.Join(t.TempDir(), "outbox.ndjson")
+	publisher, err := relay.NewFilePublisher(path)
+	if err != nil {
+		t.Fatalf("Failed to open the file: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = publisher.Publish(context.Background(), m); err != nil {
+			t.Fatalf("Failed to publish to the file: %v", err)
+		}
+	}
+	_ = publisher.Close()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open the file: %v", err)
+	}
+	defer file.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); lines++ {
+		var line outbox.Message
+		if err = json.Unmarshal(scanner.Bytes(), &line); err != nil || line.ID != m.ID {
+			t.Errorf("Expected line %d to be message %d, got %s", lines, m.ID, scanner.Text())
+		}
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+}
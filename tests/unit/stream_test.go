@@ -0,0 +1,164 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/outbox"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is an event read off a stream, or a comment when Comment is set.
+type sseEvent struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+}
+
+// openStream follows the owner's favorites on server and returns the events
+// read off the stream until ctx is done.
+func openStream(t *testing.T, ctx context.Context, server *httptest.Server, ownerID uuid.UUID, lastEventID string) <-chan sseEvent {
+	t.Helper()
+	url := server.URL + projectURL(testProjectID, "/favorites/stream?owner_type=USER&owner_id="+ownerID.String())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open the stream: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	events := make(chan sseEvent)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+		var e sseEvent
+		for scanner := bufio.NewScanner(resp.Body); scanner.Scan(); {
+			field, value, _ := strings.Cut(scanner.Text(), ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "":
+				if value == "" {
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+					e = sseEvent{}
+				} else {
+					e.Comment = value
+				}
+			case "id":
+				e.ID = value
+			case "event":
+				e.Event = value
+			case "data":
+				e.Data = value
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent returns the next event of the stream, skipping heartbeats.
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	for {
+		select {
+		case e := <-events:
+			if e.Comment == "" {
+				return e
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected an event on the stream")
+		}
+	}
+}
+
+func TestStreamFavorites(t *testing.T) {
+	t.Setenv("STREAM_HEARTBEAT", "20ms")
+	router := newRouter()
+	server := httptest.NewServer(router)
+	// Close waits for the streams to end, so it fails the test by timeout if
+	// a disconnected client leaves its stream running.
+	defer server.Close()
+	ownerID := uuid.New()
+	before := createFavorite(t, router, ownerID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := openStream(t, ctx, server, ownerID, "")
+	heartbeat := false
+	for !heartbeat {
+		select {
+		case e := <-events:
+			if e.Comment == "" {
+				t.Fatalf("Expected no events from before the stream opened, got %+v", e)
+			}
+			heartbeat = e.Comment == "heartbeat"
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected a heartbeat while nothing changes")
+		}
+	}
+	created := createFavorite(t, router, ownerID)
+	createFavorite(t, router, uuid.New())
+	deleteFavorite(router, testProjectID, created)
+	first, second := nextEvent(t, events), nextEvent(t, events)
+	var change dto.FavoriteChangeEvent
+	if err := json.Unmarshal([]byte(first.Data), &change); err != nil {
+		t.Fatalf("Failed to decode event data: %v", err)
+	}
+	if first.Event != string(outbox.TypeFavoriteCreated) || change.Favorite.ID != created.ID {
+		t.Errorf("Expected favorite %s to be created, got %+v", created.ID, first)
+	}
+	if err := json.Unmarshal([]byte(second.Data), &change); err != nil {
+		t.Fatalf("Failed to decode event data: %v", err)
+	}
+	firstSeq, _ := strconv.ParseInt(first.ID, 10, 64)
+	secondSeq, _ := strconv.ParseInt(second.ID, 10, 64)
+	if second.Event != string(outbox.TypeFavoriteDeleted) || change.Favorite.ID != created.ID || secondSeq <= firstSeq {
+		t.Errorf("Expected favorite %s to be deleted after event %s, got %+v", created.ID, first.ID, second)
+	}
+	cancel()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	restored := restoreFavorite(router, created)
+	if restored.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, restored.Code, restored.Body)
+	}
+	events = openStream(t, ctx, server, ownerID, first.ID)
+	if e := nextEvent(t, events); e.ID != second.ID {
+		t.Errorf("Expected the stream to resume with event %s, got %+v", second.ID, e)
+	}
+	if e := nextEvent(t, events); e.Event != string(outbox.TypeFavoriteCreated) || !strings.Contains(e.Data, created.ID.String()) {
+		t.Errorf("Expected the restored favorite %s as created, got %+v", created.ID, e)
+	}
+	deleteFavorite(router, testProjectID, before)
+	if e := nextEvent(t, events); e.Event != string(outbox.TypeFavoriteDeleted) || !strings.Contains(e.Data, before.ID.String()) {
+		t.Errorf("Expected favorite %s to be deleted, got %+v", before.ID, e)
+	}
+
+	url := server.URL + projectURL(testProjectID, "/favorites/stream?owner_type=USER&owner_id="+ownerID.String())
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", bearer("USER", uuid.New()))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to request the stream: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status %d for another user, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"favorites/internal/handlers/dto"
+	"favorites/internal/models/outbox"
+	"favorites/internal/pagetoken"
+	"favorites/internal/repository"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func getFavoriteChanges(t *testing.T, router *gin.Engine, ownerID uuid.UUID, since string, limit string) dto.FavoriteChangesResponse {
+	t.Helper()
+	query := url.Values{"owner_type": {"USER"}, "owner_id": {ownerID.String()}, "since": {since}, "limit": {limit}}
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites/changes?"+query.Encode()), nil)
+	req.Header.Set("Authorization", bearer("USER", ownerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response dto.FavoriteChangesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode changes: %v", err)
+	}
+	return response
+}
+
+func pushFavoriteChanges(router *gin.Engine, ownerID uuid.UUID, callerID uuid.UUID, mutations []map[string]any) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]any{"owner_type": "USER", "owner_id": ownerID, "mutations": mutations})
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/favorites/changes"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer("USER", callerID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetFavoriteChanges(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	createFavorite(t, router, ownerID)
+	initial := getFavoriteChanges(t, router, ownerID, "", "")
+	if !initial.Resync || len(initial.Changes) != 0 || initial.NextToken == "" {
+		t.Fatalf("Expected a first sync to ask for a resync, got %+v", initial)
+	}
+
+	first := createFavorite(t, router, ownerID)
+	second := createFavorite(t, router, ownerID)
+	createFavorite(t, router, uuid.New())
+	deleteFavorite(router, testProjectID, first)
+	changes := getFavoriteChanges(t, router, ownerID, initial.NextToken, "")
+	if changes.Resync || changes.HasMore || len(changes.Changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if c := changes.Changes[0]; c.Type != outbox.TypeFavoriteCreated || c.Favorite.ID != second.ID {
+		t.Errorf("Expected favorite %s to be created, got %+v", second.ID, c)
+	}
+	if c := changes.Changes[1]; c.Type != outbox.TypeFavoriteDeleted || c.Favorite.ID != first.ID || c.Favorite.DeletedAt == nil {
+		t.Errorf("Expected the tombstone of favorite %s, got %+v", first.ID, c)
+	}
+	if next := getFavoriteChanges(t, router, ownerID, changes.NextToken, ""); len(next.Changes) != 0 || next.Resync {
+		t.Errorf("Expected no changes since the last token, got %+v", next)
+	}
+
+	page := getFavoriteChanges(t, router, ownerID, initial.NextToken, "2")
+	if !page.HasMore || len(page.Changes) != 2 {
+		t.Fatalf("Expected a full page of 2 changes, got %+v", page)
+	}
+	if rest := getFavoriteChanges(t, router, ownerID, page.NextToken, "2"); rest.HasMore || len(rest.Changes) != 1 ||
+		rest.Changes[0].Type != outbox.TypeFavoriteDeleted {
+		t.Errorf("Expected the deletion to follow on the next page, got %+v", rest)
+	}
+
+	expired, _ := pagetoken.NewSigner([]pagetoken.Key{{ID: "test", Secret: []byte("secret")}}, -time.Hour)
+	stale := expired.Encode(&repository.Cursor{Seq: 1}, pagetoken.DirectionAfter, pagetoken.Scope{
+		"project_id": testProjectID.String(),
+		"changes":    "USER:" + ownerID.String(),
+	})
+	if response := getFavoriteChanges(t, router, ownerID, stale, ""); !response.Resync {
+		t.Errorf("Expected a token older than the retention to ask for a resync, got %+v", response)
+	}
+	otherID := uuid.New()
+	foreign := getFavoriteChanges(t, router, otherID, "", "").NextToken
+	forged := fmt.Sprintf("1.%d", time.Now().Unix())
+	for _, since := range []string{"garbage", forged, foreign} {
+		query := url.Values{"owner_type": {"USER"}, "owner_id": {ownerID.String()}, "since": {since}}
+		req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/favorites/changes?"+query.Encode()), nil)
+		req.Header.Set("Authorization", bearer("USER", ownerID))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for token %q, got %d", http.StatusBadRequest, since, w.Code)
+		}
+	}
+}
+
+func TestPushFavoriteChanges(t *testing.T) {
+	router := newRouter()
+	ownerID := uuid.New()
+	kept := createFavorite(t, router, ownerID)
+	removed := createFavorite(t, router, ownerID)
+	offline := time.Now().Add(-time.Hour)
+	added := uuid.New()
+	w := pushFavoriteChanges(router, ownerID, ownerID, []map[string]any{
+		// Made offline before the server created kept, so the server wins.
+		{"type": "favorite.deleted", "object_type": "IMAGE", "object_id": kept.ObjectID, "changed_at": offline},
+		{"type": "favorite.created", "object_type": "VIDEO", "object_id": added, "changed_at": offline, "note": "later"},
+		{"type": "favorite.deleted", "object_type": "VIDEO", "object_id": added, "changed_at": offline.Add(time.Minute)},
+		{"type": "favorite.created", "object_type": "VIDEO", "object_id": added, "changed_at": offline.Add(2 * time.Minute)},
+		// Both dated in the future, so both count as made now.
+		{"type": "favorite.deleted", "object_type": "IMAGE", "object_id": removed.ObjectID, "changed_at": time.Now().Add(time.Hour)},
+		{"type": "favorite.deleted", "object_type": "IMAGE", "object_id": removed.ObjectID, "changed_at": time.Now().Add(2 * time.Hour)},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response dto.PushFavoriteChangesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode results: %v", err)
+	}
+	expected := []string{"conflict", "applied", "applied", "applied", "applied", "unchanged"}
+	if len(response.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %+v", len(expected), response.Results)
+	}
+	for i, result := range response.Results {
+		if result.Status != expected[i] {
+			t.Errorf("Expected mutation %d to be %s, got %s", i, expected[i], result.Status)
+		}
+	}
+	if conflict := response.Results[0].Favorite; conflict == nil || conflict.ID != kept.ID {
+		t.Errorf("Expected the conflict to carry favorite %s, got %+v", kept.ID, conflict)
+	}
+	if created := response.Results[3].Favorite; created == nil || created.ObjectID != added {
+		t.Errorf("Expected the favorite of object %s to be created again, got %+v", added, created)
+	}
+	manual := getManualOrder(t, router, ownerID)
+	if len(manual) != 2 || !slices.Contains(manual, response.Results[3].Favorite.ID) {
+		t.Errorf("Expected the owner to keep 2 favorites including the pushed one, got %v", manual)
+	}
+
+	if w = pushFavoriteChanges(router, ownerID, uuid.New(), []map[string]any{
+		{"type": "favorite.created", "object_type": "IMAGE", "object_id": uuid.New(), "changed_at": offline},
+	}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if w = pushFavoriteChanges(router, ownerID, ownerID, []map[string]any{
+		{"type": "favorite.moved", "object_type": "IMAGE", "object_id": uuid.New(), "changed_at": offline},
+	}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown mutation, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestConcurrentPushesKeepTheLastWriter(t *testing.T) {
+	router := newRouter()
+	ownerID, objectID := uuid.New(), uuid.New()
+	offline := time.Now().Add(-time.Hour)
+	var wg sync.WaitGroup
+	for _, m := range []map[string]any{
+		{"type": "favorite.deleted", "object_type": "IMAGE", "object_id": objectID, "changed_at": offline},
+		{"type": "favorite.created", "object_type": "IMAGE", "object_id": objectID, "changed_at": offline.Add(time.Minute)},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := pushFavoriteChanges(router, ownerID, ownerID, []map[string]any{m}); w.Code != http.StatusOK {
+				t.Errorf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+			}
+		}()
+	}
+	wg.Wait()
+	if manual := getManualOrder(t, router, ownerID); len(manual) != 1 {
+		t.Errorf("Expected the later creation to win whatever the order of the pushes, got %v", manual)
+	}
+}
@@ -0,0 +1,232 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"favorites/internal/auth"
+	"favorites/internal/dispatch"
+	"favorites/internal/models/apikey"
+	"favorites/internal/models/outbox"
+	"favorites/internal/models/webhook"
+	"favorites/internal/relay"
+	"favorites/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "0123456789abcdef"
+
+func newAdminToken(t *testing.T, storage repository.Storage) string {
+	t.Helper()
+	admin, secret := auth.NewAPIKey(testProjectID, "webhooks", []apikey.Scope{apikey.ScopeAdmin}, nil)
+	if err := storage.APIKeys.CreateAPIKey(context.Background(), &admin); err != nil {
+		t.Fatalf("Failed to create admin key: %v", err)
+	}
+	return auth.APIKeyToken(admin, secret)
+}
+
+func createWebhook(t *testing.T, router *gin.Engine, token string, requestBody map[string]any) webhook.Subscription {
+	t.Helper()
+	body, _ := json.Marshal(requestBody)
+	req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/webhooks"), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := withAPIKey(router, req, token)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	var s webhook.Subscription
+	if err := json.Unmarshal(w.Body.Bytes(), &s); err != nil {
+		t.Fatalf("Failed to decode webhook: %v", err)
+	}
+	return s
+}
+
+func getWebhookDeliveries(t *testing.T, router *gin.Engine, token string, s webhook.Subscription) []webhook.Delivery {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, projectURL(testProjectID, "/webhooks/"+s.ID.String()+"/deliveries"), nil)
+	w := withAPIKey(router, req, token)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var deliveries []webhook.Delivery
+	if err := json.Unmarshal(w.Body.Bytes(), &deliveries); err != nil {
+		t.Fatalf("Failed to decode deliveries: %v", err)
+	}
+	return deliveries
+}
+
+// relayToWebhooks fans the pending outbox messages out to the subscriptions.
+func relayToWebhooks(t *testing.T, storage repository.Storage) {
+	t.Helper()
+	publisher := relay.Publishers{dispatch.NewFanout(storage.Webhooks)}
+	if _, err := relay.NewRelay(storage.Outbox, publisher, 10, time.Hour, time.Hour).RelayOnce(context.Background()); err != nil {
+		t.Fatalf("Failed to relay: %v", err)
+	}
+}
+
+func newTestDispatcher(storage repository.Storage, backoff time.Duration) *dispatch.Dispatcher {
+	return dispatch.NewDispatcher(storage.Webhooks, dispatch.Options{
+		MaxAttempts: 3,
+		Backoff:     backoff,
+		MaxBackoff:  2 * backoff,
+		Timeout:     time.Second,
+		BatchSize:   10,
+		Interval:    time.Hour,
+		Retention:   time.Hour,
+	})
+}
+
+func TestWebhookDeliveriesAreSigned(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+
+	var mu sync.Mutex
+	var events []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, signature, _ := strings.Cut(strings.TrimPrefix(r.Header.Get(dispatch.SignatureHeader), "t="), ",v1=")
+		mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+		mac.Write([]byte(timestamp + "." + string(body)))
+		if !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+			t.Errorf("Expected a valid signature, got %q", r.Header.Get(dispatch.SignatureHeader))
+		}
+		var m outbox.Message
+		if err := json.Unmarshal(body, &m); err != nil || string(m.Type) != r.Header.Get("X-Webhook-Event") {
+			t.Errorf("Expected the body to be the %s message, got %s", r.Header.Get("X-Webhook-Event"), body)
+		}
+		mu.Lock()
+		events = append(events, r.Header.Get("X-Webhook-Event"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+	all := createWebhook(t, router, token, map[string]any{"url": server.URL, "secret": testWebhookSecret})
+	deletions := createWebhook(t, router, token, map[string]any{
+		"url":          server.URL,
+		"secret":       testWebhookSecret,
+		"events":       []string{"favorite.deleted"},
+		"object_types": []string{"IMAGE"},
+	})
+	videos := createWebhook(t, router, token, map[string]any{
+		"url":          server.URL,
+		"secret":       testWebhookSecret,
+		"object_types": []string{"VIDEO"},
+	})
+
+	f := createFavorite(t, router, uuid.New())
+	moveFavorite(router, f, map[string]any{"pinned": true})
+	deleteFavorite(router, testProjectID, f)
+	relayToWebhooks(t, storage)
+	relayToWebhooks(t, storage)
+	attempted, err := newTestDispatcher(storage, time.Minute).DispatchOnce(context.Background())
+	if err != nil || attempted != 3 {
+		t.Fatalf("Expected 3 deliveries to be attempted, got %d, %v", attempted, err)
+	}
+	if len(events) != 3 {
+		t.Errorf("Expected the server to receive 3 deliveries, got %v", events)
+	}
+	for s, expected := range map[*webhook.Subscription]int{&all: 2, &deletions: 1, &videos: 0} {
+		deliveries := getWebhookDeliveries(t, router, token, *s)
+		if len(deliveries) != expected {
+			t.Errorf("Expected %d deliveries to %s, got %d", expected, s.ID, len(deliveries))
+		}
+		for _, d := range deliveries {
+			if d.Status != webhook.StatusDelivered || d.Attempts != 1 || d.DeliveredAt == nil {
+				t.Errorf("Expected delivery %s to be delivered at the first attempt, got %+v", d.ID, d)
+			}
+		}
+	}
+
+	for _, requestBody := range []map[string]any{
+		{"url": "ftp://example.com", "secret": testWebhookSecret},
+		{"url": "/hooks", "secret": testWebhookSecret},
+		{"url": server.URL, "secret": "short"},
+		{"url": server.URL, "secret": testWebhookSecret, "events": []string{"favorite.moved"}},
+		{"url": server.URL, "secret": testWebhookSecret, "object_types": []string{"AUDIO"}},
+	} {
+		body, _ := json.Marshal(requestBody)
+		req := httptest.NewRequest(http.MethodPost, projectURL(testProjectID, "/webhooks"), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if w := withAPIKey(router, req, token); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %v to be rejected, got %d", requestBody, w.Code)
+		}
+	}
+}
+
+func TestWebhookRetriesAndRedelivery(t *testing.T) {
+	storage := repository.NewMemoryStorage()
+	router := newRouterWithStorage(storage)
+	token := newAdminToken(t, storage)
+
+	var mu sync.Mutex
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	s := createWebhook(t, router, token, map[string]any{"url": server.URL, "secret": testWebhookSecret})
+	createFavorite(t, router, uuid.New())
+	relayToWebhooks(t, storage)
+
+	backoff := 100 * time.Millisecond
+	dispatcher := newTestDispatcher(storage, backoff)
+	for attempt := 1; attempt <= 3; attempt++ {
+		if attempted, err := dispatcher.DispatchOnce(context.Background()); err != nil || attempted != 1 {
+			t.Fatalf("Expected attempt %d to be made, got %d, %v", attempt, attempted, err)
+		}
+		if attempted, _ := dispatcher.DispatchOnce(context.Background()); attempted != 0 {
+			t.Fatalf("Expected no attempt before the backoff after attempt %d is over", attempt)
+		}
+		time.Sleep(backoff * time.Duration(attempt))
+	}
+	deliveries := getWebhookDeliveries(t, router, token, s)
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(deliveries))
+	}
+	d := deliveries[0]
+	if d.Status != webhook.StatusDead || d.Attempts != 3 || d.LastStatusCode == nil || *d.LastStatusCode != status {
+		t.Fatalf("Expected the delivery to be dead after 3 attempts answered with %d, got %+v", status, d)
+	}
+
+	redeliver := func() *httptest.ResponseRecorder {
+		path := "/webhooks/" + s.ID.String() + "/deliveries/" + d.ID.String() + "/redeliver"
+		return withAPIKey(router, httptest.NewRequest(http.MethodPost, projectURL(testProjectID, path), nil), token)
+	}
+	if w := redeliver(); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body)
+	}
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+	if attempted, err := dispatcher.DispatchOnce(context.Background()); err != nil || attempted != 1 {
+		t.Fatalf("Expected the redelivery to be attempted, got %d, %v", attempted, err)
+	}
+	d = getWebhookDeliveries(t, router, token, s)[0]
+	if d.Status != webhook.StatusDelivered || d.Attempts != 1 {
+		t.Errorf("Expected the redelivery to succeed at the first attempt, got %+v", d)
+	}
+	if w := redeliver(); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, projectURL(testProjectID, "/webhooks/"+s.ID.String()), nil)
+	if w := withAPIKey(router, req, token); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w := redeliver(); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}